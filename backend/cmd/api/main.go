@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/ieeemumsb/Sinepsis/backend/internal/api"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/embedcache"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
 	auth "github.com/ieeemumsb/Sinepsis/backend/internal/service"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/documents"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/gamestats"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/spellgraph"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/token"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/wikisync"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/rs/cors"
@@ -43,18 +52,62 @@ func main() {
 		log.Fatal("Failed to create uploads directory:", err)
 	}
 
+	tokenConfig, err := token.LoadConfig()
+	if err != nil {
+		log.Fatal("Invalid JWT configuration:", err)
+	}
+
+	tokenManager, err := token.NewManager(tokenConfig)
+	if err != nil {
+		log.Fatal("Failed to set up JWT signing:", err)
+	}
+
+	provider, err := llm.FromEnv(context.Background())
+	if err != nil {
+		log.Fatal("Failed to set up LLM provider:", err)
+	}
+
 	queries := db.New(dbConn)
 
+	embedCache, err := embedcache.FromEnv(provider, queries)
+	if err != nil {
+		log.Fatal("Invalid embedding cache configuration:", err)
+	}
+
 	authService := auth.New(queries)
-	calendarService := calendar.New(queries)
-	mysticService := mystic.New(queries)
+	calendarService := calendar.New(dbConn)
+	retrievalEngine := retrieval.New(queries, embedCache)
+	if err := retrievalEngine.CheckModel(context.Background()); err != nil {
+		log.Println("Warning:", err)
+	}
+	mysticService := mystic.New(dbConn, calendarService, retrievalEngine, provider, spellgraph.NewLoader(queries))
 	gameStatsService := gamestats.New(queries)
+	documentService, err := documents.New(dbConn, provider, provider)
+	if err != nil {
+		log.Fatal("Invalid document configuration:", err)
+	}
+
+	// WIKI_REFRESH_INTERVAL turns on the background wiki refresher
+	if v := os.Getenv("WIKI_REFRESH_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatal("Invalid WIKI_REFRESH_INTERVAL:", v)
+		}
+		refresher, err := wikisync.New(dbConn, provider)
+		if err != nil {
+			log.Fatal("Invalid wiki refresh configuration:", err)
+		}
+		go refresher.Schedule(context.Background(), interval)
+	}
 
 	server := api.NewServer(
 		authService,
 		calendarService,
 		mysticService,
 		gameStatsService,
+		documentService,
+		embedCache,
+		tokenManager,
 	)
 
 	log.Println("Server starting on port 8080")
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/rageval"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/spellgraph"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// rageval scores retrieval and answers against a golden set of questions.
+// Each configuration file (see rageval.Config) is evaluated in turn; giving
+// two prints them side by side. Without one the environment is evaluated
+// as is.
+func main() {
+	golden := flag.String("golden", "cmd/rageval/golden.example.yaml", "golden set, YAML or JSON")
+	configA := flag.String("a", "", "first configuration file")
+	configB := flag.String("b", "", "second configuration file, compared against the first")
+	k := flag.Int("k", 5, "retrieved spells scored for recall")
+	retrievalOnly := flag.Bool("retrieval-only", false, "skip answer generation and fact coverage")
+	verbose := flag.Bool("v", false, "print per-question scores and missing facts")
+	asJSON := flag.Bool("json", false, "print the reports as JSON")
+	flag.Parse()
+
+	if *k <= 0 {
+		log.Fatal("-k must be positive")
+	}
+	if *configB != "" && *configA == "" {
+		log.Fatal("-b needs -a to compare against")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file, using the environment")
+	}
+
+	set, err := rageval.LoadGoldenSet(*golden)
+	if err != nil {
+		log.Fatal("Failed to load golden set:", err)
+	}
+
+	configs := []*rageval.Config{{Name: "env"}}
+	if *configA != "" {
+		configs = configs[:0]
+		for _, path := range []string{*configA, *configB} {
+			if path == "" {
+				continue
+			}
+			cfg, err := rageval.LoadConfig(path)
+			if err != nil {
+				log.Fatal("Failed to load configuration:", err)
+			}
+			configs = append(configs, cfg)
+		}
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL not set in environment")
+	}
+	dbConn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var reports []*rageval.Report
+	for _, cfg := range configs {
+		log.Printf("Evaluating %s on %d questions", cfg.Name, len(set.Questions))
+		report, err := evaluate(ctx, dbConn, set, cfg, *k, !*retrievalOnly)
+		if err != nil {
+			log.Fatalf("Evaluating %s failed: %v", cfg.Name, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := rageval.Print(os.Stdout, *verbose, reports...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// evaluate builds the provider and services under the configuration's
+// environment and runs the golden set through them
+func evaluate(ctx context.Context, dbConn *sql.DB, set *rageval.GoldenSet, cfg *rageval.Config, k int, generate bool) (*rageval.Report, error) {
+	restore := setenv(cfg.Env)
+	defer restore()
+
+	provider, err := llm.FromEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up LLM provider: %w", err)
+	}
+	queries := db.New(dbConn)
+	engine := retrieval.New(queries, provider)
+	if err := engine.CheckModel(ctx); err != nil {
+		log.Printf("warning: %s: %v", cfg.Name, err)
+	}
+	service := mystic.New(dbConn, discardNotifier{}, engine, provider, spellgraph.NewLoader(queries))
+
+	// Access levels are left to the configuration's filters, so no spell is
+	// ever withheld from this caller or raises an alert
+	caller := mystic.Caller{Email: "rageval", Clearance: math.MaxInt16}
+	opts := cfg.Options(k)
+
+	return rageval.Evaluate(ctx, cfg.Name, set, k, func(ctx context.Context, question string) (rageval.Outcome, error) {
+		var out rageval.Outcome
+		var hits []mystic.SearchResult
+		if generate {
+			resp, err := service.QuerySpells(ctx, caller, question, opts)
+			if err != nil {
+				return out, err
+			}
+			hits, out.Answer = resp.Results, resp.Answer
+		} else {
+			var err error
+			if hits, err = engine.Search(ctx, question, opts); err != nil {
+				return out, err
+			}
+		}
+		for _, h := range hits {
+			out.Retrieved = append(out.Retrieved, h.PageID)
+		}
+		return out, nil
+	})
+}
+
+// setenv applies overrides and returns a func putting the old values back
+func setenv(overrides map[string]string) func() {
+	type saved struct {
+		value string
+		set   bool
+	}
+	old := make(map[string]saved, len(overrides))
+	for name, value := range overrides {
+		v, ok := os.LookupEnv(name)
+		old[name] = saved{v, ok}
+		os.Setenv(name, value)
+	}
+	return func() {
+		for name, s := range old {
+			if s.set {
+				os.Setenv(name, s.value)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+// discardNotifier stands in for the calendar service; an evaluation run never
+// notifies anyone
+type discardNotifier struct{}
+
+func (discardNotifier) CreateNotification(context.Context, uuid.UUID, uuid.NullUUID, db.NotificationTypeEnum, string) (db.Notification, error) {
+	return db.Notification{}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/reindex"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// reindex re-embeds every spell with the model configured for the API (see
+// llm.FromEnv) and switches retrieval over once all spells are covered. It
+// can be interrupted and run again to resume.
+func main() {
+	batchSize := flag.Int("batch", 32, "spells embedded per API call")
+	interval := flag.Duration("interval", time.Second, "minimum time between embedding calls")
+	restart := flag.Bool("restart", false, "discard the checkpoint and re-embed every spell")
+	noSwitch := flag.Bool("no-switch", false, "embed without switching retrieval over")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file, using the environment")
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL not set in environment")
+	}
+
+	dbConn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	provider, err := llm.FromEnv(ctx)
+	if err != nil {
+		log.Fatal("Failed to set up LLM provider:", err)
+	}
+
+	job := reindex.New(dbConn, provider)
+	job.BatchSize = *batchSize
+	job.Interval = *interval
+	job.Restart = *restart
+	job.Switch = !*noSwitch
+
+	if err := job.Run(ctx); err != nil {
+		log.Fatal("Reindex failed:", err)
+	}
+}
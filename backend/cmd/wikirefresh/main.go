@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/wikisync"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// wikirefresh re-fetches the spells whose wiki page changed since they were
+// scraped. It runs one pass and exits, or keeps refreshing with -every.
+func main() {
+	every := flag.Duration("every", 0, "refresh on this interval instead of running once")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file, using the environment")
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Fatal("DATABASE_URL not set in environment")
+	}
+
+	dbConn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	provider, err := llm.FromEnv(ctx)
+	if err != nil {
+		log.Fatal("Failed to set up LLM provider:", err)
+	}
+
+	refresher, err := wikisync.New(dbConn, provider)
+	if err != nil {
+		log.Fatal("Invalid wiki refresh configuration:", err)
+	}
+
+	if *every > 0 {
+		refresher.Schedule(ctx, *every)
+		return
+	}
+
+	res, err := refresher.Run(ctx)
+	if err != nil {
+		log.Fatal("Refresh failed:", err)
+	}
+	log.Printf("Checked %d spells: %d updated, %d re-embedded, %d missing, %d failed",
+		res.Checked, res.Updated, res.Reembedded, res.Missing, res.Failed)
+	if res.Failed > 0 {
+		os.Exit(1)
+	}
+}
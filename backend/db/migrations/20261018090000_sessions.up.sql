@@ -0,0 +1,22 @@
+BEGIN;
+
+-- One row per refresh token. Rotating a token marks the old row as used and
+-- inserts a new row in the same family; family_id identifies the login
+-- session and is carried in the access token as "sid".
+CREATE TABLE IF NOT EXISTS sessions (
+  id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  family_id           UUID NOT NULL,
+  refresh_token_hash  TEXT NOT NULL UNIQUE,
+  user_agent          TEXT,
+  ip_address          TEXT,
+  expires_at          TIMESTAMPTZ NOT NULL,
+  used_at             TIMESTAMPTZ,           -- set once the token has been rotated
+  revoked_at          TIMESTAMPTZ,
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_squads_commander_id;
+DROP INDEX IF EXISTS idx_users_squad_id;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS squad_id,
+  DROP COLUMN IF EXISTS role;
+
+DROP TABLE IF EXISTS squads;
+
+DROP TYPE IF EXISTS user_role_enum;
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TYPE user_role_enum AS ENUM ('agent', 'commander', 'admin');
+
+CREATE TABLE IF NOT EXISTS squads (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name          TEXT NOT NULL,
+  commander_id  UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS role user_role_enum NOT NULL DEFAULT 'agent',
+  ADD COLUMN IF NOT EXISTS squad_id UUID REFERENCES squads(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_squad_id ON users (squad_id);
+CREATE INDEX IF NOT EXISTS idx_squads_commander_id ON squads (commander_id);
+
+COMMIT;
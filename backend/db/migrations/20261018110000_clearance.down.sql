@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS clearance_level;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Compared against spells.access_level; spells above a user's clearance are
+-- hidden from them and raise a high_threat_alert when matched
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS clearance_level SMALLINT NOT NULL DEFAULT 0;
+
+COMMIT;
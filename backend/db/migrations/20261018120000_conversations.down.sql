@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS conversation_messages;
+DROP TABLE IF EXISTS conversations;
+DROP TYPE IF EXISTS message_role_enum;
+
+COMMIT;
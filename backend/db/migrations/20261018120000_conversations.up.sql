@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TYPE message_role_enum AS ENUM ('user', 'assistant');
+
+CREATE TABLE IF NOT EXISTS conversations (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  title       TEXT NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- retrieval_query is the standalone query a user turn was rewritten into;
+-- cited_pageids are the spells an assistant turn cited, so follow-ups can be
+-- grounded on them again
+CREATE TABLE IF NOT EXISTS conversation_messages (
+  id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  conversation_id  UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+  role             message_role_enum NOT NULL,
+  content          TEXT NOT NULL,
+  retrieval_query  TEXT,
+  cited_pageids    INTEGER[] NOT NULL DEFAULT '{}',
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversations_user_id ON conversations (user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_conversation_id ON conversation_messages (conversation_id, created_at);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_spells_alias_text_trgm;
+DROP INDEX IF EXISTS idx_spells_title_trgm;
+DROP INDEX IF EXISTS idx_spells_search_tsv;
+
+ALTER TABLE spells
+  DROP COLUMN IF EXISTS search_tsv,
+  DROP COLUMN IF EXISTS alias_text;
+
+DROP FUNCTION IF EXISTS spell_search_document(TEXT, TEXT, TEXT[], TEXT[]);
+DROP FUNCTION IF EXISTS spell_aliases_text(TEXT[]);
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- array_to_string is only STABLE, so generated columns go through these
+-- IMMUTABLE wrappers
+CREATE OR REPLACE FUNCTION spell_aliases_text(aliases TEXT[])
+RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT COALESCE(array_to_string(aliases, ' '), '') $$;
+
+CREATE OR REPLACE FUNCTION spell_search_document(title TEXT, summary TEXT, aliases TEXT[], categories TEXT[])
+RETURNS tsvector
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$
+  SELECT setweight(to_tsvector('english', COALESCE(title, '')), 'A')
+      || setweight(to_tsvector('english', COALESCE(array_to_string(aliases, ' '), '')), 'A')
+      || setweight(to_tsvector('english', COALESCE(array_to_string(categories, ' '), '')), 'C')
+      || setweight(to_tsvector('english', COALESCE(summary, '')), 'B')
+$$;
+
+ALTER TABLE spells
+  ADD COLUMN IF NOT EXISTS alias_text TEXT
+    GENERATED ALWAYS AS (spell_aliases_text(aliases)) STORED,
+  ADD COLUMN IF NOT EXISTS search_tsv tsvector
+    GENERATED ALWAYS AS (spell_search_document(title, summary, aliases, categories)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_spells_search_tsv ON spells USING GIN (search_tsv);
+CREATE INDEX IF NOT EXISTS idx_spells_title_trgm ON spells USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_spells_alias_text_trgm ON spells USING GIN (alias_text gin_trgm_ops);
+
+COMMIT;
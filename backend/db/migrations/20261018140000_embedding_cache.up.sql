@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Query embeddings keyed by a hash of the embedding model and the normalized
+-- query text, so repeated queries skip the embedding API across restarts
+CREATE TABLE IF NOT EXISTS embedding_cache (
+  cache_key   TEXT PRIMARY KEY,
+  model       TEXT NOT NULL,
+  embedding   REAL[] NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_cache_created_at ON embedding_cache (created_at);
+
+COMMIT;
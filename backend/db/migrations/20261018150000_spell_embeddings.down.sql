@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS spell_embedding_model;
+DROP TABLE IF EXISTS reindex_jobs;
+DROP TABLE IF EXISTS spell_embeddings;
+
+ALTER TABLE spells
+  ALTER COLUMN embedding TYPE vector(768) USING embedding::vector(768);
+
+COMMIT;
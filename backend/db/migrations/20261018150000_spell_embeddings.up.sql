@@ -0,0 +1,40 @@
+BEGIN;
+
+-- Drop the fixed dimension so a model with a different output size can be
+-- switched in
+ALTER TABLE spells
+  ALTER COLUMN embedding TYPE vector USING embedding::vector;
+
+-- Vectors computed by the reindex job, one set per embedding model. They are
+-- copied into spells.embedding only once a model covers every spell.
+CREATE TABLE IF NOT EXISTS spell_embeddings (
+  pageid      INTEGER NOT NULL REFERENCES spells(pageid) ON DELETE CASCADE,
+  model       TEXT NOT NULL,
+  embedding   vector NOT NULL,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (pageid, model)
+);
+
+-- Checkpoint of each model's reindex run; last_pageid is where a resumed
+-- run continues from
+CREATE TABLE IF NOT EXISTS reindex_jobs (
+  model         TEXT PRIMARY KEY,
+  last_pageid   INTEGER NOT NULL DEFAULT 0,
+  embedded      INTEGER NOT NULL DEFAULT 0,
+  started_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  completed_at  TIMESTAMPTZ
+);
+
+-- The model spells.embedding currently holds. NULL means the vectors came
+-- from the original scraper.
+CREATE TABLE IF NOT EXISTS spell_embedding_model (
+  id           BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  model        TEXT,
+  switched_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO spell_embedding_model (id, model) VALUES (TRUE, NULL)
+ON CONFLICT (id) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS spell_changes;
+DROP TYPE IF EXISTS spell_change_kind_enum;
+
+COMMIT;
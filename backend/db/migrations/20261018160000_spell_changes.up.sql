@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TYPE spell_change_kind_enum AS ENUM ('updated', 'missing');
+
+-- One row per wiki revision the refresher picked up. changed_fields names
+-- the spell columns the revision altered; a page deleted from the wiki is
+-- logged as missing and left in place.
+CREATE TABLE IF NOT EXISTS spell_changes (
+  id              BIGSERIAL PRIMARY KEY,
+  pageid          INTEGER NOT NULL,
+  kind            spell_change_kind_enum NOT NULL,
+  old_rev_id      BIGINT,
+  new_rev_id      BIGINT,
+  changed_fields  TEXT[] NOT NULL DEFAULT '{}',
+  reembedded      BOOLEAN NOT NULL DEFAULT FALSE,
+  changed_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spell_changes_pageid ON spell_changes (pageid, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_spell_changes_changed_at ON spell_changes (changed_at DESC);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_chunks_document_id;
+DROP INDEX IF EXISTS idx_documents_owner_id;
+
+ALTER TABLE chunks ALTER COLUMN id DROP DEFAULT;
+ALTER TABLE documents ALTER COLUMN id DROP DEFAULT;
+
+ALTER TABLE documents
+  DROP COLUMN IF EXISTS embedding_model,
+  DROP COLUMN IF EXISTS chunk_count,
+  DROP COLUMN IF EXISTS size_bytes,
+  DROP COLUMN IF EXISTS owner_id;
+
+COMMIT;
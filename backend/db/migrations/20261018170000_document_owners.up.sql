@@ -0,0 +1,19 @@
+BEGIN;
+
+-- Documents uploaded through the Go API belong to a user. Rows written by the
+-- Python RAGService have no owner and are only visible to admins.
+-- embedding_model is the vector space of the document's chunks, so chat never
+-- compares vectors from different models.
+ALTER TABLE documents
+  ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS size_bytes BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS chunk_count INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS embedding_model TEXT;
+
+ALTER TABLE documents ALTER COLUMN id SET DEFAULT gen_random_uuid();
+ALTER TABLE chunks ALTER COLUMN id SET DEFAULT gen_random_uuid();
+
+CREATE INDEX IF NOT EXISTS idx_documents_owner_id ON documents (owner_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_chunks_document_id ON chunks (document_id, chunk_index);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_documents_mission_id;
+
+ALTER TABLE documents
+  DROP COLUMN IF EXISTS attachment_id,
+  DROP COLUMN IF EXISTS mission_id;
+
+COMMIT;
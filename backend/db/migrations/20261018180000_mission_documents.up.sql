@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Text-bearing mission attachments are indexed as documents linked back to
+-- their mission, so questions about a mission can be answered from them
+ALTER TABLE documents
+  ADD COLUMN IF NOT EXISTS mission_id UUID REFERENCES missions(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS attachment_id UUID UNIQUE REFERENCES mission_attachments(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_documents_mission_id ON documents (mission_id);
+
+COMMIT;
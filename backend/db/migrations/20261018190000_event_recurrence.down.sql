@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS calendar_event_overrides;
+
+ALTER TABLE calendar_events
+  DROP COLUMN IF EXISTS series_end,
+  DROP COLUMN IF EXISTS timezone,
+  DROP COLUMN IF EXISTS rrule;
+
+COMMIT;
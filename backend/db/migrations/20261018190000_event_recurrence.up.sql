@@ -0,0 +1,29 @@
+BEGIN;
+
+-- Recurring events carry an RFC 5545 RRULE expanded in their timezone, so a
+-- weekly 09:00 meeting stays at 09:00 across DST changes. series_end is the
+-- latest end of any occurrence, NULL while the rule is unbounded, and lets
+-- range queries skip finished series.
+ALTER TABLE calendar_events
+  ADD COLUMN IF NOT EXISTS rrule TEXT,
+  ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC',
+  ADD COLUMN IF NOT EXISTS series_end TIMESTAMPTZ;
+
+-- A single occurrence edited or cancelled on its own. original_start is the
+-- occurrence's start before the edit (its RECURRENCE-ID); NULL title and
+-- description inherit from the series. Cancelled rows are the EXDATEs.
+CREATE TABLE IF NOT EXISTS calendar_event_overrides (
+  id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  event_id       UUID NOT NULL REFERENCES calendar_events(id) ON DELETE CASCADE,
+  original_start TIMESTAMPTZ NOT NULL,
+  title          TEXT,
+  description    TEXT,
+  start_time     TIMESTAMPTZ NOT NULL,
+  end_time       TIMESTAMPTZ,
+  cancelled      BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (event_id, original_start)
+);
+
+COMMIT;
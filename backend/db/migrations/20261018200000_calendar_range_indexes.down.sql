@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_missions_user_start;
+DROP INDEX IF EXISTS idx_calendar_events_user_start;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Range queries and keyset pagination walk a user's events and missions by
+-- start time
+CREATE INDEX IF NOT EXISTS idx_calendar_events_user_start ON calendar_events (user_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_missions_user_start ON missions (user_id, start_time);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS calendar_feed_tokens;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Subscription feeds are fetched by calendar apps that cannot send a JWT, so
+-- each feed URL carries its own token. Only its SHA-256 is stored, as for
+-- refresh tokens; revoking a token cuts off every app using that URL.
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  token_hash    TEXT NOT NULL UNIQUE,
+  name          TEXT,
+  last_used_at  TIMESTAMPTZ,
+  revoked_at    TIMESTAMPTZ,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_feed_tokens_user_id ON calendar_feed_tokens (user_id);
+
+COMMIT;
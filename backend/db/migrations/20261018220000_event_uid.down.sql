@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_calendar_events_user_uid;
+
+ALTER TABLE calendar_events
+  DROP COLUMN IF EXISTS uid;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- The UID of an event imported from an .ics file, so importing the file
+-- again updates the event instead of adding a copy. Events created here
+-- have none and are exported as <id>@sinepsis.
+ALTER TABLE calendar_events
+  ADD COLUMN IF NOT EXISTS uid TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_events_user_uid
+  ON calendar_events (user_id, uid)
+  WHERE uid IS NOT NULL;
+
+COMMIT;
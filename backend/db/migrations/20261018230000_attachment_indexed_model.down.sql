@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE mission_attachments
+  DROP COLUMN IF EXISTS indexed_model;
+
+COMMIT;
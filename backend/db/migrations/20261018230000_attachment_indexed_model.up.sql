@@ -0,0 +1,9 @@
+BEGIN;
+
+-- The embedding model an attachment was last indexed under, set even when
+-- the file held no text, so such files are not read again on every question
+-- until the model changes
+ALTER TABLE mission_attachments
+  ADD COLUMN IF NOT EXISTS indexed_model TEXT;
+
+COMMIT;
@@ -1,6 +1,6 @@
 -- name: CreateCalendarEvent :one
-INSERT INTO calendar_events (user_id, title, description, start_time, end_time)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO calendar_events (user_id, title, description, start_time, end_time, rrule, timezone, series_end, uid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetCalendarEventByID :one
@@ -8,14 +8,42 @@ SELECT * FROM calendar_events
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetEventByUID :one
+SELECT * FROM calendar_events
+WHERE user_id = $1 AND uid = $2;
+
 -- name: GetEventsByUser :many
 SELECT * FROM calendar_events
 WHERE user_id = $1
 ORDER BY start_time ASC;
 
+-- name: ListEventsPage :many
+-- Keyset pagination on (start_time, id): after_start/after_id are the key of
+-- the last event on the previous page, NULL for the first page
+SELECT * FROM calendar_events
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(after_id)::uuid IS NULL
+       OR (start_time, id) > (sqlc.narg(after_start)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY start_time ASC, id ASC
+LIMIT sqlc.arg(lim);
+
+-- name: GetEventsInRange :many
+-- Events that may have an occurrence overlapping [range_start, range_end):
+-- single events by their own times, series by series_end since an edited
+-- occurrence can move before the series start
+SELECT * FROM calendar_events
+WHERE user_id = sqlc.arg(user_id)
+  AND (
+    (rrule IS NULL AND start_time < sqlc.arg(range_end)
+      AND COALESCE(end_time, start_time) >= sqlc.arg(range_start)::timestamptz)
+    OR (rrule IS NOT NULL AND (series_end IS NULL OR series_end >= sqlc.arg(range_start)::timestamptz))
+  )
+ORDER BY start_time ASC;
+
 -- name: UpdateCalendarEvent :one
 UPDATE calendar_events
-SET title = $2, description = $3, start_time = $4, end_time = $5, updated_at = NOW()
+SET title = $2, description = $3, start_time = $4, end_time = $5,
+    rrule = $6, timezone = $7, series_end = $8, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
@@ -27,3 +55,38 @@ WHERE id = $1;
 SELECT *
 FROM calendar_events
 ORDER BY start_time ASC;
+
+-- name: UpsertEventOverride :one
+INSERT INTO calendar_event_overrides (event_id, original_start, title, description, start_time, end_time, cancelled)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (event_id, original_start) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    cancelled = EXCLUDED.cancelled,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetEventOverride :one
+SELECT * FROM calendar_event_overrides
+WHERE event_id = $1 AND original_start = $2;
+
+-- name: ListEventOverrides :many
+SELECT * FROM calendar_event_overrides
+WHERE event_id = ANY(sqlc.arg(event_ids)::uuid[])
+ORDER BY original_start ASC;
+
+-- name: DeleteEventOverridesFrom :exec
+DELETE FROM calendar_event_overrides
+WHERE event_id = $1 AND original_start >= $2;
+
+-- name: DeleteEventOverrides :exec
+DELETE FROM calendar_event_overrides
+WHERE event_id = $1;
+
+-- name: ExtendSeriesEnd :exec
+-- Moves a bounded series' end out to cover an occurrence edited past it
+UPDATE calendar_events
+SET series_end = GREATEST(series_end, sqlc.arg(occurrence_end)::timestamptz)
+WHERE id = sqlc.arg(id) AND series_end IS NOT NULL;
@@ -0,0 +1,21 @@
+-- name: CreateFeedToken :one
+INSERT INTO calendar_feed_tokens (user_id, token_hash, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListFeedTokens :many
+SELECT * FROM calendar_feed_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: UseFeedToken :one
+-- Resolves an active token to its owner and records the fetch
+UPDATE calendar_feed_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL
+RETURNING user_id;
+
+-- name: RevokeFeedToken :execrows
+UPDATE calendar_feed_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
@@ -0,0 +1,42 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (user_id, title)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetConversationByID :one
+SELECT * FROM conversations
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListConversationsByUser :many
+SELECT * FROM conversations
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteConversation :exec
+DELETE FROM conversations
+WHERE id = $1;
+
+-- name: CreateConversationMessage :one
+INSERT INTO conversation_messages (conversation_id, role, content, retrieval_query, cited_pageids)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetConversationMessages :many
+SELECT * FROM conversation_messages
+WHERE conversation_id = $1
+ORDER BY created_at ASC;
+
+-- name: GetRecentConversationMessages :many
+SELECT * FROM (
+  SELECT * FROM conversation_messages
+  WHERE conversation_id = $1
+  ORDER BY created_at DESC
+  LIMIT $2
+) recent
+ORDER BY created_at ASC;
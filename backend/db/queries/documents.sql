@@ -0,0 +1,66 @@
+-- name: CreateDocument :one
+INSERT INTO documents (title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, doc_embedding)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, sqlc.arg(doc_embedding)::vector)
+RETURNING id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at;
+
+-- name: InsertChunk :exec
+INSERT INTO chunks (document_id, chunk_index, content, embedding)
+VALUES ($1, $2, $3, sqlc.arg(embedding)::vector);
+
+-- GetDocumentByID, ListDocuments and DeleteDocument leave out documents
+-- indexed from mission attachments, which are reached through their mission.
+
+-- name: GetDocumentByID :one
+SELECT id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at
+FROM documents
+WHERE id = $1 AND mission_id IS NULL
+LIMIT 1;
+
+-- name: ListDocuments :many
+-- A NULL owner lists every document, for admins
+SELECT id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at
+FROM documents
+WHERE mission_id IS NULL
+  AND (sqlc.narg(owner_id)::uuid IS NULL OR owner_id = sqlc.narg(owner_id)::uuid)
+ORDER BY created_at DESC;
+
+-- name: DeleteDocument :exec
+DELETE FROM documents
+WHERE id = $1 AND mission_id IS NULL;
+
+-- name: DeleteAttachmentDocument :exec
+DELETE FROM documents
+WHERE attachment_id = $1;
+
+-- name: ListUnindexedMissionAttachments :many
+-- Attachments not yet indexed under the given model, either never indexed or
+-- indexed before the embedding model changed. Files found to hold no text
+-- have no document and are told apart by indexed_model.
+SELECT a.* FROM mission_attachments a
+WHERE a.mission_id = $1
+  AND a.indexed_model IS DISTINCT FROM sqlc.arg(model)::text
+  AND NOT EXISTS (
+    SELECT 1 FROM documents d
+    WHERE d.attachment_id = a.id AND d.embedding_model = sqlc.arg(model)::text
+  )
+ORDER BY a.created_at;
+
+-- name: MarkAttachmentIndexed :exec
+UPDATE mission_attachments
+SET indexed_model = sqlc.arg(model)::text
+WHERE id = sqlc.arg(id);
+
+-- name: SearchChunks :many
+-- Nearest chunks by cosine distance, matching the ivfflat index.
+-- document_ids and owner_id narrow the search. A mission_id searches that
+-- mission's attachments only; without one attachments are left out.
+SELECT c.id, c.document_id, c.chunk_index, c.content, d.title, d.filename,
+       (c.embedding <=> sqlc.arg(embedding)::vector)::float8 AS distance
+FROM chunks c
+JOIN documents d ON d.id = c.document_id
+WHERE d.embedding_model = sqlc.arg(model)::text
+  AND (cardinality(sqlc.arg(document_ids)::uuid[]) = 0 OR c.document_id = ANY(sqlc.arg(document_ids)::uuid[]))
+  AND (sqlc.narg(owner_id)::uuid IS NULL OR d.owner_id = sqlc.narg(owner_id)::uuid)
+  AND d.mission_id IS NOT DISTINCT FROM sqlc.narg(mission_id)::uuid
+ORDER BY c.embedding <=> sqlc.arg(embedding)::vector
+LIMIT sqlc.arg(lim);
@@ -0,0 +1,13 @@
+-- name: GetCachedEmbedding :one
+SELECT embedding FROM embedding_cache
+WHERE cache_key = $1 AND created_at > $2;
+
+-- name: PutCachedEmbedding :exec
+INSERT INTO embedding_cache (cache_key, model, embedding)
+VALUES ($1, $2, $3)
+ON CONFLICT (cache_key) DO UPDATE
+SET embedding = EXCLUDED.embedding, created_at = NOW();
+
+-- name: DeleteExpiredEmbeddings :exec
+DELETE FROM embedding_cache
+WHERE created_at <= $1;
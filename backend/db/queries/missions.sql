@@ -13,6 +13,39 @@ SELECT * FROM missions
 WHERE user_id = $1
 ORDER BY start_time DESC;
 
+-- name: ListMissionsPage :many
+-- Newest first with keyset pagination on (start_time, id). include_squad
+-- adds the missions of members of squads the user commands. range_start and
+-- range_end keep the missions overlapping that window; NULL filters match
+-- every mission.
+SELECT * FROM missions
+WHERE (user_id = sqlc.arg(user_id)
+       OR (sqlc.arg(include_squad)::boolean AND user_id IN (
+         SELECT u.id FROM users u
+         JOIN squads s ON s.id = u.squad_id
+         WHERE s.commander_id = sqlc.arg(user_id))))
+  AND (sqlc.narg(range_end)::timestamptz IS NULL OR start_time < sqlc.narg(range_end)::timestamptz)
+  AND (sqlc.narg(range_start)::timestamptz IS NULL
+       OR COALESCE(end_time, start_time) >= sqlc.narg(range_start)::timestamptz)
+  AND (sqlc.narg(mission_type)::mission_type_enum IS NULL
+       OR mission_type = sqlc.narg(mission_type)::mission_type_enum)
+  AND (sqlc.narg(threat_level)::threat_level_enum IS NULL
+       OR threat_level = sqlc.narg(threat_level)::threat_level_enum)
+  AND (sqlc.narg(success)::boolean IS NULL OR success = sqlc.narg(success)::boolean)
+  AND (sqlc.narg(after_id)::uuid IS NULL
+       OR (start_time, id) < (sqlc.narg(after_start)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY start_time DESC, id DESC
+LIMIT sqlc.arg(lim);
+
+-- name: GetMissionsInRange :many
+-- Missions overlapping [range_start, range_end), a mission without an end
+-- counting as the instant it starts
+SELECT * FROM missions
+WHERE user_id = sqlc.arg(user_id)
+  AND start_time < sqlc.arg(range_end)::timestamptz
+  AND COALESCE(end_time, start_time) >= sqlc.arg(range_start)::timestamptz
+ORDER BY start_time ASC;
+
 -- name: UpdateMission :one
 UPDATE missions
 SET title = $2, description = $3, mission_type = $4,
@@ -0,0 +1,64 @@
+-- name: StartReindexJob :one
+INSERT INTO reindex_jobs (model)
+VALUES ($1)
+ON CONFLICT (model) DO UPDATE
+SET updated_at = NOW()
+RETURNING *;
+
+-- name: ResetReindexJob :one
+INSERT INTO reindex_jobs (model)
+VALUES ($1)
+ON CONFLICT (model) DO UPDATE
+SET last_pageid = 0, embedded = 0, started_at = NOW(), updated_at = NOW(), completed_at = NULL
+RETURNING *;
+
+-- name: AdvanceReindexJob :exec
+UPDATE reindex_jobs
+SET last_pageid = $2, embedded = embedded + $3, updated_at = NOW()
+WHERE model = $1;
+
+-- name: CompleteReindexJob :exec
+UPDATE reindex_jobs
+SET completed_at = NOW(), updated_at = NOW()
+WHERE model = $1;
+
+-- name: ListSpellsToEmbed :many
+SELECT s.pageid, s.title, s.summary, s.aliases, s.categories
+FROM spells s
+WHERE s.pageid > sqlc.arg(after_pageid)
+  AND NOT EXISTS (
+    SELECT 1 FROM spell_embeddings se
+    WHERE se.pageid = s.pageid AND se.model = sqlc.arg(model)
+  )
+ORDER BY s.pageid
+LIMIT sqlc.arg(lim);
+
+-- name: UpsertSpellEmbedding :exec
+INSERT INTO spell_embeddings (pageid, model, embedding)
+VALUES ($1, $2, sqlc.arg(embedding)::vector)
+ON CONFLICT (pageid, model) DO UPDATE
+SET embedding = EXCLUDED.embedding, created_at = NOW();
+
+-- name: CountSpellsMissingEmbedding :one
+SELECT COUNT(*) FROM spells s
+WHERE NOT EXISTS (
+  SELECT 1 FROM spell_embeddings se
+  WHERE se.pageid = s.pageid AND se.model = $1
+);
+
+-- name: CopySpellEmbeddings :exec
+UPDATE spells s
+SET embedding = se.embedding
+FROM spell_embeddings se
+WHERE se.pageid = s.pageid AND se.model = $1;
+
+-- name: SetSpellEmbeddingModel :exec
+UPDATE spell_embedding_model
+SET model = $1, switched_at = NOW();
+
+-- name: GetSpellEmbeddingModel :one
+SELECT model FROM spell_embedding_model;
+
+-- name: DeleteSpellEmbeddings :exec
+DELETE FROM spell_embeddings
+WHERE model = $1;
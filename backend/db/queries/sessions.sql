@@ -0,0 +1,47 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, family_id, refresh_token_hash, user_agent, ip_address, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSessionByTokenHash :one
+SELECT * FROM sessions
+WHERE refresh_token_hash = $1
+LIMIT 1;
+
+-- name: MarkSessionUsed :one
+UPDATE sessions
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+  SELECT 1 FROM sessions
+  WHERE family_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+);
+
+-- name: ListActiveSessions :many
+-- The current row of each of the user's signed-in session families
+SELECT * FROM sessions
+WHERE user_id = $1
+  AND used_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC;
+
+-- name: RevokeUserSessionFamily :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL;
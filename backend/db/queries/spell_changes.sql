@@ -0,0 +1,52 @@
+-- name: ListSpellRevisions :many
+SELECT pageid, page_rev_id
+FROM spells
+WHERE pageid > sqlc.arg(after_pageid)
+ORDER BY pageid
+LIMIT sqlc.arg(lim);
+
+-- name: GetSpellForRefresh :one
+SELECT pageid, title, url, summary, used_by_doctor_strange, page_rev_id,
+       image_url, categories, realities, first_appearance, aliases,
+       infobox, sections, outlinks
+FROM spells
+WHERE pageid = $1;
+
+-- name: UpdateSpellFromWiki :exec
+UPDATE spells
+SET title = $2,
+    url = $3,
+    summary = $4,
+    used_by_doctor_strange = $5,
+    page_rev_id = $6,
+    last_rev_ts = $7,
+    image_url = $8,
+    categories = $9,
+    realities = $10,
+    first_appearance = $11,
+    aliases = $12,
+    infobox = $13,
+    sections = $14,
+    outlinks = $15,
+    last_fetched_at = NOW(),
+    updated_at = NOW()
+WHERE pageid = $1;
+
+-- name: SetSpellEmbedding :exec
+UPDATE spells
+SET embedding = sqlc.arg(embedding)::vector
+WHERE pageid = $1;
+
+-- name: DeleteOtherSpellEmbeddings :exec
+DELETE FROM spell_embeddings
+WHERE pageid = $1 AND model <> $2;
+
+-- name: InsertSpellChange :exec
+INSERT INTO spell_changes (pageid, kind, old_rev_id, new_rev_id, changed_fields, reembedded)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetLatestSpellChangeKind :one
+SELECT kind FROM spell_changes
+WHERE pageid = $1
+ORDER BY changed_at DESC, id DESC
+LIMIT 1;
@@ -1,22 +1,150 @@
+-- SearchSpells, SearchSpellsFullText and SearchSpellsByName share the same
+-- optional filters; empty arrays and NULLs leave a filter off.
+
 -- name: SearchSpells :many
-SELECT title, summary, url, categories
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason,
+       (embedding <-> sqlc.arg(embedding)::vector)::float8 AS distance
+FROM spells
+WHERE embedding IS NOT NULL
+  AND (sqlc.narg(max_distance)::float8 IS NULL OR embedding <-> sqlc.arg(embedding)::vector <= sqlc.narg(max_distance)::float8)
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+  AND (sqlc.narg(max_access_level)::smallint IS NULL OR COALESCE(access_level, 0) <= sqlc.narg(max_access_level)::smallint)
+  AND (sqlc.narg(min_access_level)::smallint IS NULL OR COALESCE(access_level, 0) >= sqlc.narg(min_access_level)::smallint)
+ORDER BY embedding <-> sqlc.arg(embedding)::vector
+LIMIT sqlc.arg(lim);
+
+-- name: SearchSpellsFullText :many
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason
+FROM spells
+WHERE search_tsv @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+  AND (sqlc.narg(max_access_level)::smallint IS NULL OR COALESCE(access_level, 0) <= sqlc.narg(max_access_level)::smallint)
+  AND (sqlc.narg(min_access_level)::smallint IS NULL OR COALESCE(access_level, 0) >= sqlc.narg(min_access_level)::smallint)
+ORDER BY ts_rank_cd(search_tsv, websearch_to_tsquery('english', sqlc.arg(query)::text)) DESC
+LIMIT sqlc.arg(lim);
+
+-- name: SearchSpellsByName :many
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason
+FROM spells
+WHERE (title % sqlc.arg(query)::text OR sqlc.arg(query)::text <% alias_text)
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+  AND (sqlc.narg(max_access_level)::smallint IS NULL OR COALESCE(access_level, 0) <= sqlc.narg(max_access_level)::smallint)
+  AND (sqlc.narg(min_access_level)::smallint IS NULL OR COALESCE(access_level, 0) >= sqlc.narg(min_access_level)::smallint)
+ORDER BY GREATEST(
+  similarity(title, sqlc.arg(query)::text),
+  word_similarity(sqlc.arg(query)::text, alias_text)
+) DESC
+LIMIT sqlc.arg(lim);
+
+-- name: ListSpellCatalogue :many
+-- Keyset pagination: after_title/after_pageid are the sort key of the last
+-- spell on the previous page, NULL for the first page
+SELECT pageid, title, url, summary, image_url, categories, realities,
+       origin, power_class, COALESCE(access_level, 0)::smallint AS access_level,
+       aliases, used_by_doctor_strange, infobox
+FROM spells
+WHERE COALESCE(access_level, 0) <= sqlc.arg(clearance)::smallint
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(alias)::text IS NULL OR alias_text ILIKE '%' || sqlc.narg(alias)::text || '%')
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+  AND (
+    sqlc.narg(after_pageid)::int IS NULL
+    OR (sqlc.arg(sort)::text = 'title'
+        AND (title, pageid) > (sqlc.narg(after_title)::text, sqlc.narg(after_pageid)::int))
+    OR (sqlc.arg(sort)::text = '-title'
+        AND (title < sqlc.narg(after_title)::text
+             OR (title = sqlc.narg(after_title)::text AND pageid > sqlc.narg(after_pageid)::int)))
+    OR (sqlc.arg(sort)::text = 'pageid' AND pageid > sqlc.narg(after_pageid)::int)
+    OR (sqlc.arg(sort)::text = '-pageid' AND pageid < sqlc.narg(after_pageid)::int)
+  )
+ORDER BY
+  CASE WHEN sqlc.arg(sort)::text = 'title' THEN title END ASC,
+  CASE WHEN sqlc.arg(sort)::text = '-title' THEN title END DESC,
+  CASE WHEN sqlc.arg(sort)::text = '-pageid' THEN pageid END DESC,
+  pageid ASC
+LIMIT sqlc.arg(lim);
+
+-- name: CountSpellCatalogue :one
+SELECT COUNT(*) FROM spells
+WHERE COALESCE(access_level, 0) <= sqlc.arg(clearance)::smallint
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(alias)::text IS NULL OR alias_text ILIKE '%' || sqlc.narg(alias)::text || '%')
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean);
+
+-- name: CountSpellRealityFacets :many
+SELECT reality::text AS value, COUNT(*) AS count
+FROM spells, unnest(realities) AS reality
+WHERE COALESCE(access_level, 0) <= sqlc.arg(clearance)::smallint
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(alias)::text IS NULL OR alias_text ILIKE '%' || sqlc.narg(alias)::text || '%')
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+GROUP BY reality
+ORDER BY count DESC, reality;
+
+-- name: CountSpellCategoryFacets :many
+SELECT category::text AS value, COUNT(*) AS count
+FROM spells, unnest(categories) AS category
+WHERE COALESCE(access_level, 0) <= sqlc.arg(clearance)::smallint
+  AND (COALESCE(cardinality(sqlc.arg(realities)::text[]), 0) = 0 OR realities && sqlc.arg(realities)::text[])
+  AND (COALESCE(cardinality(sqlc.arg(categories)::text[]), 0) = 0 OR categories && sqlc.arg(categories)::text[])
+  AND (sqlc.narg(power_class)::text IS NULL OR power_class = sqlc.narg(power_class)::text)
+  AND (sqlc.narg(origin)::text IS NULL OR origin = sqlc.narg(origin)::text)
+  AND (sqlc.narg(alias)::text IS NULL OR alias_text ILIKE '%' || sqlc.narg(alias)::text || '%')
+  AND (sqlc.narg(used_by_doctor_strange)::boolean IS NULL OR used_by_doctor_strange = sqlc.narg(used_by_doctor_strange)::boolean)
+GROUP BY category
+ORDER BY count DESC, category;
+
+-- name: GetSpellByPageID :one
+SELECT pageid, title, url, summary, used_by_doctor_strange, image_url,
+       categories, realities, first_appearance, aliases, infobox, sections,
+       outlinks, origin, power_class, COALESCE(access_level, 0)::smallint AS access_level,
+       restricted_reason, page_rev_id, last_rev_ts, last_fetched_at, updated_at
+FROM spells
+WHERE pageid = $1;
+
+-- name: MarkSpellAlertTriggered :execrows
+-- Stamps the spell unless it already alerted after since, so one alert
+-- covers every match in a window
+UPDATE spells
+SET alert_triggered_at = NOW()
+WHERE pageid = sqlc.arg(pageid)
+  AND (alert_triggered_at IS NULL OR alert_triggered_at < sqlc.arg(since)::timestamptz);
+
+-- name: GetMaxSpellAccessLevel :one
+SELECT COALESCE(MAX(access_level), 0)::smallint FROM spells;
+
+-- name: GetSpellsByPageIDs :many
+SELECT pageid, title, summary, url, categories
+FROM spells
+WHERE pageid = ANY(sqlc.arg(pageids)::int[])
+  AND COALESCE(access_level, 0) <= sqlc.arg(clearance)::smallint;
+
+-- name: ListSpellGraph :many
+SELECT pageid, title, url, summary, aliases,
+       COALESCE(access_level, 0)::smallint AS access_level, outlinks
 FROM spells
-ORDER BY embedding <-> $1
-LIMIT $2;
-
--- name: GetSpells :many
-SELECT 
-    pageid,
-    title,
-    url,
-    summary,
-    categories,
-    realities,
-    origin,
-    power_class,
-    access_level,
-    aliases,
-    infobox,
-    embedding
-FROM public.spells
-LIMIT 5;
+ORDER BY pageid;
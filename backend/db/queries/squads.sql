@@ -0,0 +1,20 @@
+-- name: CreateSquad :one
+INSERT INTO squads (name, commander_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetSquadByID :one
+SELECT * FROM squads
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListSquads :many
+SELECT * FROM squads
+ORDER BY name ASC;
+
+-- name: IsSquadMember :one
+SELECT EXISTS (
+  SELECT 1 FROM users u
+  JOIN squads s ON s.id = u.squad_id
+  WHERE u.id = $1 AND s.commander_id = $2
+);
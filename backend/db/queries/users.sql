@@ -45,3 +45,22 @@ WHERE id = $1;
 -- name: SelectByEmail :many
 SELECT * FROM users
 WHERE email = $1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET role = $2,
+squad_id = $3,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserClearance :one
+UPDATE users
+SET clearance_level = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAdminIDs :many
+SELECT id FROM users
+WHERE role = 'admin';
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+)
+
+func (s *Server) registerAdminRoutes() {
+	s.router.HandleFunc("PUT /api/admin/users/{userID}/role", s.auth.JwtAuthMiddleware(s.handleSetUserRole))
+	s.router.HandleFunc("PUT /api/admin/users/{userID}/clearance", s.auth.JwtAuthMiddleware(s.handleSetUserClearance))
+	s.router.HandleFunc("POST /api/admin/squads", s.auth.JwtAuthMiddleware(s.handleCreateSquad))
+	s.router.HandleFunc("GET /api/admin/squads", s.auth.JwtAuthMiddleware(s.handleListSquads))
+	s.router.HandleFunc("GET /api/admin/embedding-cache", s.auth.JwtAuthMiddleware(s.handleEmbeddingCacheStats))
+}
+
+func (s *Server) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		Role    string     `json:"role"`
+		SquadID *uuid.UUID `json:"squad_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	role := db.UserRoleEnum(req.Role)
+	switch role {
+	case db.UserRoleEnumAgent, db.UserRoleEnumCommander, db.UserRoleEnumAdmin:
+	default:
+		response.RespondWithError(w, http.StatusBadRequest, "Role must be one of agent, commander or admin")
+		return
+	}
+
+	squadID := uuid.NullUUID{}
+	if req.SquadID != nil {
+		squadID = uuid.NullUUID{UUID: *req.SquadID, Valid: true}
+	}
+
+	user, err := s.authService.SetUserRole(r.Context(), userID, role, squadID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user role")
+		return
+	}
+
+	response.RespondWithSuccess(w, "User role updated successfully", map[string]any{
+		"id":       user.ID,
+		"email":    user.Email,
+		"role":     user.Role,
+		"squad_id": user.SquadID,
+	})
+}
+
+func (s *Server) handleSetUserClearance(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req struct {
+		ClearanceLevel *int16 `json:"clearance_level"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.ClearanceLevel == nil || *req.ClearanceLevel < 0 {
+		response.RespondWithError(w, http.StatusBadRequest, "Clearance level must be zero or greater")
+		return
+	}
+
+	user, err := s.authService.SetUserClearance(r.Context(), userID, *req.ClearanceLevel)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update user clearance")
+		return
+	}
+
+	response.RespondWithSuccess(w, "User clearance updated successfully", map[string]any{
+		"id":              user.ID,
+		"email":           user.Email,
+		"clearance_level": user.ClearanceLevel,
+	})
+}
+
+func (s *Server) handleCreateSquad(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
+	var req struct {
+		Name        string     `json:"name"`
+		CommanderID *uuid.UUID `json:"commander_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	commanderID := uuid.NullUUID{}
+	if req.CommanderID != nil {
+		commanderID = uuid.NullUUID{UUID: *req.CommanderID, Valid: true}
+	}
+
+	squad, err := s.authService.CreateSquad(r.Context(), req.Name, commanderID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create squad")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Squad created successfully", squad)
+}
+
+func (s *Server) handleListSquads(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
+	squads, err := s.authService.ListSquads(r.Context())
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get squads")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Squads retrieved successfully", squads)
+}
+
+func (s *Server) handleEmbeddingCacheStats(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
+	response.RespondWithSuccess(w, "Embedding cache stats retrieved successfully", s.embedCache.Stats())
+}
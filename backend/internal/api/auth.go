@@ -2,14 +2,16 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	auth "github.com/ieeemumsb/Sinepsis/backend/internal/service"
 )
 
 func (s *Server) registerAuthRoutes() {
@@ -17,7 +19,12 @@ func (s *Server) registerAuthRoutes() {
 	s.router.HandleFunc("POST /api/auth/login", s.handleLogin)
 	s.router.HandleFunc("GET /api/auth/google/login", s.handleGoogleLogin)
 	s.router.HandleFunc("GET /api/auth/google/callback", s.handleGoogleCallback)
-	s.router.HandleFunc("GET /api/profile", middleware.JwtAuthMiddleware(s.handleProfile))
+	s.router.HandleFunc("POST /api/auth/refresh", s.handleRefresh)
+	s.router.HandleFunc("POST /api/auth/logout", s.handleLogout)
+	s.router.HandleFunc("POST /api/auth/logout-all", s.auth.JwtAuthMiddleware(s.handleLogoutAll))
+	s.router.HandleFunc("GET /api/auth/sessions", s.auth.JwtAuthMiddleware(s.handleListSessions))
+	s.router.HandleFunc("DELETE /api/auth/sessions/{sessionID}", s.auth.JwtAuthMiddleware(s.handleRevokeSession))
+	s.router.HandleFunc("GET /api/profile", s.auth.JwtAuthMiddleware(s.handleProfile))
 }
 
 func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
@@ -68,27 +75,12 @@ func (s *Server) handleGoogleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte("secret"))
-	if err != nil {
+	if _, _, err := s.startSession(w, r, user); err != nil {
 		log.Println("JWT signing error:", err)
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    tokenString,
-		HttpOnly: true,
-		Secure:   true, // set to true in production
-		Path:     "/",
-	})
-
 	http.Redirect(w, r, "http://localhost:3000/landingpage", http.StatusSeeOther)
 }
 
@@ -162,29 +154,136 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(), // expires in 24h
+	// Create JWT token and refresh session
+	tokenString, session, err := s.startSession(w, r, user)
+	if err != nil {
+		log.Println(err.Error())
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Login successful", map[string]string{
+		"token":         tokenString,
+		"refresh_token": session.RefreshToken,
 	})
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := refreshTokenFromRequest(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	tokenString, err := token.SignedString([]byte("secret"))
+	user, session, err := s.authService.RefreshSession(r.Context(), refreshToken, sessionInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrSessionNotFound),
+			errors.Is(err, auth.ErrSessionExpired),
+			errors.Is(err, auth.ErrSessionRevoked),
+			errors.Is(err, auth.ErrRefreshTokenReused):
+			clearAuthCookies(w)
+			response.RespondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
+		default:
+			log.Println("Refresh error:", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to refresh session")
+		}
+		return
+	}
+
+	tokenString, err := s.tokens.Sign(tokenSubject(user, session))
 	if err != nil {
 		log.Println(err.Error())
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    tokenString,
-		HttpOnly: true,
-		Secure:   true, // set to true in production
-		Path:     "/",
-	})
+	setAuthCookies(w, tokenString, session)
 
-	response.RespondWithSuccess(w, "Login successful", map[string]string{
-		"token": tokenString,
+	response.RespondWithSuccess(w, "Session refreshed", map[string]string{
+		"token":         tokenString,
+		"refresh_token": session.RefreshToken,
 	})
 }
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, err := refreshTokenFromRequest(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.authService.RevokeSession(r.Context(), refreshToken); err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		log.Println("Logout error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	clearAuthCookies(w)
+	response.RespondWithSuccess(w, "Logged out", nil)
+}
+
+func (s *Server) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	if err := s.authService.RevokeAllSessions(r.Context(), userID); err != nil {
+		log.Println("Logout error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	clearAuthCookies(w)
+	response.RespondWithSuccess(w, "Logged out of all sessions", nil)
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	sessions, err := s.authService.ListSessions(r.Context(), userID)
+	if err != nil {
+		log.Println("List sessions error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Sessions retrieved successfully", sessions)
+}
+
+// handleRevokeSession signs the caller out of one device. Revoking the
+// session the request was made with also clears its cookies.
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if err := s.authService.RevokeSessionByID(r.Context(), principal.UserID, sessionID); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			response.RespondWithError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		log.Println("Revoke session error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	if sessionID == principal.SessionID {
+		clearAuthCookies(w)
+	}
+	response.RespondWithSuccess(w, "Session revoked", nil)
+}
@@ -1,47 +1,194 @@
 package api
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
-	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	auth "github.com/ieeemumsb/Sinepsis/backend/internal/service"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/token"
 )
 
-func (s *Server) getUserIDFromToken(r *http.Request) (uuid.UUID, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return uuid.Nil, fmt.Errorf("missing Authorization header")
+// currentUserID returns the ID of the caller authenticated by
+// JwtAuthMiddleware
+func currentUserID(r *http.Request) (uuid.UUID, error) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, errors.New("request is not authenticated")
 	}
+	return principal.UserID, nil
+}
 
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	if tokenString == authHeader {
-		return uuid.Nil, fmt.Errorf("invalid Authorization header format")
+// startSession creates a refresh session for the user, signs an access token
+// bound to it and sets both as cookies
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user *db.User) (string, *auth.Session, error) {
+	session, err := s.authService.CreateSession(r.Context(), user.ID, sessionInfo(r))
+	if err != nil {
+		return "", nil, err
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte("secret"), nil
+	tokenString, err := s.tokens.Sign(tokenSubject(user, session))
+	if err != nil {
+		return "", nil, err
+	}
+
+	setAuthCookies(w, tokenString, session)
+	return tokenString, session, nil
+}
+
+func tokenSubject(user *db.User, session *auth.Session) token.Subject {
+	return token.Subject{
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: session.ID,
+		Roles:     auth.Roles(user),
+		Clearance: user.ClearanceLevel,
+	}
+}
+
+func setAuthCookies(w http.ResponseWriter, accessToken string, session *auth.Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    accessToken,
+		HttpOnly: true,
+		Secure:   true, // set to true in production
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
 	})
 
+	// The refresh token is only ever needed by the auth endpoints
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    session.RefreshToken,
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     "/api/auth",
+	})
+}
+
+func clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: "auth_token", Value: "", MaxAge: -1, HttpOnly: true, Secure: true, Path: "/"})
+	http.SetCookie(w, &http.Cookie{Name: "refresh_token", Value: "", MaxAge: -1, HttpOnly: true, Secure: true, Path: "/api/auth"})
+}
+
+// refreshTokenFromRequest reads the refresh token from the JSON body, falling
+// back to the refresh_token cookie
+func refreshTokenFromRequest(r *http.Request) (string, error) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return "", errors.New("Invalid request body")
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, nil
+	}
+
+	cookie, err := r.Cookie("refresh_token")
+	if err != nil || cookie.Value == "" {
+		return "", errors.New("Refresh token is required")
+	}
+	return cookie.Value, nil
+}
+
+func sessionInfo(r *http.Request) auth.SessionInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid token")
+		ip = r.RemoteAddr
+	}
+	return auth.SessionInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
 	}
+}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return uuid.Nil, fmt.Errorf("invalid user_id claim")
-		}
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return uuid.Nil, fmt.Errorf("invalid user_id format")
+// authorize checks that the caller holds perm on a record owned by ownerID,
+// writing the error response when it does not
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, perm authz.Permission, ownerID uuid.UUID) bool {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return false
+	}
+
+	if err := s.policy.Authorize(r.Context(), principal, perm, ownerID); err != nil {
+		if errors.Is(err, authz.ErrForbidden) {
+			response.RespondWithError(w, http.StatusForbidden, "You are not authorized to perform this action")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
 		}
-		return userID, nil
+		return false
+	}
+
+	return true
+}
+
+// authorizeMission loads the mission named by the {missionID} path value and
+// checks the caller holds perm on it
+func (s *Server) authorizeMission(w http.ResponseWriter, r *http.Request, perm authz.Permission) (db.Mission, bool) {
+	missionID, err := uuid.Parse(r.PathValue("missionID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
+		return db.Mission{}, false
+	}
+
+	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
+		return db.Mission{}, false
+	}
+
+	if !s.authorize(w, r, perm, mission.UserID) {
+		return db.Mission{}, false
+	}
+
+	return mission, true
+}
+
+// authorizeEvent loads the event named by the {eventID} path value and checks
+// the caller holds perm on it
+func (s *Server) authorizeEvent(w http.ResponseWriter, r *http.Request, perm authz.Permission) (db.CalendarEvent, bool) {
+	eventID, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		return db.CalendarEvent{}, false
+	}
+
+	event, err := s.calendarService.GetCalendarEventByID(r.Context(), eventID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusNotFound, "Event not found")
+		return db.CalendarEvent{}, false
+	}
+
+	if !s.authorize(w, r, perm, event.UserID) {
+		return db.CalendarEvent{}, false
+	}
+
+	return event, true
+}
+
+// requirePermission checks that the caller's roles grant perm regardless of
+// any record, writing the error response when they do not
+func requirePermission(w http.ResponseWriter, r *http.Request, perm authz.Permission) bool {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return false
+	}
+
+	if err := authz.Require(principal, perm); err != nil {
+		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to perform this action")
+		return false
 	}
 
-	return uuid.Nil, fmt.Errorf("invalid token")
+	return true
 }
@@ -4,42 +4,19 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
 )
 
 func (s *Server) handleAddMissionAttachment(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 || parts[5] != "attachments" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to add attachments to this mission")
+	mission, ok := s.authorizeMission(w, r, authz.MissionUpdate)
+	if !ok {
 		return
 	}
 
@@ -77,46 +54,28 @@ func (s *Server) handleAddMissionAttachment(w http.ResponseWriter, r *http.Reque
 	fileURL := "/uploads/mission_attachments/" + newFileName
 	fileType := handler.Header.Get("Content-Type")
 
-	attachment, err := s.calendarService.AddMissionAttachment(r.Context(), missionID, fileURL, sql.NullString{String: fileType, Valid: fileType != ""})
+	attachment, err := s.calendarService.AddMissionAttachment(r.Context(), mission.ID, fileURL, sql.NullString{String: fileType, Valid: fileType != ""})
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create attachment record")
 		return
 	}
 
+	// Text-bearing attachments are indexed for mission questions. A failure
+	// does not fail the upload; asking about the mission retries it.
+	if err := s.documentService.IndexAttachment(r.Context(), mission, attachment); err != nil {
+		log.Printf("Failed to index attachment %s: %v", attachment.ID, err)
+	}
+
 	response.RespondWithSuccess(w, "Attachment added successfully", attachment)
 }
 
 func (s *Server) handleGetMissionAttachments(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	mission, ok := s.authorizeMission(w, r, authz.MissionRead)
+	if !ok {
 		return
 	}
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 || parts[5] != "attachments" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to view attachments for this mission")
-		return
-	}
-
-	attachments, err := s.calendarService.GetAttachmentsByMission(r.Context(), missionID)
+	attachments, err := s.calendarService.GetAttachmentsByMission(r.Context(), mission.ID)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get attachments")
 		return
@@ -126,42 +85,19 @@ func (s *Server) handleGetMissionAttachments(w http.ResponseWriter, r *http.Requ
 }
 
 func (s *Server) handleDeleteMissionAttachment(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	mission, ok := s.authorizeMission(w, r, authz.MissionUpdate)
+	if !ok {
 		return
 	}
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 || parts[4] != "attachments" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-	missionID, err := uuid.Parse(parts[3])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to delete attachments from this mission")
-		return
-	}
-
-	attachmentID, err := uuid.Parse(parts[5])
+	attachmentID, err := uuid.Parse(r.PathValue("attachmentID"))
 	if err != nil {
 		response.RespondWithError(w, http.StatusBadRequest, "Invalid attachment ID")
 		return
 	}
 
 	attachment, err := s.calendarService.GetMissionAttachmentByID(r.Context(), attachmentID)
-	if err != nil {
+	if err != nil || attachment.MissionID != mission.ID {
 		response.RespondWithError(w, http.StatusNotFound, "Attachment not found")
 		return
 	}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
+)
+
+// handleFreeBusy returns the busy and free periods of the caller's events
+// and missions between from and to
+func (s *Server) handleFreeBusy(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventRead) {
+		return
+	}
+	if !requirePermission(w, r, authz.MissionRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	query := r.URL.Query()
+	from, ok := timeParam(w, query, "from")
+	if !ok {
+		return
+	}
+	to, ok := timeParam(w, query, "to")
+	if !ok {
+		return
+	}
+	if from.IsZero() || to.IsZero() {
+		response.RespondWithError(w, http.StatusBadRequest, "from and to are both required")
+		return
+	}
+
+	fb, err := s.calendarService.FreeBusy(r.Context(), userID, from, to)
+	if err != nil {
+		if errors.Is(err, calendar.ErrInvalidWindow) {
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get free/busy")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Free/busy retrieved successfully", fb)
+}
+
+// respondConflict answers a create or update refused for overlapping other
+// items with a 409 listing them, reporting whether err was such a refusal
+func respondConflict(w http.ResponseWriter, err error) bool {
+	var conflict *calendar.ConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+	response.RespondWithJSON(w, http.StatusConflict, response.Response{
+		Success: false,
+		Message: "Overlaps other events or missions; send allow_conflict=true to save anyway",
+		Data:    conflict.Conflicts,
+	})
+	return true
+}
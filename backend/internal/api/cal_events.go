@@ -1,19 +1,23 @@
 package api
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
 )
 
 func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	if !requirePermission(w, r, authz.EventCreate) {
+		return
+	}
+
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -24,6 +28,8 @@ func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		Description string    `json:"description"`
 		StartTime   time.Time `json:"start_time"`
 		EndTime     time.Time `json:"end_time"`
+		RRule       string    `json:"rrule"`
+		Timezone    string    `json:"timezone"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -41,8 +47,28 @@ func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := s.calendarService.CreateEvent(r.Context(), userID, req.Title, req.Description, req.StartTime, req.EndTime)
+	allowConflict, ok := boolParam(w, r.URL.Query(), "allow_conflict")
+	if !ok {
+		return
+	}
+
+	event, err := s.calendarService.CreateEvent(r.Context(), userID, calendar.EventInput{
+		Title:         req.Title,
+		Description:   req.Description,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		RRule:         req.RRule,
+		Timezone:      req.Timezone,
+		AllowConflict: allowConflict,
+	})
 	if err != nil {
+		if isEventInputError(err) {
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if respondConflict(w, err) {
+			return
+		}
 		println(err.Error())
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create event")
 		return
@@ -51,48 +77,75 @@ func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 	response.RespondWithSuccess(w, "Event created successfully", event)
 }
 
+// handleGetEvents pages through the caller's events as stored, the first
+// page when no cursor is given. Given from and to it instead pages through
+// the occurrences overlapping that window, recurring events expanded.
 func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	if !requirePermission(w, r, authz.EventRead) {
 		return
 	}
 
-	events, err := s.calendarService.GetEventsByUser(r.Context(), userID)
+	userID, err := currentUserID(r)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get events")
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
 	}
 
-	response.RespondWithSuccess(w, "Events retrieved successfully", events)
-}
-
-func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	query := r.URL.Query()
+	page, ok := pageParams(w, query)
+	if !ok {
 		return
 	}
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
+	var result any
+	switch {
+	case query.Has("from") || query.Has("to"):
+		from, ok := timeParam(w, query, "from")
+		if !ok {
+			return
+		}
+		to, ok := timeParam(w, query, "to")
+		if !ok {
+			return
+		}
+		if from.IsZero() || to.IsZero() {
+			response.RespondWithError(w, http.StatusBadRequest, "from and to are both required")
+			return
+		}
+		result, err = s.calendarService.ListOccurrences(r.Context(), userID, from, to, page)
+	default:
+		result, err = s.calendarService.ListEvents(r.Context(), userID, page)
 	}
-	eventID, err := uuid.Parse(parts[4])
 	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+		switch {
+		case errors.Is(err, calendar.ErrInvalidWindow), errors.Is(err, calendar.ErrInvalidCursor):
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to get events")
+		}
 		return
 	}
 
-	event, err := s.calendarService.GetCalendarEventByID(r.Context(), eventID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Event not found")
+	response.RespondWithSuccess(w, "Events retrieved successfully", result)
+}
+
+// handleUpdateEvent edits an event. For recurring events the scope query
+// parameter picks this occurrence, this and following ones, or all of them
+// (the default); occurrence names the one edited by its original start. An
+// edit overlapping other items gets a 409 unless allow_conflict=true.
+func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := s.authorizeEvent(w, r, authz.EventUpdate)
+	if !ok {
 		return
 	}
 
-	if event.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to update this event")
+	scope, occurrence, ok := eventScope(w, r, event)
+	if !ok {
+		return
+	}
+
+	allowConflict, ok := boolParam(w, r.URL.Query(), "allow_conflict")
+	if !ok {
 		return
 	}
 
@@ -101,6 +154,8 @@ func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		Description *string    `json:"description"`
 		StartTime   *time.Time `json:"start_time"`
 		EndTime     *time.Time `json:"end_time"`
+		RRule       *string    `json:"rrule"`
+		Timezone    *string    `json:"timezone"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -108,72 +163,73 @@ func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	params := db.UpdateCalendarEventParams{
-		ID: eventID,
-	}
-
-	params.Title = ""
-	if req.Title != nil {
-		params.Title = *req.Title
-	}
-
-	params.Description = sql.NullString{String: "", Valid: false} // default empty
-	if req.Description != nil {
-		params.Description = sql.NullString{
-			String: *req.Description,
-			Valid:  true,
-		}
+	if req.Title != nil && *req.Title == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Title cannot be empty")
+		return
 	}
 
-	if req.Description != nil {
-		params.Description = sql.NullString{String: *req.Description, Valid: true}
+	changes := calendar.EventChanges{
+		Title:         req.Title,
+		Description:   req.Description,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		RRule:         req.RRule,
+		Timezone:      req.Timezone,
+		AllowConflict: allowConflict,
 	}
 
-	if req.EndTime != nil {
-		params.EndTime = sql.NullTime{Time: *req.EndTime, Valid: true}
+	var updated any
+	var err error
+	switch scope {
+	case calendar.ScopeThis:
+		updated, err = s.calendarService.UpdateOccurrence(r.Context(), event, occurrence, changes)
+	case calendar.ScopeFollowing:
+		updated, err = s.calendarService.UpdateFollowing(r.Context(), event, occurrence, changes)
+	default:
+		updated, err = s.calendarService.UpdateEvent(r.Context(), event, occurrence, changes)
 	}
-
-	params.ID = eventID
-
-	updatedEvent, err := s.calendarService.UpdateEvent(r.Context(), params)
 	if err != nil {
+		if isEventInputError(err) {
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if respondConflict(w, err) {
+			return
+		}
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update event")
 		return
 	}
 
-	response.RespondWithSuccess(w, "Event updated successfully", updatedEvent)
+	response.RespondWithSuccess(w, "Event updated successfully", updated)
 }
 
+// handleDeleteEvent deletes an event, or with scope and occurrence one
+// occurrence of a recurring event or it and every later one
 func (s *Server) handleDeleteEvent(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	event, ok := s.authorizeEvent(w, r, authz.EventDelete)
+	if !ok {
 		return
 	}
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-	eventID, err := uuid.Parse(parts[3])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid event ID")
+	scope, occurrence, ok := eventScope(w, r, event)
+	if !ok {
 		return
 	}
 
-	event, err := s.calendarService.GetCalendarEventByID(r.Context(), eventID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Event not found")
-		return
-	}
-
-	if event.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to delete this event")
-		return
+	var err error
+	switch scope {
+	case calendar.ScopeThis:
+		err = s.calendarService.DeleteOccurrence(r.Context(), event, occurrence)
+	case calendar.ScopeFollowing:
+		err = s.calendarService.DeleteFollowing(r.Context(), event, occurrence)
+	default:
+		err = s.calendarService.DeleteEvent(r.Context(), event.ID)
 	}
-
-	if err := s.calendarService.DeleteEvent(r.Context(), eventID); err != nil {
+	if err != nil {
+		if isEventInputError(err) {
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete event")
 		return
 	}
@@ -181,7 +237,12 @@ func (s *Server) handleDeleteEvent(w http.ResponseWriter, r *http.Request) {
 	response.RespondWithSuccess(w, "Event deleted successfully", nil)
 }
 
+// handleGetAllEvents lists every user's events, for admins only
 func (s *Server) handleGetAllEvents(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.UserManage) {
+		return
+	}
+
 	events, err := s.calendarService.GetAllEvents(r.Context())
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get all events")
@@ -190,3 +251,46 @@ func (s *Server) handleGetAllEvents(w http.ResponseWriter, r *http.Request) {
 
 	response.RespondWithSuccess(w, "All events retrieved successfully", events)
 }
+
+// eventScope reads the scope and occurrence query parameters of an edit.
+// Single events are always edited whole.
+func eventScope(w http.ResponseWriter, r *http.Request, event db.CalendarEvent) (calendar.Scope, time.Time, bool) {
+	scope, err := calendar.ParseScope(r.URL.Query().Get("scope"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return "", time.Time{}, false
+	}
+	if !event.Rrule.Valid {
+		return calendar.ScopeAll, time.Time{}, true
+	}
+
+	var occurrence time.Time
+	if v := r.URL.Query().Get("occurrence"); v != "" {
+		if occurrence, err = time.Parse(time.RFC3339, v); err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "occurrence must be an RFC 3339 time")
+			return "", time.Time{}, false
+		}
+	}
+	if scope != calendar.ScopeAll && occurrence.IsZero() {
+		response.RespondWithError(w, http.StatusBadRequest, "occurrence is required for scope "+string(scope))
+		return "", time.Time{}, false
+	}
+	return scope, occurrence, true
+}
+
+// isEventInputError reports whether err comes from an invalid event edit
+// rather than a failure to store it
+func isEventInputError(err error) bool {
+	for _, target := range []error{
+		calendar.ErrInvalidRecurrence,
+		calendar.ErrInvalidTimezone,
+		calendar.ErrEndBeforeStart,
+		calendar.ErrNotAnOccurrence,
+		calendar.ErrRecurrenceScope,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
+)
+
+// maxImportSize caps an .ics upload
+const maxImportSize = 10 << 20 // 10 MB
+
+// handleExportCalendar downloads the caller's events and missions as an
+// .ics file
+func (s *Server) handleExportCalendar(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventRead) {
+		return
+	}
+	if !requirePermission(w, r, authz.MissionRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="sinepsis.ics"`)
+	s.writeICalendar(w, r, userID)
+}
+
+// handleCalendarFeed serves /api/calendar/feed/{token}.ics to calendar apps
+// subscribed with a feed token. Unknown and revoked tokens get a 404 so the
+// URL reveals nothing once revoked.
+func (s *Server) handleCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID, err := s.calendarService.FeedOwner(r.Context(), token)
+	if err != nil {
+		if !errors.Is(err, calendar.ErrFeedTokenInvalid) {
+			log.Println("Calendar feed error:", err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, no-cache")
+	s.writeICalendar(w, r, userID)
+}
+
+func (s *Server) writeICalendar(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	cal, err := s.calendarService.ICalendar(r.Context(), userID)
+	if err != nil {
+		log.Println("Calendar export error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to export calendar")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := cal.Encode(w); err != nil {
+		log.Println("Calendar export write error:", err)
+	}
+}
+
+// handleCreateFeedToken issues a feed token. The token is only ever shown in
+// this response, together with the feed path to subscribe to.
+func (s *Server) handleCreateFeedToken(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventRead) {
+		return
+	}
+	if !requirePermission(w, r, authz.MissionRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := s.calendarService.CreateFeedToken(r.Context(), userID, req.Name)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create feed token")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Feed token created successfully", struct {
+		calendar.FeedToken
+		FeedPath string `json:"feed_path"`
+	}{token, "/api/calendar/feed/" + token.Token + ".ics"})
+}
+
+func (s *Server) handleListFeedTokens(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventRead) {
+		return
+	}
+	if !requirePermission(w, r, authz.MissionRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	tokens, err := s.calendarService.ListFeedTokens(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get feed tokens")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Feed tokens retrieved successfully", tokens)
+}
+
+func (s *Server) handleRevokeFeedToken(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventRead) {
+		return
+	}
+	if !requirePermission(w, r, authz.MissionRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid feed token ID")
+		return
+	}
+
+	if err := s.calendarService.RevokeFeedToken(r.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, calendar.ErrFeedTokenNotFound) {
+			response.RespondWithError(w, http.StatusNotFound, "Feed token not found")
+			return
+		}
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke feed token")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Feed token revoked successfully", nil)
+}
+
+// handleImportCalendar reads an .ics file, sent as the "file" field of a
+// multipart form or as the request body, into the caller's events. With
+// dry_run=true it only reports what the import would do; timezone reads
+// times the file leaves floating.
+func (s *Server) handleImportCalendar(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.EventCreate) {
+		return
+	}
+	if !requirePermission(w, r, authz.EventUpdate) {
+		return
+	}
+	if !requirePermission(w, r, authz.EventDelete) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun, ok := boolParam(w, query, "dry_run")
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20)
+	var src io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Failed to parse form data")
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(src, maxImportSize+1))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Failed to read calendar")
+		return
+	}
+	if len(data) > maxImportSize {
+		response.RespondWithError(w, http.StatusRequestEntityTooLarge, "Calendar is larger than 10 MB")
+		return
+	}
+
+	result, err := s.calendarService.Import(r.Context(), userID, bytes.NewReader(data), calendar.ImportOptions{
+		DryRun:   dryRun,
+		Timezone: query.Get("timezone"),
+	})
+	if err != nil {
+		if errors.Is(err, calendar.ErrInvalidCalendar) || errors.Is(err, calendar.ErrInvalidTimezone) {
+			response.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Println("Calendar import error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to import calendar")
+		return
+	}
+
+	message := "Calendar imported successfully"
+	if dryRun {
+		message = "Calendar import preview"
+	}
+	response.RespondWithSuccess(w, message, result)
+}
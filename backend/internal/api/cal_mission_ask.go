@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/documents"
+)
+
+// handleAskMission answers a question using only the mission's details, logs
+// and text attachments
+func (s *Server) handleAskMission(w http.ResponseWriter, r *http.Request) {
+	mission, ok := s.authorizeMission(w, r, authz.MissionRead)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Query == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Query is required")
+		return
+	}
+	if req.Limit < 0 || req.Limit > documents.MaxChatLimit {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be at most %d", documents.MaxChatLimit))
+		return
+	}
+
+	answer, err := s.documentService.AskMission(r.Context(), mission, req.Query, req.Limit)
+	if err != nil {
+		log.Println("Mission question error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to answer question")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Question answered successfully", answer)
+}
@@ -3,39 +3,16 @@ package api
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
 )
 
 func (s *Server) handleAddMissionLog(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 || parts[5] != "logs" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to add logs to this mission")
+	mission, ok := s.authorizeMission(w, r, authz.MissionUpdate)
+	if !ok {
 		return
 	}
 
@@ -58,7 +35,7 @@ func (s *Server) handleAddMissionLog(w http.ResponseWriter, r *http.Request) {
 		req.LogDate = time.Now()
 	}
 
-	log, err := s.calendarService.AddMissionLog(r.Context(), missionID, req.Note, req.LogDate)
+	log, err := s.calendarService.AddMissionLog(r.Context(), mission.ID, req.Note, req.LogDate)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to add mission log")
 		return
@@ -68,36 +45,12 @@ func (s *Server) handleAddMissionLog(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetMissionLogs(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 || parts[5] != "logs" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
+	mission, ok := s.authorizeMission(w, r, authz.MissionRead)
+	if !ok {
 		return
 	}
 
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to view logs for this mission")
-		return
-	}
-
-	logs, err := s.calendarService.GetMissionLogs(r.Context(), missionID)
+	logs, err := s.calendarService.GetMissionLogs(r.Context(), mission.ID)
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get mission logs")
 		return
@@ -107,39 +60,20 @@ func (s *Server) handleGetMissionLogs(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDeleteMissionLog(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	// Expecting URL: /api/calendar/missions/{missionID}/logs/{logID}
-	if len(parts) < 7 || parts[5] != "logs" {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
+	mission, ok := s.authorizeMission(w, r, authz.MissionUpdate)
+	if !ok {
 		return
 	}
 
-	missionID, err := uuid.Parse(parts[4])
+	logID, err := uuid.Parse(r.PathValue("logID"))
 	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to delete logs from this mission")
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid log ID")
 		return
 	}
 
-	logID, err := uuid.Parse(parts[6])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid log ID")
+	missionLog, err := s.calendarService.GetMissionLogByID(r.Context(), logID)
+	if err != nil || missionLog.MissionID != mission.ID {
+		response.RespondWithError(w, http.StatusNotFound, "Mission log not found")
 		return
 	}
 
@@ -3,18 +3,24 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
 )
 
 func (s *Server) handleCreateMission(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	if !requirePermission(w, r, authz.MissionCreate) {
+		return
+	}
+
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -47,6 +53,11 @@ func (s *Server) handleCreateMission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	allowConflict, ok := boolParam(w, r.URL.Query(), "allow_conflict")
+	if !ok {
+		return
+	}
+
 	// Convert to sql.NullFloat64
 	latitude := sql.NullFloat64{}
 	if req.Latitude != nil {
@@ -65,25 +76,12 @@ func (s *Server) handleCreateMission(w http.ResponseWriter, r *http.Request) {
 		MissionTypeEnum: db.MissionTypeEnum(req.MissionType),
 		Valid:           req.MissionType != "",
 	}
-	if req.MissionType != "" {
-		missionType.MissionTypeEnum = db.MissionTypeEnum(req.MissionType)
-		missionType.Valid = true
-	}
-
-	if req.MissionType != "" {
-		missionType.MissionTypeEnum = missionType.MissionTypeEnum
-		missionType.Valid = true
-	}
 
 	// Convert to db.NullThreatLevelEnum
 	threatLevel := db.NullThreatLevelEnum{
 		ThreatLevelEnum: db.ThreatLevelEnum(req.ThreatLevel), // cast string to enum type
 		Valid:           req.ThreatLevel != "",
 	}
-	if req.ThreatLevel != "" {
-		threatLevel.ThreatLevelEnum = threatLevel.ThreatLevelEnum
-		threatLevel.Valid = true
-	}
 
 	// Convert to sql.NullTime for end time
 	var endTime time.Time
@@ -110,8 +108,12 @@ func (s *Server) handleCreateMission(w http.ResponseWriter, r *http.Request) {
 		endTime,
 		threatLevel,
 		success,
+		allowConflict,
 	)
 	if err != nil {
+		if respondConflict(w, err) {
+			return
+		}
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create mission")
 		return
 	}
@@ -119,54 +121,76 @@ func (s *Server) handleCreateMission(w http.ResponseWriter, r *http.Request) {
 	response.RespondWithSuccess(w, "Mission created successfully", mission)
 }
 
+// handleGetMissions pages through the caller's missions, newest first,
+// filtered by from, to, mission_type, threat_level and success. Commanders
+// also get their squad members' missions.
 func (s *Server) handleGetMissions(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	if !requirePermission(w, r, authz.MissionRead) {
 		return
 	}
 
-	missions, err := s.calendarService.GetMissionsByUser(r.Context(), userID)
+	userID, err := currentUserID(r)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get missions")
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
 	}
 
-	response.RespondWithSuccess(w, "Missions retrieved successfully", missions)
-}
-
-func (s *Server) handleGetMissionByID(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+	query := r.URL.Query()
+	principal, _ := middleware.PrincipalFromContext(r.Context())
+	q := calendar.MissionQuery{IncludeSquad: authz.ForSquad(principal, authz.MissionRead)}
+	var ok bool
+	if q.Page, ok = pageParams(w, query); !ok {
 		return
 	}
-
-	parts := strings.Split(r.URL.Path, "/")
-
-	fmt.Println(parts)
-
-	if len(parts) < 4 {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
+	if q.From, ok = timeParam(w, query, "from"); !ok {
 		return
 	}
-
-	missionIDStr := strings.TrimSpace(parts[4])
-	missionID, err := uuid.Parse(missionIDStr)
-	if err != nil {
-		println(err.Error())
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
+	if q.To, ok = timeParam(w, query, "to"); !ok {
 		return
 	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
+	if v := query.Get("mission_type"); v != "" {
+		switch t := db.MissionTypeEnum(v); t {
+		case db.MissionTypeEnumRecon, db.MissionTypeEnumRescue, db.MissionTypeEnumPatrol:
+			q.MissionType = db.NullMissionTypeEnum{MissionTypeEnum: t, Valid: true}
+		default:
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid mission_type")
+			return
+		}
+	}
+	if v := query.Get("threat_level"); v != "" {
+		switch l := db.ThreatLevelEnum(v); l {
+		case db.ThreatLevelEnumLow, db.ThreatLevelEnumMedium, db.ThreatLevelEnumHigh, db.ThreatLevelEnumCritical:
+			q.ThreatLevel = db.NullThreatLevelEnum{ThreatLevelEnum: l, Valid: true}
+		default:
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid threat_level")
+			return
+		}
+	}
+	if v := query.Get("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid success")
+			return
+		}
+		q.Success = sql.NullBool{Bool: success, Valid: true}
+	}
+
+	page, err := s.calendarService.ListMissions(r.Context(), userID, q)
 	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
+		if errors.Is(err, calendar.ErrInvalidCursor) {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get missions")
 		return
 	}
 
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to view this mission")
+	response.RespondWithSuccess(w, "Missions retrieved successfully", page)
+}
+
+func (s *Server) handleGetMissionByID(w http.ResponseWriter, r *http.Request) {
+	mission, ok := s.authorizeMission(w, r, authz.MissionRead)
+	if !ok {
 		return
 	}
 
@@ -174,31 +198,8 @@ func (s *Server) handleGetMissionByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleUpdateMission(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 5 { // note: 5 because the first element is empty string due to leading '/'
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to update this mission")
+	mission, ok := s.authorizeMission(w, r, authz.MissionUpdate)
+	if !ok {
 		return
 	}
 
@@ -219,6 +220,11 @@ func (s *Server) handleUpdateMission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	allowConflict, ok := boolParam(w, r.URL.Query(), "allow_conflict")
+	if !ok {
+		return
+	}
+
 	// Convert to sql.NullFloat64
 	latitude := sql.NullFloat64{}
 	if req.Latitude != nil {
@@ -259,7 +265,7 @@ func (s *Server) handleUpdateMission(w http.ResponseWriter, r *http.Request) {
 	}
 
 	params := db.UpdateMissionParams{
-		ID:          missionID,
+		ID:          mission.ID,
 		Title:       req.Title,
 		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
 		MissionType: missionType,
@@ -271,8 +277,11 @@ func (s *Server) handleUpdateMission(w http.ResponseWriter, r *http.Request) {
 		Success:     success,
 	}
 
-	updatedMission, err := s.calendarService.UpdateMission(r.Context(), params)
+	updatedMission, err := s.calendarService.UpdateMission(r.Context(), params, allowConflict)
 	if err != nil {
+		if respondConflict(w, err) {
+			return
+		}
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to update mission")
 		return
 	}
@@ -281,38 +290,12 @@ func (s *Server) handleUpdateMission(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDeleteMission(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
-	if err != nil {
-		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid URL")
-		return
-	}
-
-	missionID, err := uuid.Parse(parts[4])
-	if err != nil {
-		fmt.Println(parts)
-		println(err.Error())
-		response.RespondWithError(w, http.StatusBadRequest, "Invalid mission ID")
-		return
-	}
-
-	mission, err := s.calendarService.GetMissionByID(r.Context(), missionID)
-	if err != nil {
-		response.RespondWithError(w, http.StatusNotFound, "Mission not found")
-		return
-	}
-
-	if mission.UserID != userID {
-		response.RespondWithError(w, http.StatusForbidden, "You are not authorized to delete this mission")
+	mission, ok := s.authorizeMission(w, r, authz.MissionDelete)
+	if !ok {
 		return
 	}
 
-	if err := s.calendarService.DeleteMission(r.Context(), missionID); err != nil {
+	if err := s.calendarService.DeleteMission(r.Context(), mission.ID); err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete mission")
 		return
 	}
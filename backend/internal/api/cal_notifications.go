@@ -9,7 +9,7 @@ import (
 )
 
 func (s *Server) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -25,7 +25,7 @@ func (s *Server) handleGetNotifications(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -63,7 +63,7 @@ func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Reque
 }
 
 func (s *Server) handleDeleteNotification(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
+)
+
+// pageParams reads the limit and cursor query parameters
+func pageParams(w http.ResponseWriter, query url.Values) (calendar.Page, bool) {
+	page := calendar.Page{Cursor: query.Get("cursor")}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return page, false
+		}
+		page.Limit = limit
+	}
+	return page, true
+}
+
+// timeParam reads an optional RFC 3339 query parameter, zero when absent
+func timeParam(w http.ResponseWriter, query url.Values, name string) (time.Time, bool) {
+	v := query.Get(name)
+	if v == "" {
+		return time.Time{}, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, name+" must be an RFC 3339 time")
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// boolParam reads an optional true/false query parameter, false when absent
+func boolParam(w http.ResponseWriter, query url.Values, name string) (bool, bool) {
+	v := query.Get(name)
+	if v == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, name+" must be true or false")
+		return false, false
+	}
+	return b, true
+}
@@ -1,91 +1,127 @@
 package api
 
-import "github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
-
 func (s *Server) registerCalendarRoutes() {
 	// Events
 	s.router.HandleFunc(
 		"POST /api/calendar/events",
-		middleware.JwtAuthMiddleware(s.handleCreateEvent),
+		s.auth.JwtAuthMiddleware(s.handleCreateEvent),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/events",
-		middleware.JwtAuthMiddleware(s.handleGetEvents),
+		s.auth.JwtAuthMiddleware(s.handleGetEvents),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/events-all",
-		s.handleGetAllEvents,
+		s.auth.JwtAuthMiddleware(s.handleGetAllEvents),
 	)
 	s.router.HandleFunc(
 		"PUT /api/calendar/events/{eventID}",
-		middleware.JwtAuthMiddleware(s.handleUpdateEvent),
+		s.auth.JwtAuthMiddleware(s.handleUpdateEvent),
 	)
 	s.router.HandleFunc(
 		"DELETE /api/calendar/events/{eventID}",
-		middleware.JwtAuthMiddleware(s.handleDeleteEvent),
+		s.auth.JwtAuthMiddleware(s.handleDeleteEvent),
 	)
 
 	// Missions
 	s.router.HandleFunc(
 		"POST /api/calendar/missions",
-		middleware.JwtAuthMiddleware(s.handleCreateMission),
+		s.auth.JwtAuthMiddleware(s.handleCreateMission),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/missions",
-		middleware.JwtAuthMiddleware(s.handleGetMissions),
+		s.auth.JwtAuthMiddleware(s.handleGetMissions),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/missions/{missionID}",
-		middleware.JwtAuthMiddleware(s.handleGetMissionByID),
+		s.auth.JwtAuthMiddleware(s.handleGetMissionByID),
 	)
 	s.router.HandleFunc(
 		"PUT /api/calendar/missions/{missionID}",
-		middleware.JwtAuthMiddleware(s.handleUpdateMission),
+		s.auth.JwtAuthMiddleware(s.handleUpdateMission),
 	)
 	s.router.HandleFunc(
 		"DELETE /api/calendar/missions/{missionID}",
-		middleware.JwtAuthMiddleware(s.handleDeleteMission),
+		s.auth.JwtAuthMiddleware(s.handleDeleteMission),
 	)
 
 	// Mission Logs
 	s.router.HandleFunc(
 		"POST /api/calendar/missions/{missionID}/logs",
-		middleware.JwtAuthMiddleware(s.handleAddMissionLog),
+		s.auth.JwtAuthMiddleware(s.handleAddMissionLog),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/missions/{missionID}/logs",
-		middleware.JwtAuthMiddleware(s.handleGetMissionLogs),
+		s.auth.JwtAuthMiddleware(s.handleGetMissionLogs),
 	)
 	s.router.HandleFunc(
 		"DELETE /api/calendar/missions/{missionID}/logs/{logID}",
-		middleware.JwtAuthMiddleware(s.handleDeleteMissionLog),
+		s.auth.JwtAuthMiddleware(s.handleDeleteMissionLog),
 	)
 
 	// Mission Attachments
 	s.router.HandleFunc(
 		"POST /api/calendar/missions/{missionID}/attachments",
-		middleware.JwtAuthMiddleware(s.handleAddMissionAttachment),
+		s.auth.JwtAuthMiddleware(s.handleAddMissionAttachment),
 	)
 	s.router.HandleFunc(
 		"GET /api/calendar/missions/{missionID}/attachments",
-		middleware.JwtAuthMiddleware(s.handleGetMissionAttachments),
+		s.auth.JwtAuthMiddleware(s.handleGetMissionAttachments),
 	)
 	s.router.HandleFunc(
 		"DELETE /api/calendar/missions/{missionID}/attachments/{attachmentID}",
-		middleware.JwtAuthMiddleware(s.handleDeleteMissionAttachment),
+		s.auth.JwtAuthMiddleware(s.handleDeleteMissionAttachment),
+	)
+
+	// Mission questions
+	s.router.HandleFunc(
+		"POST /api/calendar/missions/{missionID}/ask",
+		s.auth.JwtAuthMiddleware(s.handleAskMission),
+	)
+
+	// Availability
+	s.router.HandleFunc(
+		"GET /api/calendar/free-busy",
+		s.auth.JwtAuthMiddleware(s.handleFreeBusy),
+	)
+
+	// iCalendar export, import and subscription feeds
+	s.router.HandleFunc(
+		"GET /api/calendar/export.ics",
+		s.auth.JwtAuthMiddleware(s.handleExportCalendar),
+	)
+	s.router.HandleFunc(
+		"POST /api/calendar/import",
+		s.auth.JwtAuthMiddleware(s.handleImportCalendar),
+	)
+	s.router.HandleFunc(
+		"GET /api/calendar/feed/{file}",
+		s.handleCalendarFeed,
+	)
+	s.router.HandleFunc(
+		"POST /api/calendar/feed-tokens",
+		s.auth.JwtAuthMiddleware(s.handleCreateFeedToken),
+	)
+	s.router.HandleFunc(
+		"GET /api/calendar/feed-tokens",
+		s.auth.JwtAuthMiddleware(s.handleListFeedTokens),
+	)
+	s.router.HandleFunc(
+		"DELETE /api/calendar/feed-tokens/{tokenID}",
+		s.auth.JwtAuthMiddleware(s.handleRevokeFeedToken),
 	)
 
 	// Notifications
 	s.router.HandleFunc(
 		"GET /api/notifications",
-		middleware.JwtAuthMiddleware(s.handleGetNotifications),
+		s.auth.JwtAuthMiddleware(s.handleGetNotifications),
 	)
 	s.router.HandleFunc(
 		"POST /api/notifications/{notificationID}/read",
-		middleware.JwtAuthMiddleware(s.handleMarkNotificationRead),
+		s.auth.JwtAuthMiddleware(s.handleMarkNotificationRead),
 	)
 	s.router.HandleFunc(
 		"DELETE /api/notifications/{notificationID}",
-		middleware.JwtAuthMiddleware(s.handleDeleteNotification),
+		s.auth.JwtAuthMiddleware(s.handleDeleteNotification),
 	)
 }
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/documents"
+)
+
+// maxDocumentSize caps a briefing upload
+const maxDocumentSize = 20 << 20 // 20 MB
+
+func (s *Server) registerDocumentRoutes() {
+	s.router.HandleFunc("POST /api/documents", s.auth.JwtAuthMiddleware(s.handleUploadDocument))
+	s.router.HandleFunc("GET /api/documents", s.auth.JwtAuthMiddleware(s.handleListDocuments))
+	s.router.HandleFunc("POST /api/documents/chat", s.auth.JwtAuthMiddleware(s.handleDocumentChat))
+	s.router.HandleFunc("GET /api/documents/{documentID}", s.auth.JwtAuthMiddleware(s.handleGetDocument))
+	s.router.HandleFunc("DELETE /api/documents/{documentID}", s.auth.JwtAuthMiddleware(s.handleDeleteDocument))
+}
+
+func (s *Server) handleUploadDocument(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.DocumentCreate) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDocumentSize+1<<20)
+	if err := r.ParseMultipartForm(maxDocumentSize); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Failed to parse form data")
+		return
+	}
+
+	file, handler, err := r.FormFile("document")
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "document file is required")
+		return
+	}
+	defer file.Close()
+
+	contentType := handler.Header.Get("Content-Type")
+	if documents.Kind(handler.Filename, contentType) == "" {
+		response.RespondWithError(w, http.StatusUnsupportedMediaType, documents.ErrUnsupportedType.Error())
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxDocumentSize+1))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Failed to read document")
+		return
+	}
+	if len(data) > maxDocumentSize {
+		response.RespondWithError(w, http.StatusRequestEntityTooLarge, "Document is larger than 20 MB")
+		return
+	}
+
+	doc, err := s.documentService.Ingest(r.Context(), userID, documents.Upload{
+		Title:    r.FormValue("title"),
+		Filename: handler.Filename,
+		MimeType: contentType,
+		Data:     data,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, documents.ErrUnsupportedType):
+			response.RespondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+		case errors.Is(err, documents.ErrNoText):
+			response.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			log.Println("Document ingestion error:", err)
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to ingest document")
+		}
+		return
+	}
+
+	response.RespondWithSuccess(w, "Document uploaded successfully", doc)
+}
+
+// handleListDocuments lists the caller's documents; admins see every
+// document, including those uploaded through the Python RAGService
+func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.DocumentRead) {
+		return
+	}
+
+	docs, err := s.documentService.List(r.Context(), documentOwnerScope(r))
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get documents")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Documents retrieved successfully", docs)
+}
+
+func (s *Server) handleGetDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.authorizeDocument(w, r, authz.DocumentRead)
+	if !ok {
+		return
+	}
+
+	response.RespondWithSuccess(w, "Document retrieved successfully", doc)
+}
+
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.authorizeDocument(w, r, authz.DocumentDelete)
+	if !ok {
+		return
+	}
+
+	if err := s.documentService.Delete(r.Context(), doc.ID); err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete document")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Document deleted successfully", nil)
+}
+
+// handleDocumentChat answers a question over the listed documents, or over
+// all of the caller's documents if none are listed
+func (s *Server) handleDocumentChat(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.DocumentRead) {
+		return
+	}
+
+	var req struct {
+		Query       string      `json:"query"`
+		DocumentIDs []uuid.UUID `json:"document_ids"`
+		Limit       int         `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Query == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "Query is required")
+		return
+	}
+	if req.Limit < 0 || req.Limit > documents.MaxChatLimit {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be at most %d", documents.MaxChatLimit))
+		return
+	}
+
+	scope := documents.Scope{OwnerID: documentOwnerScope(r)}
+	if len(req.DocumentIDs) > 0 {
+		for _, id := range req.DocumentIDs {
+			doc, err := s.documentService.Get(r.Context(), id)
+			if err != nil {
+				if errors.Is(err, documents.ErrDocumentNotFound) {
+					response.RespondWithError(w, http.StatusNotFound, "Document not found")
+				} else {
+					response.RespondWithError(w, http.StatusInternalServerError, "Failed to get document")
+				}
+				return
+			}
+			if !s.authorize(w, r, authz.DocumentRead, documentOwner(doc)) {
+				return
+			}
+		}
+		scope.DocumentIDs = req.DocumentIDs
+	}
+
+	answer, err := s.documentService.Chat(r.Context(), scope, req.Query, req.Limit)
+	if err != nil {
+		log.Println("Document chat error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to answer question")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Question answered successfully", answer)
+}
+
+// authorizeDocument loads the document named by the {documentID} path value
+// and checks the caller holds perm on it
+func (s *Server) authorizeDocument(w http.ResponseWriter, r *http.Request, perm authz.Permission) (documents.Document, bool) {
+	documentID, err := uuid.Parse(r.PathValue("documentID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid document ID")
+		return documents.Document{}, false
+	}
+
+	doc, err := s.documentService.Get(r.Context(), documentID)
+	if err != nil {
+		if errors.Is(err, documents.ErrDocumentNotFound) {
+			response.RespondWithError(w, http.StatusNotFound, "Document not found")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to get document")
+		}
+		return documents.Document{}, false
+	}
+
+	if !s.authorize(w, r, perm, documentOwner(doc)) {
+		return documents.Document{}, false
+	}
+
+	return doc, true
+}
+
+// documentOwner is the owner authorization checks against. Documents from
+// the Python RAGService have none, which leaves them to admins.
+func documentOwner(doc documents.Document) uuid.UUID {
+	if doc.OwnerID == nil {
+		return uuid.Nil
+	}
+	return *doc.OwnerID
+}
+
+// documentOwnerScope narrows listing and chat to the caller's own documents
+// unless they are an admin
+func documentOwnerScope(r *http.Request) uuid.NullUUID {
+	principal, _ := middleware.PrincipalFromContext(r.Context())
+	if authz.HasRole(principal, authz.RoleAdmin) {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: principal.UserID, Valid: true}
+}
@@ -9,13 +9,13 @@ import (
 )
 
 func (s *Server) registerGameStatsRoutes() {
-	s.router.HandleFunc("POST /api/gamestats", s.handleUpsertGameStats)
-	s.router.HandleFunc("GET /api/gamestats", s.handleGetGameStats)
+	s.router.HandleFunc("POST /api/gamestats", s.auth.JwtAuthMiddleware(s.handleUpsertGameStats))
+	s.router.HandleFunc("GET /api/gamestats", s.auth.JwtAuthMiddleware(s.handleGetGameStats))
 }
 
 // handleUpsertGameStats creates or updates the user’s game stats
 func (s *Server) handleUpsertGameStats(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
@@ -48,7 +48,7 @@ func (s *Server) handleUpsertGameStats(w http.ResponseWriter, r *http.Request) {
 
 // handleGetGameStats fetches the user’s stats
 func (s *Server) handleGetGameStats(w http.ResponseWriter, r *http.Request) {
-	userID, err := s.getUserIDFromToken(r)
+	userID, err := currentUserID(r)
 	if err != nil {
 		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 		return
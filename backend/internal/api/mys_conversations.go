@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
+)
+
+func (s *Server) handleCreateConversation(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	var req struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	conv, err := s.mysticService.CreateConversation(r.Context(), userID, req.Title)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to create conversation")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Conversation created successfully", conv)
+}
+
+func (s *Server) handleListConversations(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	convs, err := s.mysticService.ListConversations(r.Context(), userID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get conversations")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Conversations retrieved successfully", convs)
+}
+
+func (s *Server) handleGetConversation(w http.ResponseWriter, r *http.Request) {
+	conv, ok := s.ownConversation(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := s.mysticService.GetConversationMessages(r.Context(), conv.ID)
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get messages")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Conversation retrieved successfully", map[string]any{
+		"conversation": conv,
+		"messages":     messages,
+	})
+}
+
+func (s *Server) handleContinueConversation(w http.ResponseWriter, r *http.Request) {
+	conv, ok := s.ownConversation(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeSpellQuery(w, r)
+	if !ok {
+		return
+	}
+
+	reply, err := s.mysticService.AskInConversation(r.Context(), mysticCaller(r), conv.ID, req.Query, req.options())
+	if err != nil {
+		log.Println("Conversation error:", err)
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to answer question")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Question answered successfully", reply)
+}
+
+func (s *Server) handleDeleteConversation(w http.ResponseWriter, r *http.Request) {
+	conv, ok := s.ownConversation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.mysticService.DeleteConversation(r.Context(), conv.ID); err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to delete conversation")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Conversation deleted successfully", nil)
+}
+
+// ownConversation loads the conversation named in the path. Conversations are
+// private, so one belonging to someone else is reported as not found.
+func (s *Server) ownConversation(w http.ResponseWriter, r *http.Request) (db.Conversation, bool) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return db.Conversation{}, false
+	}
+
+	userID, err := currentUserID(r)
+	if err != nil {
+		response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		return db.Conversation{}, false
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid conversation ID")
+		return db.Conversation{}, false
+	}
+
+	conv, err := s.mysticService.GetConversation(r.Context(), userID, conversationID)
+	if err != nil {
+		if errors.Is(err, mystic.ErrConversationNotFound) {
+			response.RespondWithError(w, http.StatusNotFound, "Conversation not found")
+		} else {
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to get conversation")
+		}
+		return db.Conversation{}, false
+	}
+
+	return conv, true
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
+)
+
+func (s *Server) handleRelatedSpells(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	pageid, err := strconv.ParseInt(r.PathValue("pageid"), 10, 32)
+	if err != nil {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid spell ID")
+		return
+	}
+
+	related, err := s.mysticService.RelatedSpells(r.Context(), mysticCaller(r), int32(pageid))
+	if errors.Is(err, mystic.ErrSpellNotFound) {
+		response.RespondWithError(w, http.StatusNotFound, "Spell not found")
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get related spells")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Related spells retrieved successfully", related)
+}
+
+func (s *Server) handleSpellPath(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		response.RespondWithError(w, http.StatusBadRequest, "from and to are required")
+		return
+	}
+
+	path, err := s.mysticService.SpellPath(r.Context(), mysticCaller(r), from, to)
+	if errors.Is(err, mystic.ErrSpellNotFound) {
+		response.RespondWithError(w, http.StatusNotFound, "Spell or entity not found")
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to find path")
+		return
+	}
+
+	response.RespondWithSuccess(w, "Path retrieved successfully", map[string]any{
+		"found": len(path) > 0,
+		"hops":  max(len(path)-1, 0),
+		"path":  path,
+	})
+}
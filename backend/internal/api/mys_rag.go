@@ -2,14 +2,43 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
 )
 
 func (s *Server) registerMysticRoutes() {
-	s.router.HandleFunc("/api/mystic/query", s.handleQuerySpells)
-	s.router.HandleFunc("/api/mystic/spells", s.handleListSpells)
+	s.router.HandleFunc("/api/mystic/query", s.auth.JwtAuthMiddleware(s.handleQuerySpells))
+	s.router.HandleFunc("POST /api/mystic/query/stream", s.auth.JwtAuthMiddleware(s.handleQuerySpellsStream))
+	s.router.HandleFunc("/api/mystic/spells", s.auth.JwtAuthMiddleware(s.handleListSpells))
+	s.router.HandleFunc("GET /api/mystic/spells/{pageid}", s.auth.JwtAuthMiddleware(s.handleGetSpell))
+	s.router.HandleFunc("GET /api/mystic/spells/{pageid}/related", s.auth.JwtAuthMiddleware(s.handleRelatedSpells))
+	s.router.HandleFunc("GET /api/mystic/graph/path", s.auth.JwtAuthMiddleware(s.handleSpellPath))
+
+	s.router.HandleFunc("POST /api/mystic/conversations", s.auth.JwtAuthMiddleware(s.handleCreateConversation))
+	s.router.HandleFunc("GET /api/mystic/conversations", s.auth.JwtAuthMiddleware(s.handleListConversations))
+	s.router.HandleFunc("GET /api/mystic/conversations/{conversationID}", s.auth.JwtAuthMiddleware(s.handleGetConversation))
+	s.router.HandleFunc("POST /api/mystic/conversations/{conversationID}/messages", s.auth.JwtAuthMiddleware(s.handleContinueConversation))
+	s.router.HandleFunc("DELETE /api/mystic/conversations/{conversationID}", s.auth.JwtAuthMiddleware(s.handleDeleteConversation))
+}
+
+// mysticCaller describes the authenticated caller to the mystic service
+func mysticCaller(r *http.Request) mystic.Caller {
+	principal, _ := middleware.PrincipalFromContext(r.Context())
+	return mystic.Caller{
+		UserID:    principal.UserID,
+		Email:     principal.Email,
+		Clearance: principal.Clearance,
+	}
 }
 
 func (s *Server) handleQuerySpells(w http.ResponseWriter, r *http.Request) {
@@ -18,45 +47,181 @@ func (s *Server) handleQuerySpells(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse JSON body
-	var req struct {
-		Query string `json:"query"`
-		Limit int    `json:"limit"`
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	req, ok := decodeSpellQuery(w, r)
+	if !ok {
+		return
+	}
+
+	ragResp, err := s.mysticService.QuerySpells(r.Context(), mysticCaller(r), req.Query, req.options())
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.RespondWithSuccess(w, "RAG query successful", ragResp)
+}
+
+func (s *Server) handleQuerySpellsStream(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	req, ok := decodeSpellQuery(w, r)
+	if !ok {
+		return
+	}
+
+	stream, ok := newSSEWriter(w)
+	if !ok {
+		return
 	}
+
+	err := s.mysticService.StreamSpells(r.Context(), mysticCaller(r), req.Query, req.options(), spellStream{stream})
+	if err != nil {
+		// Nobody is listening once the client has gone away
+		if r.Context().Err() != nil {
+			return
+		}
+		log.Println("Mystic stream error:", err)
+		stream.send("error", map[string]string{"message": err.Error()})
+	}
+}
+
+// spellStream relays a streamed mystic query as results, token and done
+// events
+type spellStream struct {
+	*sseWriter
+}
+
+func (s spellStream) Results(results []mystic.SearchResult) error {
+	return s.send("results", results)
+}
+
+func (s spellStream) Token(text string) error {
+	return s.send("token", map[string]string{"text": text})
+}
+
+func (s spellStream) Done(done mystic.StreamDone) error {
+	return s.send("done", done)
+}
+
+type spellQueryRequest struct {
+	Query   string             `json:"query"`
+	Limit   int                `json:"limit"`
+	Weights *retrieval.Weights `json:"weights"`
+	Filters retrieval.Filters  `json:"filters"`
+}
+
+func (req spellQueryRequest) options() retrieval.Options {
+	return retrieval.Options{Limit: req.Limit, Weights: req.Weights, Filters: req.Filters}
+}
+
+func decodeSpellQuery(w http.ResponseWriter, r *http.Request) (spellQueryRequest, bool) {
+	var req spellQueryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
-		return
+		return req, false
 	}
 
 	if req.Query == "" {
 		response.RespondWithError(w, http.StatusBadRequest, "Query is required")
-		return
+		return req, false
+	}
+	if req.Limit < 0 || req.Limit > retrieval.MaxLimit {
+		response.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be at most %d", retrieval.MaxLimit))
+		return req, false
 	}
-	if req.Limit <= 0 {
+	if req.Limit == 0 {
 		req.Limit = 5
 	}
+	if req.Weights != nil {
+		if err := req.Weights.Validate(); err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid weights: "+err.Error())
+			return req, false
+		}
+	}
 
+	return req, true
+}
 
-	ragResp, err := s.mysticService.QuerySpells(r.Context(), req.Query, req.Limit)
+func (s *Server) handleListSpells(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !requirePermission(w, r, authz.SpellRead) {
+		return
+	}
+
+	params := r.URL.Query()
+	q := mystic.CatalogueQuery{
+		Cursor:     params.Get("cursor"),
+		Sort:       params.Get("sort"),
+		Realities:  params["reality"],
+		Categories: params["category"],
+		PowerClass: params.Get("power_class"),
+		Origin:     params.Get("origin"),
+		Alias:      params.Get("alias"),
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		q.Limit = limit
+	}
+	if v := params.Get("used_by_doctor_strange"); v != "" {
+		used, err := strconv.ParseBool(v)
+		if err != nil {
+			response.RespondWithError(w, http.StatusBadRequest, "Invalid used_by_doctor_strange")
+			return
+		}
+		q.UsedByDoctorStrange = &used
+	}
+
+	page, err := s.mysticService.ListSpells(r.Context(), mysticCaller(r), q)
+	if errors.Is(err, mystic.ErrInvalidSort) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid sort, expected one of "+strings.Join(mystic.CatalogueSorts, ", "))
+		return
+	}
+	if errors.Is(err, mystic.ErrInvalidCursor) {
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		response.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.RespondWithSuccess(w, "RAG query successful", ragResp)
+	response.RespondWithSuccess(w, "Spells retrieved successfully", page)
 }
 
-func (s *Server) handleListSpells(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		response.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+func (s *Server) handleGetSpell(w http.ResponseWriter, r *http.Request) {
+	if !requirePermission(w, r, authz.SpellRead) {
 		return
 	}
 
-	spells, err := s.mysticService.ListSpells(r.Context())
+	pageid, err := strconv.ParseInt(r.PathValue("pageid"), 10, 32)
 	if err != nil {
-		response.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		response.RespondWithError(w, http.StatusBadRequest, "Invalid spell ID")
+		return
+	}
+
+	spell, err := s.mysticService.GetSpell(r.Context(), mysticCaller(r), int32(pageid))
+	if errors.Is(err, mystic.ErrSpellNotFound) {
+		response.RespondWithError(w, http.StatusNotFound, "Spell not found")
+		return
+	}
+	if err != nil {
+		response.RespondWithError(w, http.StatusInternalServerError, "Failed to get spell")
 		return
 	}
 
-	response.RespondWithSuccess(w, "Spells retrieved successfully", spells)
+	response.RespondWithSuccess(w, "Spell retrieved successfully", spell)
 }
@@ -4,18 +4,28 @@ package api
 import (
 	"net/http"
 
+	"github.com/ieeemumsb/Sinepsis/backend/internal/authz"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/embedcache"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
 	auth "github.com/ieeemumsb/Sinepsis/backend/internal/service"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/calendar"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/service/documents"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/gamestats"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/service/mystic"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/token"
 )
 
 type Server struct {
-	router          *http.ServeMux
-	authService     *auth.AuthService
-	calendarService *calendar.CalendarService
-	mysticService   *mystic.SearchService
+	router           *http.ServeMux
+	authService      *auth.AuthService
+	calendarService  *calendar.CalendarService
+	mysticService    *mystic.SearchService
 	gameStatsService *gamestats.GameStatsService
+	documentService  *documents.DocumentService
+	embedCache       *embedcache.Cache
+	tokens           *token.Manager
+	auth             *middleware.Auth
+	policy           *authz.Policy
 }
 
 func NewServer(
@@ -23,13 +33,21 @@ func NewServer(
 	calendarService *calendar.CalendarService,
 	mysticService *mystic.SearchService,
 	gameStatsService *gamestats.GameStatsService,
+	documentService *documents.DocumentService,
+	embedCache *embedcache.Cache,
+	tokens *token.Manager,
 ) *Server {
 	s := &Server{
-		router:          http.NewServeMux(),
-		authService:     authService,
-		calendarService: calendarService,
-		mysticService:   mysticService,
+		router:           http.NewServeMux(),
+		authService:      authService,
+		calendarService:  calendarService,
+		mysticService:    mysticService,
 		gameStatsService: gameStatsService,
+		documentService:  documentService,
+		embedCache:       embedCache,
+		tokens:           tokens,
+		auth:             middleware.NewAuth(tokens, authService),
+		policy:           authz.New(authService),
 	}
 
 	s.registerRoutes()
@@ -46,4 +64,6 @@ func (s *Server) registerRoutes() {
 	s.registerCalendarRoutes()
 	s.registerMysticRoutes()
 	s.registerGameStatsRoutes()
+	s.registerDocumentRoutes()
+	s.registerAdminRoutes()
 }
@@ -0,0 +1,133 @@
+// Package authz decides which records an authenticated principal may act on
+package authz
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/middleware"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+type Permission string
+
+const (
+	MissionCreate Permission = "mission:create"
+	MissionRead   Permission = "mission:read"
+	MissionUpdate Permission = "mission:update"
+	MissionDelete Permission = "mission:delete"
+
+	EventCreate Permission = "event:create"
+	EventRead   Permission = "event:read"
+	EventUpdate Permission = "event:update"
+	EventDelete Permission = "event:delete"
+
+	SpellRead Permission = "spell:read"
+
+	DocumentCreate Permission = "document:create"
+	DocumentRead   Permission = "document:read"
+	DocumentDelete Permission = "document:delete"
+
+	UserManage Permission = "user:manage"
+)
+
+const (
+	RoleAgent     = string(db.UserRoleEnumAgent)
+	RoleCommander = string(db.UserRoleEnumCommander)
+	RoleAdmin     = string(db.UserRoleEnumAdmin)
+)
+
+var recordPermissions = []Permission{
+	MissionCreate, MissionRead, MissionUpdate, MissionDelete,
+	EventCreate, EventRead, EventUpdate, EventDelete,
+	SpellRead,
+	DocumentCreate, DocumentRead, DocumentDelete,
+}
+
+// rolePermissions are the permissions each role holds. Agents and commanders
+// hold record permissions on their own records only; see Authorize.
+var rolePermissions = map[string][]Permission{
+	RoleAgent:     recordPermissions,
+	RoleCommander: recordPermissions,
+	RoleAdmin:     append(slices.Clone(recordPermissions), UserManage),
+}
+
+// squadPermissions are the permissions a commander holds on records owned by
+// members of their squad
+var squadPermissions = []Permission{MissionRead, MissionUpdate, DocumentRead}
+
+// SquadChecker reports whether a user belongs to a squad led by a commander
+type SquadChecker interface {
+	IsSquadMember(ctx context.Context, userID, commanderID uuid.UUID) (bool, error)
+}
+
+type Policy struct {
+	squads SquadChecker
+}
+
+func New(squads SquadChecker) *Policy {
+	return &Policy{squads: squads}
+}
+
+// Authorize checks that the principal may exercise perm on a record owned by
+// ownerID. Owners may do anything with their own records, commanders may read
+// and update their squad's missions and read its documents, and admins may
+// manage every record.
+func (p *Policy) Authorize(
+	ctx context.Context,
+	principal middleware.Principal,
+	perm Permission,
+	ownerID uuid.UUID,
+) error {
+	if err := Require(principal, perm); err != nil {
+		return err
+	}
+
+	if HasRole(principal, RoleAdmin) || principal.UserID == ownerID {
+		return nil
+	}
+
+	if ForSquad(principal, perm) {
+		member, err := p.squads.IsSquadMember(ctx, ownerID, principal.UserID)
+		if err != nil {
+			return err
+		}
+		if member {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+// Require checks that one of the principal's roles grants perm, without
+// looking at any particular record
+func Require(principal middleware.Principal, perm Permission) error {
+	roles := principal.Roles
+	if len(roles) == 0 {
+		// Tokens issued before roles existed belong to agents
+		roles = []string{RoleAgent}
+	}
+
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// ForSquad reports whether the principal holds perm on the records of their
+// squad's members, as commanders do for some permissions
+func ForSquad(principal middleware.Principal, perm Permission) bool {
+	return HasRole(principal, RoleCommander) && slices.Contains(squadPermissions, perm)
+}
+
+// HasRole reports whether the principal was issued the role
+func HasRole(principal middleware.Principal, role string) bool {
+	return slices.Contains(principal.Roles, role)
+}
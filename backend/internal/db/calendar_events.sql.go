@@ -11,12 +11,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createCalendarEvent = `-- name: CreateCalendarEvent :one
-INSERT INTO calendar_events (user_id, title, description, start_time, end_time)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, title, description, start_time, end_time, created_at, updated_at
+INSERT INTO calendar_events (user_id, title, description, start_time, end_time, rrule, timezone, series_end, uid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid
 `
 
 type CreateCalendarEventParams struct {
@@ -25,6 +26,10 @@ type CreateCalendarEventParams struct {
 	Description sql.NullString
 	StartTime   time.Time
 	EndTime     sql.NullTime
+	Rrule       sql.NullString
+	Timezone    string
+	SeriesEnd   sql.NullTime
+	Uid         sql.NullString
 }
 
 func (q *Queries) CreateCalendarEvent(ctx context.Context, arg CreateCalendarEventParams) (CalendarEvent, error) {
@@ -34,6 +39,10 @@ func (q *Queries) CreateCalendarEvent(ctx context.Context, arg CreateCalendarEve
 		arg.Description,
 		arg.StartTime,
 		arg.EndTime,
+		arg.Rrule,
+		arg.Timezone,
+		arg.SeriesEnd,
+		arg.Uid,
 	)
 	var i CalendarEvent
 	err := row.Scan(
@@ -45,6 +54,10 @@ func (q *Queries) CreateCalendarEvent(ctx context.Context, arg CreateCalendarEve
 		&i.EndTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rrule,
+		&i.Timezone,
+		&i.SeriesEnd,
+		&i.Uid,
 	)
 	return i, err
 }
@@ -59,8 +72,50 @@ func (q *Queries) DeleteCalendarEvent(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteEventOverrides = `-- name: DeleteEventOverrides :exec
+DELETE FROM calendar_event_overrides
+WHERE event_id = $1
+`
+
+func (q *Queries) DeleteEventOverrides(ctx context.Context, eventID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteEventOverrides, eventID)
+	return err
+}
+
+const deleteEventOverridesFrom = `-- name: DeleteEventOverridesFrom :exec
+DELETE FROM calendar_event_overrides
+WHERE event_id = $1 AND original_start >= $2
+`
+
+type DeleteEventOverridesFromParams struct {
+	EventID       uuid.UUID
+	OriginalStart time.Time
+}
+
+func (q *Queries) DeleteEventOverridesFrom(ctx context.Context, arg DeleteEventOverridesFromParams) error {
+	_, err := q.db.ExecContext(ctx, deleteEventOverridesFrom, arg.EventID, arg.OriginalStart)
+	return err
+}
+
+const extendSeriesEnd = `-- name: ExtendSeriesEnd :exec
+UPDATE calendar_events
+SET series_end = GREATEST(series_end, $1::timestamptz)
+WHERE id = $2 AND series_end IS NOT NULL
+`
+
+type ExtendSeriesEndParams struct {
+	OccurrenceEnd time.Time
+	ID            uuid.UUID
+}
+
+// Moves a bounded series' end out to cover an occurrence edited past it
+func (q *Queries) ExtendSeriesEnd(ctx context.Context, arg ExtendSeriesEndParams) error {
+	_, err := q.db.ExecContext(ctx, extendSeriesEnd, arg.OccurrenceEnd, arg.ID)
+	return err
+}
+
 const getAllCalendarEvents = `-- name: GetAllCalendarEvents :many
-SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid
 FROM calendar_events
 ORDER BY start_time ASC
 `
@@ -83,6 +138,10 @@ func (q *Queries) GetAllCalendarEvents(ctx context.Context) ([]CalendarEvent, er
 			&i.EndTime,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Rrule,
+			&i.Timezone,
+			&i.SeriesEnd,
+			&i.Uid,
 		); err != nil {
 			return nil, err
 		}
@@ -98,7 +157,7 @@ func (q *Queries) GetAllCalendarEvents(ctx context.Context) ([]CalendarEvent, er
 }
 
 const getCalendarEventByID = `-- name: GetCalendarEventByID :one
-SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at FROM calendar_events
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid FROM calendar_events
 WHERE id = $1
 LIMIT 1
 `
@@ -115,12 +174,74 @@ func (q *Queries) GetCalendarEventByID(ctx context.Context, id uuid.UUID) (Calen
 		&i.EndTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rrule,
+		&i.Timezone,
+		&i.SeriesEnd,
+		&i.Uid,
+	)
+	return i, err
+}
+
+const getEventByUID = `-- name: GetEventByUID :one
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid FROM calendar_events
+WHERE user_id = $1 AND uid = $2
+`
+
+type GetEventByUIDParams struct {
+	UserID uuid.UUID
+	Uid    sql.NullString
+}
+
+func (q *Queries) GetEventByUID(ctx context.Context, arg GetEventByUIDParams) (CalendarEvent, error) {
+	row := q.db.QueryRowContext(ctx, getEventByUID, arg.UserID, arg.Uid)
+	var i CalendarEvent
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Description,
+		&i.StartTime,
+		&i.EndTime,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Rrule,
+		&i.Timezone,
+		&i.SeriesEnd,
+		&i.Uid,
+	)
+	return i, err
+}
+
+const getEventOverride = `-- name: GetEventOverride :one
+SELECT id, event_id, original_start, title, description, start_time, end_time, cancelled, created_at, updated_at FROM calendar_event_overrides
+WHERE event_id = $1 AND original_start = $2
+`
+
+type GetEventOverrideParams struct {
+	EventID       uuid.UUID
+	OriginalStart time.Time
+}
+
+func (q *Queries) GetEventOverride(ctx context.Context, arg GetEventOverrideParams) (CalendarEventOverride, error) {
+	row := q.db.QueryRowContext(ctx, getEventOverride, arg.EventID, arg.OriginalStart)
+	var i CalendarEventOverride
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.OriginalStart,
+		&i.Title,
+		&i.Description,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Cancelled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getEventsByUser = `-- name: GetEventsByUser :many
-SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at FROM calendar_events
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid FROM calendar_events
 WHERE user_id = $1
 ORDER BY start_time ASC
 `
@@ -143,6 +264,165 @@ func (q *Queries) GetEventsByUser(ctx context.Context, userID uuid.UUID) ([]Cale
 			&i.EndTime,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Rrule,
+			&i.Timezone,
+			&i.SeriesEnd,
+			&i.Uid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsInRange = `-- name: GetEventsInRange :many
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid FROM calendar_events
+WHERE user_id = $1
+  AND (
+    (rrule IS NULL AND start_time < $2
+      AND COALESCE(end_time, start_time) >= $3::timestamptz)
+    OR (rrule IS NOT NULL AND (series_end IS NULL OR series_end >= $3::timestamptz))
+  )
+ORDER BY start_time ASC
+`
+
+type GetEventsInRangeParams struct {
+	UserID     uuid.UUID
+	RangeEnd   time.Time
+	RangeStart time.Time
+}
+
+// Events that may have an occurrence overlapping [range_start, range_end):
+// single events by their own times, series by series_end since an edited
+// occurrence can move before the series start
+func (q *Queries) GetEventsInRange(ctx context.Context, arg GetEventsInRangeParams) ([]CalendarEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getEventsInRange, arg.UserID, arg.RangeEnd, arg.RangeStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarEvent
+	for rows.Next() {
+		var i CalendarEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rrule,
+			&i.Timezone,
+			&i.SeriesEnd,
+			&i.Uid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventOverrides = `-- name: ListEventOverrides :many
+SELECT id, event_id, original_start, title, description, start_time, end_time, cancelled, created_at, updated_at FROM calendar_event_overrides
+WHERE event_id = ANY($1::uuid[])
+ORDER BY original_start ASC
+`
+
+func (q *Queries) ListEventOverrides(ctx context.Context, eventIds []uuid.UUID) ([]CalendarEventOverride, error) {
+	rows, err := q.db.QueryContext(ctx, listEventOverrides, pq.Array(eventIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarEventOverride
+	for rows.Next() {
+		var i CalendarEventOverride
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.OriginalStart,
+			&i.Title,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.Cancelled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsPage = `-- name: ListEventsPage :many
+SELECT id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid FROM calendar_events
+WHERE user_id = $1
+  AND ($2::uuid IS NULL
+       OR (start_time, id) > ($3::timestamptz, $2::uuid))
+ORDER BY start_time ASC, id ASC
+LIMIT $4
+`
+
+type ListEventsPageParams struct {
+	UserID     uuid.UUID
+	AfterID    uuid.NullUUID
+	AfterStart sql.NullTime
+	Lim        int32
+}
+
+// Keyset pagination on (start_time, id): after_start/after_id are the key of
+// the last event on the previous page, NULL for the first page
+func (q *Queries) ListEventsPage(ctx context.Context, arg ListEventsPageParams) ([]CalendarEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listEventsPage,
+		arg.UserID,
+		arg.AfterID,
+		arg.AfterStart,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarEvent
+	for rows.Next() {
+		var i CalendarEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.StartTime,
+			&i.EndTime,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rrule,
+			&i.Timezone,
+			&i.SeriesEnd,
+			&i.Uid,
 		); err != nil {
 			return nil, err
 		}
@@ -159,9 +439,10 @@ func (q *Queries) GetEventsByUser(ctx context.Context, userID uuid.UUID) ([]Cale
 
 const updateCalendarEvent = `-- name: UpdateCalendarEvent :one
 UPDATE calendar_events
-SET title = $2, description = $3, start_time = $4, end_time = $5, updated_at = NOW()
+SET title = $2, description = $3, start_time = $4, end_time = $5,
+    rrule = $6, timezone = $7, series_end = $8, updated_at = NOW()
 WHERE id = $1
-RETURNING id, user_id, title, description, start_time, end_time, created_at, updated_at
+RETURNING id, user_id, title, description, start_time, end_time, created_at, updated_at, rrule, timezone, series_end, uid
 `
 
 type UpdateCalendarEventParams struct {
@@ -170,6 +451,9 @@ type UpdateCalendarEventParams struct {
 	Description sql.NullString
 	StartTime   time.Time
 	EndTime     sql.NullTime
+	Rrule       sql.NullString
+	Timezone    string
+	SeriesEnd   sql.NullTime
 }
 
 func (q *Queries) UpdateCalendarEvent(ctx context.Context, arg UpdateCalendarEventParams) (CalendarEvent, error) {
@@ -179,6 +463,9 @@ func (q *Queries) UpdateCalendarEvent(ctx context.Context, arg UpdateCalendarEve
 		arg.Description,
 		arg.StartTime,
 		arg.EndTime,
+		arg.Rrule,
+		arg.Timezone,
+		arg.SeriesEnd,
 	)
 	var i CalendarEvent
 	err := row.Scan(
@@ -190,6 +477,59 @@ func (q *Queries) UpdateCalendarEvent(ctx context.Context, arg UpdateCalendarEve
 		&i.EndTime,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Rrule,
+		&i.Timezone,
+		&i.SeriesEnd,
+		&i.Uid,
+	)
+	return i, err
+}
+
+const upsertEventOverride = `-- name: UpsertEventOverride :one
+INSERT INTO calendar_event_overrides (event_id, original_start, title, description, start_time, end_time, cancelled)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (event_id, original_start) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    start_time = EXCLUDED.start_time,
+    end_time = EXCLUDED.end_time,
+    cancelled = EXCLUDED.cancelled,
+    updated_at = NOW()
+RETURNING id, event_id, original_start, title, description, start_time, end_time, cancelled, created_at, updated_at
+`
+
+type UpsertEventOverrideParams struct {
+	EventID       uuid.UUID
+	OriginalStart time.Time
+	Title         sql.NullString
+	Description   sql.NullString
+	StartTime     time.Time
+	EndTime       sql.NullTime
+	Cancelled     bool
+}
+
+func (q *Queries) UpsertEventOverride(ctx context.Context, arg UpsertEventOverrideParams) (CalendarEventOverride, error) {
+	row := q.db.QueryRowContext(ctx, upsertEventOverride,
+		arg.EventID,
+		arg.OriginalStart,
+		arg.Title,
+		arg.Description,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Cancelled,
+	)
+	var i CalendarEventOverride
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.OriginalStart,
+		&i.Title,
+		&i.Description,
+		&i.StartTime,
+		&i.EndTime,
+		&i.Cancelled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
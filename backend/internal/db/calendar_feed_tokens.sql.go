@@ -0,0 +1,111 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: calendar_feed_tokens.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createFeedToken = `-- name: CreateFeedToken :one
+INSERT INTO calendar_feed_tokens (user_id, token_hash, name)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, token_hash, name, last_used_at, revoked_at, created_at
+`
+
+type CreateFeedTokenParams struct {
+	UserID    uuid.UUID
+	TokenHash string
+	Name      sql.NullString
+}
+
+func (q *Queries) CreateFeedToken(ctx context.Context, arg CreateFeedTokenParams) (CalendarFeedToken, error) {
+	row := q.db.QueryRowContext(ctx, createFeedToken, arg.UserID, arg.TokenHash, arg.Name)
+	var i CalendarFeedToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.Name,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listFeedTokens = `-- name: ListFeedTokens :many
+SELECT id, user_id, token_hash, name, last_used_at, revoked_at, created_at FROM calendar_feed_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListFeedTokens(ctx context.Context, userID uuid.UUID) ([]CalendarFeedToken, error) {
+	rows, err := q.db.QueryContext(ctx, listFeedTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalendarFeedToken
+	for rows.Next() {
+		var i CalendarFeedToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.TokenHash,
+			&i.Name,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeFeedToken = `-- name: RevokeFeedToken :execrows
+UPDATE calendar_feed_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeFeedTokenParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeFeedToken(ctx context.Context, arg RevokeFeedTokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeFeedToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useFeedToken = `-- name: UseFeedToken :one
+UPDATE calendar_feed_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL
+RETURNING user_id
+`
+
+// Resolves an active token to its owner and records the fetch
+func (q *Queries) UseFeedToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, useFeedToken, tokenHash)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
@@ -0,0 +1,231 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: conversations.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createConversation = `-- name: CreateConversation :one
+INSERT INTO conversations (user_id, title)
+VALUES ($1, $2)
+RETURNING id, user_id, title, created_at, updated_at
+`
+
+type CreateConversationParams struct {
+	UserID uuid.UUID
+	Title  string
+}
+
+func (q *Queries) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, createConversation, arg.UserID, arg.Title)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createConversationMessage = `-- name: CreateConversationMessage :one
+INSERT INTO conversation_messages (conversation_id, role, content, retrieval_query, cited_pageids)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, conversation_id, role, content, retrieval_query, cited_pageids, created_at
+`
+
+type CreateConversationMessageParams struct {
+	ConversationID uuid.UUID
+	Role           MessageRoleEnum
+	Content        string
+	RetrievalQuery sql.NullString
+	CitedPageids   []int32
+}
+
+func (q *Queries) CreateConversationMessage(ctx context.Context, arg CreateConversationMessageParams) (ConversationMessage, error) {
+	row := q.db.QueryRowContext(ctx, createConversationMessage,
+		arg.ConversationID,
+		arg.Role,
+		arg.Content,
+		arg.RetrievalQuery,
+		pq.Array(arg.CitedPageids),
+	)
+	var i ConversationMessage
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.Role,
+		&i.Content,
+		&i.RetrievalQuery,
+		pq.Array(&i.CitedPageids),
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteConversation = `-- name: DeleteConversation :exec
+DELETE FROM conversations
+WHERE id = $1
+`
+
+func (q *Queries) DeleteConversation(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteConversation, id)
+	return err
+}
+
+const getConversationByID = `-- name: GetConversationByID :one
+SELECT id, user_id, title, created_at, updated_at FROM conversations
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetConversationByID(ctx context.Context, id uuid.UUID) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationByID, id)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getConversationMessages = `-- name: GetConversationMessages :many
+SELECT id, conversation_id, role, content, retrieval_query, cited_pageids, created_at FROM conversation_messages
+WHERE conversation_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetConversationMessages(ctx context.Context, conversationID uuid.UUID) ([]ConversationMessage, error) {
+	rows, err := q.db.QueryContext(ctx, getConversationMessages, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ConversationMessage
+	for rows.Next() {
+		var i ConversationMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.Role,
+			&i.Content,
+			&i.RetrievalQuery,
+			pq.Array(&i.CitedPageids),
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentConversationMessages = `-- name: GetRecentConversationMessages :many
+SELECT id, conversation_id, role, content, retrieval_query, cited_pageids, created_at FROM (
+  SELECT id, conversation_id, role, content, retrieval_query, cited_pageids, created_at FROM conversation_messages
+  WHERE conversation_id = $1
+  ORDER BY created_at DESC
+  LIMIT $2
+) recent
+ORDER BY created_at ASC
+`
+
+type GetRecentConversationMessagesParams struct {
+	ConversationID uuid.UUID
+	Limit          int32
+}
+
+func (q *Queries) GetRecentConversationMessages(ctx context.Context, arg GetRecentConversationMessagesParams) ([]ConversationMessage, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentConversationMessages, arg.ConversationID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ConversationMessage
+	for rows.Next() {
+		var i ConversationMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.Role,
+			&i.Content,
+			&i.RetrievalQuery,
+			pq.Array(&i.CitedPageids),
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConversationsByUser = `-- name: ListConversationsByUser :many
+SELECT id, user_id, title, created_at, updated_at FROM conversations
+WHERE user_id = $1
+ORDER BY updated_at DESC
+`
+
+func (q *Queries) ListConversationsByUser(ctx context.Context, userID uuid.UUID) ([]Conversation, error) {
+	rows, err := q.db.QueryContext(ctx, listConversationsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Conversation
+	for rows.Next() {
+		var i Conversation
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchConversation = `-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TouchConversation(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchConversation, id)
+	return err
+}
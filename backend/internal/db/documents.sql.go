@@ -0,0 +1,358 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: documents.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createDocument = `-- name: CreateDocument :one
+INSERT INTO documents (title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, doc_embedding)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::vector)
+RETURNING id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at
+`
+
+type CreateDocumentParams struct {
+	Title          sql.NullString
+	Filename       sql.NullString
+	MimeType       sql.NullString
+	OwnerID        uuid.NullUUID
+	SizeBytes      int64
+	ChunkCount     int32
+	EmbeddingModel sql.NullString
+	MissionID      uuid.NullUUID
+	AttachmentID   uuid.NullUUID
+	DocEmbedding   interface{}
+}
+
+type CreateDocumentRow struct {
+	ID             uuid.UUID
+	Title          sql.NullString
+	Filename       sql.NullString
+	MimeType       sql.NullString
+	OwnerID        uuid.NullUUID
+	SizeBytes      int64
+	ChunkCount     int32
+	EmbeddingModel sql.NullString
+	MissionID      uuid.NullUUID
+	AttachmentID   uuid.NullUUID
+	CreatedAt      sql.NullTime
+}
+
+func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams) (CreateDocumentRow, error) {
+	row := q.db.QueryRowContext(ctx, createDocument,
+		arg.Title,
+		arg.Filename,
+		arg.MimeType,
+		arg.OwnerID,
+		arg.SizeBytes,
+		arg.ChunkCount,
+		arg.EmbeddingModel,
+		arg.MissionID,
+		arg.AttachmentID,
+		arg.DocEmbedding,
+	)
+	var i CreateDocumentRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Filename,
+		&i.MimeType,
+		&i.OwnerID,
+		&i.SizeBytes,
+		&i.ChunkCount,
+		&i.EmbeddingModel,
+		&i.MissionID,
+		&i.AttachmentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAttachmentDocument = `-- name: DeleteAttachmentDocument :exec
+DELETE FROM documents
+WHERE attachment_id = $1
+`
+
+func (q *Queries) DeleteAttachmentDocument(ctx context.Context, attachmentID uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, deleteAttachmentDocument, attachmentID)
+	return err
+}
+
+const deleteDocument = `-- name: DeleteDocument :exec
+DELETE FROM documents
+WHERE id = $1 AND mission_id IS NULL
+`
+
+func (q *Queries) DeleteDocument(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteDocument, id)
+	return err
+}
+
+const getDocumentByID = `-- name: GetDocumentByID :one
+
+SELECT id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at
+FROM documents
+WHERE id = $1 AND mission_id IS NULL
+LIMIT 1
+`
+
+type GetDocumentByIDRow struct {
+	ID             uuid.UUID
+	Title          sql.NullString
+	Filename       sql.NullString
+	MimeType       sql.NullString
+	OwnerID        uuid.NullUUID
+	SizeBytes      int64
+	ChunkCount     int32
+	EmbeddingModel sql.NullString
+	MissionID      uuid.NullUUID
+	AttachmentID   uuid.NullUUID
+	CreatedAt      sql.NullTime
+}
+
+// GetDocumentByID, ListDocuments and DeleteDocument leave out documents
+// indexed from mission attachments, which are reached through their mission.
+func (q *Queries) GetDocumentByID(ctx context.Context, id uuid.UUID) (GetDocumentByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getDocumentByID, id)
+	var i GetDocumentByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Filename,
+		&i.MimeType,
+		&i.OwnerID,
+		&i.SizeBytes,
+		&i.ChunkCount,
+		&i.EmbeddingModel,
+		&i.MissionID,
+		&i.AttachmentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const insertChunk = `-- name: InsertChunk :exec
+INSERT INTO chunks (document_id, chunk_index, content, embedding)
+VALUES ($1, $2, $3, $4::vector)
+`
+
+type InsertChunkParams struct {
+	DocumentID uuid.NullUUID
+	ChunkIndex sql.NullInt32
+	Content    sql.NullString
+	Embedding  interface{}
+}
+
+func (q *Queries) InsertChunk(ctx context.Context, arg InsertChunkParams) error {
+	_, err := q.db.ExecContext(ctx, insertChunk,
+		arg.DocumentID,
+		arg.ChunkIndex,
+		arg.Content,
+		arg.Embedding,
+	)
+	return err
+}
+
+const listDocuments = `-- name: ListDocuments :many
+SELECT id, title, filename, mime_type, owner_id, size_bytes, chunk_count, embedding_model, mission_id, attachment_id, created_at
+FROM documents
+WHERE mission_id IS NULL
+  AND ($1::uuid IS NULL OR owner_id = $1::uuid)
+ORDER BY created_at DESC
+`
+
+type ListDocumentsRow struct {
+	ID             uuid.UUID
+	Title          sql.NullString
+	Filename       sql.NullString
+	MimeType       sql.NullString
+	OwnerID        uuid.NullUUID
+	SizeBytes      int64
+	ChunkCount     int32
+	EmbeddingModel sql.NullString
+	MissionID      uuid.NullUUID
+	AttachmentID   uuid.NullUUID
+	CreatedAt      sql.NullTime
+}
+
+// A NULL owner lists every document, for admins
+func (q *Queries) ListDocuments(ctx context.Context, ownerID uuid.NullUUID) ([]ListDocumentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDocuments, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDocumentsRow
+	for rows.Next() {
+		var i ListDocumentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Filename,
+			&i.MimeType,
+			&i.OwnerID,
+			&i.SizeBytes,
+			&i.ChunkCount,
+			&i.EmbeddingModel,
+			&i.MissionID,
+			&i.AttachmentID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnindexedMissionAttachments = `-- name: ListUnindexedMissionAttachments :many
+SELECT a.id, a.mission_id, a.file_url, a.file_type, a.created_at, a.indexed_model FROM mission_attachments a
+WHERE a.mission_id = $1
+  AND a.indexed_model IS DISTINCT FROM $2::text
+  AND NOT EXISTS (
+    SELECT 1 FROM documents d
+    WHERE d.attachment_id = a.id AND d.embedding_model = $2::text
+  )
+ORDER BY a.created_at
+`
+
+type ListUnindexedMissionAttachmentsParams struct {
+	MissionID uuid.UUID
+	Model     string
+}
+
+// Attachments not yet indexed under the given model, either never indexed or
+// indexed before the embedding model changed. Files found to hold no text
+// have no document and are told apart by indexed_model.
+func (q *Queries) ListUnindexedMissionAttachments(ctx context.Context, arg ListUnindexedMissionAttachmentsParams) ([]MissionAttachment, error) {
+	rows, err := q.db.QueryContext(ctx, listUnindexedMissionAttachments, arg.MissionID, arg.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MissionAttachment
+	for rows.Next() {
+		var i MissionAttachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.MissionID,
+			&i.FileUrl,
+			&i.FileType,
+			&i.CreatedAt,
+			&i.IndexedModel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAttachmentIndexed = `-- name: MarkAttachmentIndexed :exec
+UPDATE mission_attachments
+SET indexed_model = $1::text
+WHERE id = $2
+`
+
+type MarkAttachmentIndexedParams struct {
+	Model string
+	ID    uuid.UUID
+}
+
+func (q *Queries) MarkAttachmentIndexed(ctx context.Context, arg MarkAttachmentIndexedParams) error {
+	_, err := q.db.ExecContext(ctx, markAttachmentIndexed, arg.Model, arg.ID)
+	return err
+}
+
+const searchChunks = `-- name: SearchChunks :many
+SELECT c.id, c.document_id, c.chunk_index, c.content, d.title, d.filename,
+       (c.embedding <=> $1::vector)::float8 AS distance
+FROM chunks c
+JOIN documents d ON d.id = c.document_id
+WHERE d.embedding_model = $2::text
+  AND (cardinality($3::uuid[]) = 0 OR c.document_id = ANY($3::uuid[]))
+  AND ($4::uuid IS NULL OR d.owner_id = $4::uuid)
+  AND d.mission_id IS NOT DISTINCT FROM $5::uuid
+ORDER BY c.embedding <=> $1::vector
+LIMIT $6
+`
+
+type SearchChunksParams struct {
+	Embedding   interface{}
+	Model       string
+	DocumentIds []uuid.UUID
+	OwnerID     uuid.NullUUID
+	MissionID   uuid.NullUUID
+	Lim         int32
+}
+
+type SearchChunksRow struct {
+	ID         uuid.UUID
+	DocumentID uuid.NullUUID
+	ChunkIndex sql.NullInt32
+	Content    sql.NullString
+	Title      sql.NullString
+	Filename   sql.NullString
+	Distance   float64
+}
+
+// Nearest chunks by cosine distance, matching the ivfflat index.
+// document_ids and owner_id narrow the search. A mission_id searches that
+// mission's attachments only; without one attachments are left out.
+func (q *Queries) SearchChunks(ctx context.Context, arg SearchChunksParams) ([]SearchChunksRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChunks,
+		arg.Embedding,
+		arg.Model,
+		pq.Array(arg.DocumentIds),
+		arg.OwnerID,
+		arg.MissionID,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChunksRow
+	for rows.Next() {
+		var i SearchChunksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DocumentID,
+			&i.ChunkIndex,
+			&i.Content,
+			&i.Title,
+			&i.Filename,
+			&i.Distance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: embedding_cache.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const deleteExpiredEmbeddings = `-- name: DeleteExpiredEmbeddings :exec
+DELETE FROM embedding_cache
+WHERE created_at <= $1
+`
+
+func (q *Queries) DeleteExpiredEmbeddings(ctx context.Context, createdAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredEmbeddings, createdAt)
+	return err
+}
+
+const getCachedEmbedding = `-- name: GetCachedEmbedding :one
+SELECT embedding FROM embedding_cache
+WHERE cache_key = $1 AND created_at > $2
+`
+
+type GetCachedEmbeddingParams struct {
+	CacheKey  string
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetCachedEmbedding(ctx context.Context, arg GetCachedEmbeddingParams) ([]float32, error) {
+	row := q.db.QueryRowContext(ctx, getCachedEmbedding, arg.CacheKey, arg.CreatedAt)
+	var embedding []float32
+	err := row.Scan(pq.Array(&embedding))
+	return embedding, err
+}
+
+const putCachedEmbedding = `-- name: PutCachedEmbedding :exec
+INSERT INTO embedding_cache (cache_key, model, embedding)
+VALUES ($1, $2, $3)
+ON CONFLICT (cache_key) DO UPDATE
+SET embedding = EXCLUDED.embedding, created_at = NOW()
+`
+
+type PutCachedEmbeddingParams struct {
+	CacheKey  string
+	Model     string
+	Embedding []float32
+}
+
+func (q *Queries) PutCachedEmbedding(ctx context.Context, arg PutCachedEmbeddingParams) error {
+	_, err := q.db.ExecContext(ctx, putCachedEmbedding, arg.CacheKey, arg.Model, pq.Array(arg.Embedding))
+	return err
+}
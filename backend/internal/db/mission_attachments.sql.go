@@ -15,7 +15,7 @@ import (
 const createMissionAttachment = `-- name: CreateMissionAttachment :one
 INSERT INTO mission_attachments (mission_id, file_url, file_type)
 VALUES ($1, $2, $3)
-RETURNING id, mission_id, file_url, file_type, created_at
+RETURNING id, mission_id, file_url, file_type, created_at, indexed_model
 `
 
 type CreateMissionAttachmentParams struct {
@@ -33,6 +33,7 @@ func (q *Queries) CreateMissionAttachment(ctx context.Context, arg CreateMission
 		&i.FileUrl,
 		&i.FileType,
 		&i.CreatedAt,
+		&i.IndexedModel,
 	)
 	return i, err
 }
@@ -48,7 +49,7 @@ func (q *Queries) DeleteMissionAttachment(ctx context.Context, id uuid.UUID) err
 }
 
 const getAttachmentsByMission = `-- name: GetAttachmentsByMission :many
-SELECT id, mission_id, file_url, file_type, created_at FROM mission_attachments
+SELECT id, mission_id, file_url, file_type, created_at, indexed_model FROM mission_attachments
 WHERE mission_id = $1
 `
 
@@ -67,6 +68,7 @@ func (q *Queries) GetAttachmentsByMission(ctx context.Context, missionID uuid.UU
 			&i.FileUrl,
 			&i.FileType,
 			&i.CreatedAt,
+			&i.IndexedModel,
 		); err != nil {
 			return nil, err
 		}
@@ -82,7 +84,7 @@ func (q *Queries) GetAttachmentsByMission(ctx context.Context, missionID uuid.UU
 }
 
 const getMissionAttachmentByID = `-- name: GetMissionAttachmentByID :one
-SELECT id, mission_id, file_url, file_type, created_at FROM mission_attachments
+SELECT id, mission_id, file_url, file_type, created_at, indexed_model FROM mission_attachments
 WHERE id = $1
 LIMIT 1
 `
@@ -96,6 +98,7 @@ func (q *Queries) GetMissionAttachmentByID(ctx context.Context, id uuid.UUID) (M
 		&i.FileUrl,
 		&i.FileType,
 		&i.CreatedAt,
+		&i.IndexedModel,
 	)
 	return i, err
 }
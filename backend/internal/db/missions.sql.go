@@ -144,6 +144,145 @@ func (q *Queries) GetMissionsByUser(ctx context.Context, userID uuid.UUID) ([]Mi
 	return items, nil
 }
 
+const getMissionsInRange = `-- name: GetMissionsInRange :many
+SELECT id, user_id, title, description, mission_type, latitude, longitude, start_time, end_time, threat_level, success, created_at, updated_at FROM missions
+WHERE user_id = $1
+  AND start_time < $2::timestamptz
+  AND COALESCE(end_time, start_time) >= $3::timestamptz
+ORDER BY start_time ASC
+`
+
+type GetMissionsInRangeParams struct {
+	UserID     uuid.UUID
+	RangeEnd   time.Time
+	RangeStart time.Time
+}
+
+// Missions overlapping [range_start, range_end), a mission without an end
+// counting as the instant it starts
+func (q *Queries) GetMissionsInRange(ctx context.Context, arg GetMissionsInRangeParams) ([]Mission, error) {
+	rows, err := q.db.QueryContext(ctx, getMissionsInRange, arg.UserID, arg.RangeEnd, arg.RangeStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mission
+	for rows.Next() {
+		var i Mission
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.MissionType,
+			&i.Latitude,
+			&i.Longitude,
+			&i.StartTime,
+			&i.EndTime,
+			&i.ThreatLevel,
+			&i.Success,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMissionsPage = `-- name: ListMissionsPage :many
+SELECT id, user_id, title, description, mission_type, latitude, longitude, start_time, end_time, threat_level, success, created_at, updated_at FROM missions
+WHERE (user_id = $1
+       OR ($2::boolean AND user_id IN (
+         SELECT u.id FROM users u
+         JOIN squads s ON s.id = u.squad_id
+         WHERE s.commander_id = $1)))
+  AND ($3::timestamptz IS NULL OR start_time < $3::timestamptz)
+  AND ($4::timestamptz IS NULL
+       OR COALESCE(end_time, start_time) >= $4::timestamptz)
+  AND ($5::mission_type_enum IS NULL
+       OR mission_type = $5::mission_type_enum)
+  AND ($6::threat_level_enum IS NULL
+       OR threat_level = $6::threat_level_enum)
+  AND ($7::boolean IS NULL OR success = $7::boolean)
+  AND ($8::uuid IS NULL
+       OR (start_time, id) < ($9::timestamptz, $8::uuid))
+ORDER BY start_time DESC, id DESC
+LIMIT $10
+`
+
+type ListMissionsPageParams struct {
+	UserID       uuid.UUID
+	IncludeSquad bool
+	RangeEnd     sql.NullTime
+	RangeStart   sql.NullTime
+	MissionType  NullMissionTypeEnum
+	ThreatLevel  NullThreatLevelEnum
+	Success      sql.NullBool
+	AfterID      uuid.NullUUID
+	AfterStart   sql.NullTime
+	Lim          int32
+}
+
+// Newest first with keyset pagination on (start_time, id). include_squad
+// adds the missions of members of squads the user commands. range_start and
+// range_end keep the missions overlapping that window; NULL filters match
+// every mission.
+func (q *Queries) ListMissionsPage(ctx context.Context, arg ListMissionsPageParams) ([]Mission, error) {
+	rows, err := q.db.QueryContext(ctx, listMissionsPage,
+		arg.UserID,
+		arg.IncludeSquad,
+		arg.RangeEnd,
+		arg.RangeStart,
+		arg.MissionType,
+		arg.ThreatLevel,
+		arg.Success,
+		arg.AfterID,
+		arg.AfterStart,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mission
+	for rows.Next() {
+		var i Mission
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.MissionType,
+			&i.Latitude,
+			&i.Longitude,
+			&i.StartTime,
+			&i.EndTime,
+			&i.ThreatLevel,
+			&i.Success,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateMission = `-- name: UpdateMission :one
 UPDATE missions
 SET title = $2, description = $3, mission_type = $4,
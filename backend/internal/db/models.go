@@ -14,6 +14,48 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type MessageRoleEnum string
+
+const (
+	MessageRoleEnumUser      MessageRoleEnum = "user"
+	MessageRoleEnumAssistant MessageRoleEnum = "assistant"
+)
+
+func (e *MessageRoleEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = MessageRoleEnum(s)
+	case string:
+		*e = MessageRoleEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for MessageRoleEnum: %T", src)
+	}
+	return nil
+}
+
+type NullMessageRoleEnum struct {
+	MessageRoleEnum MessageRoleEnum
+	Valid           bool // Valid is true if MessageRoleEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullMessageRoleEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.MessageRoleEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.MessageRoleEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullMessageRoleEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.MessageRoleEnum), nil
+}
+
 type MissionTypeEnum string
 
 const (
@@ -99,6 +141,48 @@ func (ns NullNotificationTypeEnum) Value() (driver.Value, error) {
 	return string(ns.NotificationTypeEnum), nil
 }
 
+type SpellChangeKindEnum string
+
+const (
+	SpellChangeKindEnumUpdated SpellChangeKindEnum = "updated"
+	SpellChangeKindEnumMissing SpellChangeKindEnum = "missing"
+)
+
+func (e *SpellChangeKindEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SpellChangeKindEnum(s)
+	case string:
+		*e = SpellChangeKindEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SpellChangeKindEnum: %T", src)
+	}
+	return nil
+}
+
+type NullSpellChangeKindEnum struct {
+	SpellChangeKindEnum SpellChangeKindEnum
+	Valid               bool // Valid is true if SpellChangeKindEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSpellChangeKindEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.SpellChangeKindEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SpellChangeKindEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSpellChangeKindEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SpellChangeKindEnum), nil
+}
+
 type ThreatLevelEnum string
 
 const (
@@ -143,6 +227,49 @@ func (ns NullThreatLevelEnum) Value() (driver.Value, error) {
 	return string(ns.ThreatLevelEnum), nil
 }
 
+type UserRoleEnum string
+
+const (
+	UserRoleEnumAgent     UserRoleEnum = "agent"
+	UserRoleEnumCommander UserRoleEnum = "commander"
+	UserRoleEnumAdmin     UserRoleEnum = "admin"
+)
+
+func (e *UserRoleEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRoleEnum(s)
+	case string:
+		*e = UserRoleEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRoleEnum: %T", src)
+	}
+	return nil
+}
+
+type NullUserRoleEnum struct {
+	UserRoleEnum UserRoleEnum
+	Valid        bool // Valid is true if UserRoleEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRoleEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRoleEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRoleEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRoleEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRoleEnum), nil
+}
+
 type CalendarEvent struct {
 	ID          uuid.UUID
 	UserID      uuid.UUID
@@ -152,6 +279,33 @@ type CalendarEvent struct {
 	EndTime     sql.NullTime
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Rrule       sql.NullString
+	Timezone    string
+	SeriesEnd   sql.NullTime
+	Uid         sql.NullString
+}
+
+type CalendarEventOverride struct {
+	ID            uuid.UUID
+	EventID       uuid.UUID
+	OriginalStart time.Time
+	Title         sql.NullString
+	Description   sql.NullString
+	StartTime     time.Time
+	EndTime       sql.NullTime
+	Cancelled     bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type CalendarFeedToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  string
+	Name       sql.NullString
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+	CreatedAt  time.Time
 }
 
 type Chunk struct {
@@ -163,13 +317,44 @@ type Chunk struct {
 	CreatedAt  sql.NullTime
 }
 
+type Conversation struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ConversationMessage struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	Role           MessageRoleEnum
+	Content        string
+	RetrievalQuery sql.NullString
+	CitedPageids   []int32
+	CreatedAt      time.Time
+}
+
 type Document struct {
-	ID           uuid.UUID
-	Title        sql.NullString
-	Filename     sql.NullString
-	MimeType     sql.NullString
-	CreatedAt    sql.NullTime
-	DocEmbedding interface{}
+	ID             uuid.UUID
+	Title          sql.NullString
+	Filename       sql.NullString
+	MimeType       sql.NullString
+	CreatedAt      sql.NullTime
+	DocEmbedding   interface{}
+	OwnerID        uuid.NullUUID
+	SizeBytes      int64
+	ChunkCount     int32
+	EmbeddingModel sql.NullString
+	MissionID      uuid.NullUUID
+	AttachmentID   uuid.NullUUID
+}
+
+type EmbeddingCache struct {
+	CacheKey  string
+	Model     string
+	Embedding []float32
+	CreatedAt time.Time
 }
 
 type Mission struct {
@@ -189,11 +374,12 @@ type Mission struct {
 }
 
 type MissionAttachment struct {
-	ID        uuid.UUID
-	MissionID uuid.UUID
-	FileUrl   string
-	FileType  sql.NullString
-	CreatedAt time.Time
+	ID           uuid.UUID
+	MissionID    uuid.UUID
+	FileUrl      string
+	FileType     sql.NullString
+	CreatedAt    time.Time
+	IndexedModel sql.NullString
 }
 
 type MissionLog struct {
@@ -225,6 +411,28 @@ type OauthAccount struct {
 	CreatedAt      time.Time
 }
 
+type ReindexJob struct {
+	Model       string
+	LastPageid  int32
+	Embedded    int32
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt sql.NullTime
+}
+
+type Session struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	FamilyID         uuid.UUID
+	RefreshTokenHash string
+	UserAgent        sql.NullString
+	IpAddress        sql.NullString
+	ExpiresAt        time.Time
+	UsedAt           sql.NullTime
+	RevokedAt        sql.NullTime
+	CreatedAt        time.Time
+}
+
 type Spell struct {
 	Pageid              int32
 	Title               string
@@ -250,16 +458,52 @@ type Spell struct {
 	AlertTriggeredAt    sql.NullTime
 	CreatedAt           sql.NullTime
 	UpdatedAt           sql.NullTime
+	AliasText           sql.NullString
+	SearchTsv           interface{}
+}
+
+type SpellChange struct {
+	ID            int64
+	Pageid        int32
+	Kind          SpellChangeKindEnum
+	OldRevID      sql.NullInt64
+	NewRevID      sql.NullInt64
+	ChangedFields []string
+	Reembedded    bool
+	ChangedAt     time.Time
+}
+
+type SpellEmbedding struct {
+	Pageid    int32
+	Model     string
+	Embedding interface{}
+	CreatedAt time.Time
+}
+
+type SpellEmbeddingModel struct {
+	ID         bool
+	Model      sql.NullString
+	SwitchedAt time.Time
+}
+
+type Squad struct {
+	ID          uuid.UUID
+	Name        string
+	CommanderID uuid.NullUUID
+	CreatedAt   time.Time
 }
 
 type User struct {
-	ID           uuid.UUID
-	Email        string
-	PasswordHash sql.NullString
-	Name         sql.NullString
-	AvatarUrl    sql.NullString
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             uuid.UUID
+	Email          string
+	PasswordHash   sql.NullString
+	Name           sql.NullString
+	AvatarUrl      sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Role           UserRoleEnum
+	SquadID        uuid.NullUUID
+	ClearanceLevel int16
 }
 
 type UserGameStat struct {
@@ -0,0 +1,216 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: reindex.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const advanceReindexJob = `-- name: AdvanceReindexJob :exec
+UPDATE reindex_jobs
+SET last_pageid = $2, embedded = embedded + $3, updated_at = NOW()
+WHERE model = $1
+`
+
+type AdvanceReindexJobParams struct {
+	Model      string
+	LastPageid int32
+	Embedded   int32
+}
+
+func (q *Queries) AdvanceReindexJob(ctx context.Context, arg AdvanceReindexJobParams) error {
+	_, err := q.db.ExecContext(ctx, advanceReindexJob, arg.Model, arg.LastPageid, arg.Embedded)
+	return err
+}
+
+const completeReindexJob = `-- name: CompleteReindexJob :exec
+UPDATE reindex_jobs
+SET completed_at = NOW(), updated_at = NOW()
+WHERE model = $1
+`
+
+func (q *Queries) CompleteReindexJob(ctx context.Context, model string) error {
+	_, err := q.db.ExecContext(ctx, completeReindexJob, model)
+	return err
+}
+
+const copySpellEmbeddings = `-- name: CopySpellEmbeddings :exec
+UPDATE spells s
+SET embedding = se.embedding
+FROM spell_embeddings se
+WHERE se.pageid = s.pageid AND se.model = $1
+`
+
+func (q *Queries) CopySpellEmbeddings(ctx context.Context, model string) error {
+	_, err := q.db.ExecContext(ctx, copySpellEmbeddings, model)
+	return err
+}
+
+const countSpellsMissingEmbedding = `-- name: CountSpellsMissingEmbedding :one
+SELECT COUNT(*) FROM spells s
+WHERE NOT EXISTS (
+  SELECT 1 FROM spell_embeddings se
+  WHERE se.pageid = s.pageid AND se.model = $1
+)
+`
+
+func (q *Queries) CountSpellsMissingEmbedding(ctx context.Context, model string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSpellsMissingEmbedding, model)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteSpellEmbeddings = `-- name: DeleteSpellEmbeddings :exec
+DELETE FROM spell_embeddings
+WHERE model = $1
+`
+
+func (q *Queries) DeleteSpellEmbeddings(ctx context.Context, model string) error {
+	_, err := q.db.ExecContext(ctx, deleteSpellEmbeddings, model)
+	return err
+}
+
+const getSpellEmbeddingModel = `-- name: GetSpellEmbeddingModel :one
+SELECT model FROM spell_embedding_model
+`
+
+func (q *Queries) GetSpellEmbeddingModel(ctx context.Context) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getSpellEmbeddingModel)
+	var model sql.NullString
+	err := row.Scan(&model)
+	return model, err
+}
+
+const listSpellsToEmbed = `-- name: ListSpellsToEmbed :many
+SELECT s.pageid, s.title, s.summary, s.aliases, s.categories
+FROM spells s
+WHERE s.pageid > $1
+  AND NOT EXISTS (
+    SELECT 1 FROM spell_embeddings se
+    WHERE se.pageid = s.pageid AND se.model = $2
+  )
+ORDER BY s.pageid
+LIMIT $3
+`
+
+type ListSpellsToEmbedParams struct {
+	AfterPageid int32
+	Model       string
+	Lim         int32
+}
+
+type ListSpellsToEmbedRow struct {
+	Pageid     int32
+	Title      string
+	Summary    sql.NullString
+	Aliases    []string
+	Categories []string
+}
+
+func (q *Queries) ListSpellsToEmbed(ctx context.Context, arg ListSpellsToEmbedParams) ([]ListSpellsToEmbedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSpellsToEmbed, arg.AfterPageid, arg.Model, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSpellsToEmbedRow
+	for rows.Next() {
+		var i ListSpellsToEmbedRow
+		if err := rows.Scan(
+			&i.Pageid,
+			&i.Title,
+			&i.Summary,
+			pq.Array(&i.Aliases),
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetReindexJob = `-- name: ResetReindexJob :one
+INSERT INTO reindex_jobs (model)
+VALUES ($1)
+ON CONFLICT (model) DO UPDATE
+SET last_pageid = 0, embedded = 0, started_at = NOW(), updated_at = NOW(), completed_at = NULL
+RETURNING model, last_pageid, embedded, started_at, updated_at, completed_at
+`
+
+func (q *Queries) ResetReindexJob(ctx context.Context, model string) (ReindexJob, error) {
+	row := q.db.QueryRowContext(ctx, resetReindexJob, model)
+	var i ReindexJob
+	err := row.Scan(
+		&i.Model,
+		&i.LastPageid,
+		&i.Embedded,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const setSpellEmbeddingModel = `-- name: SetSpellEmbeddingModel :exec
+UPDATE spell_embedding_model
+SET model = $1, switched_at = NOW()
+`
+
+func (q *Queries) SetSpellEmbeddingModel(ctx context.Context, model sql.NullString) error {
+	_, err := q.db.ExecContext(ctx, setSpellEmbeddingModel, model)
+	return err
+}
+
+const startReindexJob = `-- name: StartReindexJob :one
+INSERT INTO reindex_jobs (model)
+VALUES ($1)
+ON CONFLICT (model) DO UPDATE
+SET updated_at = NOW()
+RETURNING model, last_pageid, embedded, started_at, updated_at, completed_at
+`
+
+func (q *Queries) StartReindexJob(ctx context.Context, model string) (ReindexJob, error) {
+	row := q.db.QueryRowContext(ctx, startReindexJob, model)
+	var i ReindexJob
+	err := row.Scan(
+		&i.Model,
+		&i.LastPageid,
+		&i.Embedded,
+		&i.StartedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const upsertSpellEmbedding = `-- name: UpsertSpellEmbedding :exec
+INSERT INTO spell_embeddings (pageid, model, embedding)
+VALUES ($1, $2, $3::vector)
+ON CONFLICT (pageid, model) DO UPDATE
+SET embedding = EXCLUDED.embedding, created_at = NOW()
+`
+
+type UpsertSpellEmbeddingParams struct {
+	Pageid    int32
+	Model     string
+	Embedding interface{}
+}
+
+func (q *Queries) UpsertSpellEmbedding(ctx context.Context, arg UpsertSpellEmbeddingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSpellEmbedding, arg.Pageid, arg.Model, arg.Embedding)
+	return err
+}
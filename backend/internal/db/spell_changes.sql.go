@@ -0,0 +1,233 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: spell_changes.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
+)
+
+const deleteOtherSpellEmbeddings = `-- name: DeleteOtherSpellEmbeddings :exec
+DELETE FROM spell_embeddings
+WHERE pageid = $1 AND model <> $2
+`
+
+type DeleteOtherSpellEmbeddingsParams struct {
+	Pageid int32
+	Model  string
+}
+
+func (q *Queries) DeleteOtherSpellEmbeddings(ctx context.Context, arg DeleteOtherSpellEmbeddingsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteOtherSpellEmbeddings, arg.Pageid, arg.Model)
+	return err
+}
+
+const getLatestSpellChangeKind = `-- name: GetLatestSpellChangeKind :one
+SELECT kind FROM spell_changes
+WHERE pageid = $1
+ORDER BY changed_at DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestSpellChangeKind(ctx context.Context, pageid int32) (SpellChangeKindEnum, error) {
+	row := q.db.QueryRowContext(ctx, getLatestSpellChangeKind, pageid)
+	var kind SpellChangeKindEnum
+	err := row.Scan(&kind)
+	return kind, err
+}
+
+const getSpellForRefresh = `-- name: GetSpellForRefresh :one
+SELECT pageid, title, url, summary, used_by_doctor_strange, page_rev_id,
+       image_url, categories, realities, first_appearance, aliases,
+       infobox, sections, outlinks
+FROM spells
+WHERE pageid = $1
+`
+
+type GetSpellForRefreshRow struct {
+	Pageid              int32
+	Title               string
+	Url                 string
+	Summary             sql.NullString
+	UsedByDoctorStrange bool
+	PageRevID           sql.NullInt64
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	FirstAppearance     sql.NullString
+	Aliases             []string
+	Infobox             pqtype.NullRawMessage
+	Sections            pqtype.NullRawMessage
+	Outlinks            pqtype.NullRawMessage
+}
+
+func (q *Queries) GetSpellForRefresh(ctx context.Context, pageid int32) (GetSpellForRefreshRow, error) {
+	row := q.db.QueryRowContext(ctx, getSpellForRefresh, pageid)
+	var i GetSpellForRefreshRow
+	err := row.Scan(
+		&i.Pageid,
+		&i.Title,
+		&i.Url,
+		&i.Summary,
+		&i.UsedByDoctorStrange,
+		&i.PageRevID,
+		&i.ImageUrl,
+		pq.Array(&i.Categories),
+		pq.Array(&i.Realities),
+		&i.FirstAppearance,
+		pq.Array(&i.Aliases),
+		&i.Infobox,
+		&i.Sections,
+		&i.Outlinks,
+	)
+	return i, err
+}
+
+const insertSpellChange = `-- name: InsertSpellChange :exec
+INSERT INTO spell_changes (pageid, kind, old_rev_id, new_rev_id, changed_fields, reembedded)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertSpellChangeParams struct {
+	Pageid        int32
+	Kind          SpellChangeKindEnum
+	OldRevID      sql.NullInt64
+	NewRevID      sql.NullInt64
+	ChangedFields []string
+	Reembedded    bool
+}
+
+func (q *Queries) InsertSpellChange(ctx context.Context, arg InsertSpellChangeParams) error {
+	_, err := q.db.ExecContext(ctx, insertSpellChange,
+		arg.Pageid,
+		arg.Kind,
+		arg.OldRevID,
+		arg.NewRevID,
+		pq.Array(arg.ChangedFields),
+		arg.Reembedded,
+	)
+	return err
+}
+
+const listSpellRevisions = `-- name: ListSpellRevisions :many
+SELECT pageid, page_rev_id
+FROM spells
+WHERE pageid > $1
+ORDER BY pageid
+LIMIT $2
+`
+
+type ListSpellRevisionsParams struct {
+	AfterPageid int32
+	Lim         int32
+}
+
+type ListSpellRevisionsRow struct {
+	Pageid    int32
+	PageRevID sql.NullInt64
+}
+
+func (q *Queries) ListSpellRevisions(ctx context.Context, arg ListSpellRevisionsParams) ([]ListSpellRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSpellRevisions, arg.AfterPageid, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSpellRevisionsRow
+	for rows.Next() {
+		var i ListSpellRevisionsRow
+		if err := rows.Scan(&i.Pageid, &i.PageRevID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSpellEmbedding = `-- name: SetSpellEmbedding :exec
+UPDATE spells
+SET embedding = $2::vector
+WHERE pageid = $1
+`
+
+type SetSpellEmbeddingParams struct {
+	Pageid    int32
+	Embedding interface{}
+}
+
+func (q *Queries) SetSpellEmbedding(ctx context.Context, arg SetSpellEmbeddingParams) error {
+	_, err := q.db.ExecContext(ctx, setSpellEmbedding, arg.Pageid, arg.Embedding)
+	return err
+}
+
+const updateSpellFromWiki = `-- name: UpdateSpellFromWiki :exec
+UPDATE spells
+SET title = $2,
+    url = $3,
+    summary = $4,
+    used_by_doctor_strange = $5,
+    page_rev_id = $6,
+    last_rev_ts = $7,
+    image_url = $8,
+    categories = $9,
+    realities = $10,
+    first_appearance = $11,
+    aliases = $12,
+    infobox = $13,
+    sections = $14,
+    outlinks = $15,
+    last_fetched_at = NOW(),
+    updated_at = NOW()
+WHERE pageid = $1
+`
+
+type UpdateSpellFromWikiParams struct {
+	Pageid              int32
+	Title               string
+	Url                 string
+	Summary             sql.NullString
+	UsedByDoctorStrange bool
+	PageRevID           sql.NullInt64
+	LastRevTs           sql.NullTime
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	FirstAppearance     sql.NullString
+	Aliases             []string
+	Infobox             pqtype.NullRawMessage
+	Sections            pqtype.NullRawMessage
+	Outlinks            pqtype.NullRawMessage
+}
+
+func (q *Queries) UpdateSpellFromWiki(ctx context.Context, arg UpdateSpellFromWikiParams) error {
+	_, err := q.db.ExecContext(ctx, updateSpellFromWiki,
+		arg.Pageid,
+		arg.Title,
+		arg.Url,
+		arg.Summary,
+		arg.UsedByDoctorStrange,
+		arg.PageRevID,
+		arg.LastRevTs,
+		arg.ImageUrl,
+		pq.Array(arg.Categories),
+		pq.Array(arg.Realities),
+		arg.FirstAppearance,
+		pq.Array(arg.Aliases),
+		arg.Infobox,
+		arg.Sections,
+		arg.Outlinks,
+	)
+	return err
+}
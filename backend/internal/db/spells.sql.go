@@ -8,66 +8,387 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/sqlc-dev/pqtype"
 )
 
-const getSpells = `-- name: GetSpells :many
-SELECT 
-    pageid,
-    title,
-    url,
-    summary,
-    categories,
-    realities,
-    origin,
-    power_class,
-    access_level,
-    aliases,
-    infobox,
-    embedding
-FROM public.spells
-LIMIT 12
+const countSpellCatalogue = `-- name: CountSpellCatalogue :one
+SELECT COUNT(*) FROM spells
+WHERE COALESCE(access_level, 0) <= $1::smallint
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::text IS NULL OR alias_text ILIKE '%' || $6::text || '%')
+  AND ($7::boolean IS NULL OR used_by_doctor_strange = $7::boolean)
 `
 
-type GetSpellsRow struct {
-	Pageid      int32
-	Title       string
-	Url         string
-	Summary     sql.NullString
-	Categories  []string
-	Realities   []string
-	Origin      sql.NullString
-	PowerClass  sql.NullString
-	AccessLevel sql.NullInt16
-	Aliases     []string
-	Infobox     pqtype.NullRawMessage
-	Embedding   interface{}
+type CountSpellCatalogueParams struct {
+	Clearance           int16
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	Alias               sql.NullString
+	UsedByDoctorStrange sql.NullBool
+}
+
+func (q *Queries) CountSpellCatalogue(ctx context.Context, arg CountSpellCatalogueParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSpellCatalogue,
+		arg.Clearance,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.Alias,
+		arg.UsedByDoctorStrange,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSpellCategoryFacets = `-- name: CountSpellCategoryFacets :many
+SELECT category::text AS value, COUNT(*) AS count
+FROM spells, unnest(categories) AS category
+WHERE COALESCE(access_level, 0) <= $1::smallint
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::text IS NULL OR alias_text ILIKE '%' || $6::text || '%')
+  AND ($7::boolean IS NULL OR used_by_doctor_strange = $7::boolean)
+GROUP BY category
+ORDER BY count DESC, category
+`
+
+type CountSpellCategoryFacetsParams struct {
+	Clearance           int16
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	Alias               sql.NullString
+	UsedByDoctorStrange sql.NullBool
+}
+
+type CountSpellCategoryFacetsRow struct {
+	Value string
+	Count int64
+}
+
+func (q *Queries) CountSpellCategoryFacets(ctx context.Context, arg CountSpellCategoryFacetsParams) ([]CountSpellCategoryFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countSpellCategoryFacets,
+		arg.Clearance,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.Alias,
+		arg.UsedByDoctorStrange,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSpellCategoryFacetsRow
+	for rows.Next() {
+		var i CountSpellCategoryFacetsRow
+		if err := rows.Scan(&i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSpellRealityFacets = `-- name: CountSpellRealityFacets :many
+SELECT reality::text AS value, COUNT(*) AS count
+FROM spells, unnest(realities) AS reality
+WHERE COALESCE(access_level, 0) <= $1::smallint
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::text IS NULL OR alias_text ILIKE '%' || $6::text || '%')
+  AND ($7::boolean IS NULL OR used_by_doctor_strange = $7::boolean)
+GROUP BY reality
+ORDER BY count DESC, reality
+`
+
+type CountSpellRealityFacetsParams struct {
+	Clearance           int16
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	Alias               sql.NullString
+	UsedByDoctorStrange sql.NullBool
+}
+
+type CountSpellRealityFacetsRow struct {
+	Value string
+	Count int64
+}
+
+func (q *Queries) CountSpellRealityFacets(ctx context.Context, arg CountSpellRealityFacetsParams) ([]CountSpellRealityFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countSpellRealityFacets,
+		arg.Clearance,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.Alias,
+		arg.UsedByDoctorStrange,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountSpellRealityFacetsRow
+	for rows.Next() {
+		var i CountSpellRealityFacetsRow
+		if err := rows.Scan(&i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMaxSpellAccessLevel = `-- name: GetMaxSpellAccessLevel :one
+SELECT COALESCE(MAX(access_level), 0)::smallint FROM spells
+`
+
+func (q *Queries) GetMaxSpellAccessLevel(ctx context.Context) (int16, error) {
+	row := q.db.QueryRowContext(ctx, getMaxSpellAccessLevel)
+	var column_1 int16
+	err := row.Scan(&column_1)
+	return column_1, err
 }
 
-func (q *Queries) GetSpells(ctx context.Context) ([]GetSpellsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getSpells)
+const getSpellByPageID = `-- name: GetSpellByPageID :one
+SELECT pageid, title, url, summary, used_by_doctor_strange, image_url,
+       categories, realities, first_appearance, aliases, infobox, sections,
+       outlinks, origin, power_class, COALESCE(access_level, 0)::smallint AS access_level,
+       restricted_reason, page_rev_id, last_rev_ts, last_fetched_at, updated_at
+FROM spells
+WHERE pageid = $1
+`
+
+type GetSpellByPageIDRow struct {
+	Pageid              int32
+	Title               string
+	Url                 string
+	Summary             sql.NullString
+	UsedByDoctorStrange bool
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	FirstAppearance     sql.NullString
+	Aliases             []string
+	Infobox             pqtype.NullRawMessage
+	Sections            pqtype.NullRawMessage
+	Outlinks            pqtype.NullRawMessage
+	Origin              sql.NullString
+	PowerClass          sql.NullString
+	AccessLevel         int16
+	RestrictedReason    sql.NullString
+	PageRevID           sql.NullInt64
+	LastRevTs           sql.NullTime
+	LastFetchedAt       time.Time
+	UpdatedAt           sql.NullTime
+}
+
+func (q *Queries) GetSpellByPageID(ctx context.Context, pageid int32) (GetSpellByPageIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getSpellByPageID, pageid)
+	var i GetSpellByPageIDRow
+	err := row.Scan(
+		&i.Pageid,
+		&i.Title,
+		&i.Url,
+		&i.Summary,
+		&i.UsedByDoctorStrange,
+		&i.ImageUrl,
+		pq.Array(&i.Categories),
+		pq.Array(&i.Realities),
+		&i.FirstAppearance,
+		pq.Array(&i.Aliases),
+		&i.Infobox,
+		&i.Sections,
+		&i.Outlinks,
+		&i.Origin,
+		&i.PowerClass,
+		&i.AccessLevel,
+		&i.RestrictedReason,
+		&i.PageRevID,
+		&i.LastRevTs,
+		&i.LastFetchedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSpellsByPageIDs = `-- name: GetSpellsByPageIDs :many
+SELECT pageid, title, summary, url, categories
+FROM spells
+WHERE pageid = ANY($1::int[])
+  AND COALESCE(access_level, 0) <= $2::smallint
+`
+
+type GetSpellsByPageIDsParams struct {
+	Pageids   []int32
+	Clearance int16
+}
+
+type GetSpellsByPageIDsRow struct {
+	Pageid     int32
+	Title      string
+	Summary    sql.NullString
+	Url        string
+	Categories []string
+}
+
+func (q *Queries) GetSpellsByPageIDs(ctx context.Context, arg GetSpellsByPageIDsParams) ([]GetSpellsByPageIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSpellsByPageIDs, pq.Array(arg.Pageids), arg.Clearance)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetSpellsRow
+	var items []GetSpellsByPageIDsRow
 	for rows.Next() {
-		var i GetSpellsRow
+		var i GetSpellsByPageIDsRow
+		if err := rows.Scan(
+			&i.Pageid,
+			&i.Title,
+			&i.Summary,
+			&i.Url,
+			pq.Array(&i.Categories),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSpellCatalogue = `-- name: ListSpellCatalogue :many
+SELECT pageid, title, url, summary, image_url, categories, realities,
+       origin, power_class, COALESCE(access_level, 0)::smallint AS access_level,
+       aliases, used_by_doctor_strange, infobox
+FROM spells
+WHERE COALESCE(access_level, 0) <= $1::smallint
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::text IS NULL OR alias_text ILIKE '%' || $6::text || '%')
+  AND ($7::boolean IS NULL OR used_by_doctor_strange = $7::boolean)
+  AND (
+    $8::int IS NULL
+    OR ($9::text = 'title'
+        AND (title, pageid) > ($10::text, $8::int))
+    OR ($9::text = '-title'
+        AND (title < $10::text
+             OR (title = $10::text AND pageid > $8::int)))
+    OR ($9::text = 'pageid' AND pageid > $8::int)
+    OR ($9::text = '-pageid' AND pageid < $8::int)
+  )
+ORDER BY
+  CASE WHEN $9::text = 'title' THEN title END ASC,
+  CASE WHEN $9::text = '-title' THEN title END DESC,
+  CASE WHEN $9::text = '-pageid' THEN pageid END DESC,
+  pageid ASC
+LIMIT $11
+`
+
+type ListSpellCatalogueParams struct {
+	Clearance           int16
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	Alias               sql.NullString
+	UsedByDoctorStrange sql.NullBool
+	AfterPageid         sql.NullInt32
+	Sort                string
+	AfterTitle          sql.NullString
+	Lim                 int32
+}
+
+type ListSpellCatalogueRow struct {
+	Pageid              int32
+	Title               string
+	Url                 string
+	Summary             sql.NullString
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	Origin              sql.NullString
+	PowerClass          sql.NullString
+	AccessLevel         int16
+	Aliases             []string
+	UsedByDoctorStrange bool
+	Infobox             pqtype.NullRawMessage
+}
+
+// Keyset pagination: after_title/after_pageid are the sort key of the last
+// spell on the previous page, NULL for the first page
+func (q *Queries) ListSpellCatalogue(ctx context.Context, arg ListSpellCatalogueParams) ([]ListSpellCatalogueRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSpellCatalogue,
+		arg.Clearance,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.Alias,
+		arg.UsedByDoctorStrange,
+		arg.AfterPageid,
+		arg.Sort,
+		arg.AfterTitle,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSpellCatalogueRow
+	for rows.Next() {
+		var i ListSpellCatalogueRow
 		if err := rows.Scan(
 			&i.Pageid,
 			&i.Title,
 			&i.Url,
 			&i.Summary,
+			&i.ImageUrl,
 			pq.Array(&i.Categories),
 			pq.Array(&i.Realities),
 			&i.Origin,
 			&i.PowerClass,
 			&i.AccessLevel,
 			pq.Array(&i.Aliases),
+			&i.UsedByDoctorStrange,
 			&i.Infobox,
-			&i.Embedding,
 		); err != nil {
 			return nil, err
 		}
@@ -82,27 +403,139 @@ func (q *Queries) GetSpells(ctx context.Context) ([]GetSpellsRow, error) {
 	return items, nil
 }
 
+const listSpellGraph = `-- name: ListSpellGraph :many
+SELECT pageid, title, url, summary, aliases,
+       COALESCE(access_level, 0)::smallint AS access_level, outlinks
+FROM spells
+ORDER BY pageid
+`
+
+type ListSpellGraphRow struct {
+	Pageid      int32
+	Title       string
+	Url         string
+	Summary     sql.NullString
+	Aliases     []string
+	AccessLevel int16
+	Outlinks    pqtype.NullRawMessage
+}
+
+func (q *Queries) ListSpellGraph(ctx context.Context) ([]ListSpellGraphRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSpellGraph)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSpellGraphRow
+	for rows.Next() {
+		var i ListSpellGraphRow
+		if err := rows.Scan(
+			&i.Pageid,
+			&i.Title,
+			&i.Url,
+			&i.Summary,
+			pq.Array(&i.Aliases),
+			&i.AccessLevel,
+			&i.Outlinks,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSpellAlertTriggered = `-- name: MarkSpellAlertTriggered :execrows
+UPDATE spells
+SET alert_triggered_at = NOW()
+WHERE pageid = $1
+  AND (alert_triggered_at IS NULL OR alert_triggered_at < $2::timestamptz)
+`
+
+type MarkSpellAlertTriggeredParams struct {
+	Pageid int32
+	Since  time.Time
+}
+
+// Stamps the spell unless it already alerted after since, so one alert
+// covers every match in a window
+func (q *Queries) MarkSpellAlertTriggered(ctx context.Context, arg MarkSpellAlertTriggeredParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markSpellAlertTriggered, arg.Pageid, arg.Since)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const searchSpells = `-- name: SearchSpells :many
-SELECT title, summary, url, categories
+
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason,
+       (embedding <-> $1::vector)::float8 AS distance
 FROM spells
-ORDER BY embedding <-> $1
-LIMIT $2
+WHERE embedding IS NOT NULL
+  AND ($2::float8 IS NULL OR embedding <-> $1::vector <= $2::float8)
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR realities && $3::text[])
+  AND (COALESCE(cardinality($4::text[]), 0) = 0 OR categories && $4::text[])
+  AND ($5::text IS NULL OR power_class = $5::text)
+  AND ($6::text IS NULL OR origin = $6::text)
+  AND ($7::boolean IS NULL OR used_by_doctor_strange = $7::boolean)
+  AND ($8::smallint IS NULL OR COALESCE(access_level, 0) <= $8::smallint)
+  AND ($9::smallint IS NULL OR COALESCE(access_level, 0) >= $9::smallint)
+ORDER BY embedding <-> $1::vector
+LIMIT $10
 `
 
 type SearchSpellsParams struct {
-	Embedding interface{}
-	Limit     int32
+	Embedding           interface{}
+	MaxDistance         sql.NullFloat64
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	UsedByDoctorStrange sql.NullBool
+	MaxAccessLevel      sql.NullInt16
+	MinAccessLevel      sql.NullInt16
+	Lim                 int32
 }
 
 type SearchSpellsRow struct {
-	Title      string
-	Summary    sql.NullString
-	Url        string
-	Categories []string
+	Pageid              int32
+	Title               string
+	Summary             sql.NullString
+	Url                 string
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	Origin              sql.NullString
+	PowerClass          sql.NullString
+	UsedByDoctorStrange bool
+	AccessLevel         sql.NullInt16
+	RestrictedReason    sql.NullString
+	Distance            float64
 }
 
+// SearchSpells, SearchSpellsFullText and SearchSpellsByName share the same
+// optional filters; empty arrays and NULLs leave a filter off.
 func (q *Queries) SearchSpells(ctx context.Context, arg SearchSpellsParams) ([]SearchSpellsRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchSpells, arg.Embedding, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, searchSpells,
+		arg.Embedding,
+		arg.MaxDistance,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.UsedByDoctorStrange,
+		arg.MaxAccessLevel,
+		arg.MinAccessLevel,
+		arg.Lim,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -111,10 +544,200 @@ func (q *Queries) SearchSpells(ctx context.Context, arg SearchSpellsParams) ([]S
 	for rows.Next() {
 		var i SearchSpellsRow
 		if err := rows.Scan(
+			&i.Pageid,
 			&i.Title,
 			&i.Summary,
 			&i.Url,
+			&i.ImageUrl,
 			pq.Array(&i.Categories),
+			pq.Array(&i.Realities),
+			&i.Origin,
+			&i.PowerClass,
+			&i.UsedByDoctorStrange,
+			&i.AccessLevel,
+			&i.RestrictedReason,
+			&i.Distance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSpellsByName = `-- name: SearchSpellsByName :many
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason
+FROM spells
+WHERE (title % $1::text OR $1::text <% alias_text)
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::boolean IS NULL OR used_by_doctor_strange = $6::boolean)
+  AND ($7::smallint IS NULL OR COALESCE(access_level, 0) <= $7::smallint)
+  AND ($8::smallint IS NULL OR COALESCE(access_level, 0) >= $8::smallint)
+ORDER BY GREATEST(
+  similarity(title, $1::text),
+  word_similarity($1::text, alias_text)
+) DESC
+LIMIT $9
+`
+
+type SearchSpellsByNameParams struct {
+	Query               string
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	UsedByDoctorStrange sql.NullBool
+	MaxAccessLevel      sql.NullInt16
+	MinAccessLevel      sql.NullInt16
+	Lim                 int32
+}
+
+type SearchSpellsByNameRow struct {
+	Pageid              int32
+	Title               string
+	Summary             sql.NullString
+	Url                 string
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	Origin              sql.NullString
+	PowerClass          sql.NullString
+	UsedByDoctorStrange bool
+	AccessLevel         sql.NullInt16
+	RestrictedReason    sql.NullString
+}
+
+func (q *Queries) SearchSpellsByName(ctx context.Context, arg SearchSpellsByNameParams) ([]SearchSpellsByNameRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchSpellsByName,
+		arg.Query,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.UsedByDoctorStrange,
+		arg.MaxAccessLevel,
+		arg.MinAccessLevel,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchSpellsByNameRow
+	for rows.Next() {
+		var i SearchSpellsByNameRow
+		if err := rows.Scan(
+			&i.Pageid,
+			&i.Title,
+			&i.Summary,
+			&i.Url,
+			&i.ImageUrl,
+			pq.Array(&i.Categories),
+			pq.Array(&i.Realities),
+			&i.Origin,
+			&i.PowerClass,
+			&i.UsedByDoctorStrange,
+			&i.AccessLevel,
+			&i.RestrictedReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSpellsFullText = `-- name: SearchSpellsFullText :many
+SELECT pageid, title, summary, url, image_url, categories, realities,
+       origin, power_class, used_by_doctor_strange, access_level, restricted_reason
+FROM spells
+WHERE search_tsv @@ websearch_to_tsquery('english', $1::text)
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR realities && $2::text[])
+  AND (COALESCE(cardinality($3::text[]), 0) = 0 OR categories && $3::text[])
+  AND ($4::text IS NULL OR power_class = $4::text)
+  AND ($5::text IS NULL OR origin = $5::text)
+  AND ($6::boolean IS NULL OR used_by_doctor_strange = $6::boolean)
+  AND ($7::smallint IS NULL OR COALESCE(access_level, 0) <= $7::smallint)
+  AND ($8::smallint IS NULL OR COALESCE(access_level, 0) >= $8::smallint)
+ORDER BY ts_rank_cd(search_tsv, websearch_to_tsquery('english', $1::text)) DESC
+LIMIT $9
+`
+
+type SearchSpellsFullTextParams struct {
+	Query               string
+	Realities           []string
+	Categories          []string
+	PowerClass          sql.NullString
+	Origin              sql.NullString
+	UsedByDoctorStrange sql.NullBool
+	MaxAccessLevel      sql.NullInt16
+	MinAccessLevel      sql.NullInt16
+	Lim                 int32
+}
+
+type SearchSpellsFullTextRow struct {
+	Pageid              int32
+	Title               string
+	Summary             sql.NullString
+	Url                 string
+	ImageUrl            sql.NullString
+	Categories          []string
+	Realities           []string
+	Origin              sql.NullString
+	PowerClass          sql.NullString
+	UsedByDoctorStrange bool
+	AccessLevel         sql.NullInt16
+	RestrictedReason    sql.NullString
+}
+
+func (q *Queries) SearchSpellsFullText(ctx context.Context, arg SearchSpellsFullTextParams) ([]SearchSpellsFullTextRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchSpellsFullText,
+		arg.Query,
+		pq.Array(arg.Realities),
+		pq.Array(arg.Categories),
+		arg.PowerClass,
+		arg.Origin,
+		arg.UsedByDoctorStrange,
+		arg.MaxAccessLevel,
+		arg.MinAccessLevel,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchSpellsFullTextRow
+	for rows.Next() {
+		var i SearchSpellsFullTextRow
+		if err := rows.Scan(
+			&i.Pageid,
+			&i.Title,
+			&i.Summary,
+			&i.Url,
+			&i.ImageUrl,
+			pq.Array(&i.Categories),
+			pq.Array(&i.Realities),
+			&i.Origin,
+			&i.PowerClass,
+			&i.UsedByDoctorStrange,
+			&i.AccessLevel,
+			&i.RestrictedReason,
 		); err != nil {
 			return nil, err
 		}
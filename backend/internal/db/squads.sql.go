@@ -0,0 +1,106 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: squads.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createSquad = `-- name: CreateSquad :one
+INSERT INTO squads (name, commander_id)
+VALUES ($1, $2)
+RETURNING id, name, commander_id, created_at
+`
+
+type CreateSquadParams struct {
+	Name        string
+	CommanderID uuid.NullUUID
+}
+
+func (q *Queries) CreateSquad(ctx context.Context, arg CreateSquadParams) (Squad, error) {
+	row := q.db.QueryRowContext(ctx, createSquad, arg.Name, arg.CommanderID)
+	var i Squad
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CommanderID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSquadByID = `-- name: GetSquadByID :one
+SELECT id, name, commander_id, created_at FROM squads
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSquadByID(ctx context.Context, id uuid.UUID) (Squad, error) {
+	row := q.db.QueryRowContext(ctx, getSquadByID, id)
+	var i Squad
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CommanderID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const isSquadMember = `-- name: IsSquadMember :one
+SELECT EXISTS (
+  SELECT 1 FROM users u
+  JOIN squads s ON s.id = u.squad_id
+  WHERE u.id = $1 AND s.commander_id = $2
+)
+`
+
+type IsSquadMemberParams struct {
+	ID          uuid.UUID
+	CommanderID uuid.NullUUID
+}
+
+func (q *Queries) IsSquadMember(ctx context.Context, arg IsSquadMemberParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSquadMember, arg.ID, arg.CommanderID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listSquads = `-- name: ListSquads :many
+SELECT id, name, commander_id, created_at FROM squads
+ORDER BY name ASC
+`
+
+func (q *Queries) ListSquads(ctx context.Context) ([]Squad, error) {
+	rows, err := q.db.QueryContext(ctx, listSquads)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Squad
+	for rows.Next() {
+		var i Squad
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CommanderID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
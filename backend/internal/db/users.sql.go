@@ -15,7 +15,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash, name, avatar_url)
 VALUES ($1, $2, $3, $4)
-RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at
+RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level
 `
 
 type CreateUserParams struct {
@@ -41,6 +41,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
 	)
 	return i, err
 }
@@ -68,7 +71,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, name, avatar_url, created_at, updated_at FROM users
+SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level FROM users
 WHERE email = $1
 LIMIT 1
 `
@@ -84,12 +87,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, name, avatar_url, created_at, updated_at FROM users
+SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level FROM users
 WHERE id = $1
 LIMIT 1
 `
@@ -105,12 +111,43 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
 	)
 	return i, err
 }
 
+const listAdminIDs = `-- name: ListAdminIDs :many
+SELECT id FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) ListAdminIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listAdminIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, password_hash, name, avatar_url, created_at, updated_at FROM users
+SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level FROM users
 ORDER BY created_at DESC
 `
 
@@ -131,6 +168,9 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.AvatarUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Role,
+			&i.SquadID,
+			&i.ClearanceLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -146,7 +186,7 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 }
 
 const selectByEmail = `-- name: SelectByEmail :many
-SELECT id, email, password_hash, name, avatar_url, created_at, updated_at FROM users
+SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level FROM users
 WHERE email = $1
 `
 
@@ -167,6 +207,9 @@ func (q *Queries) SelectByEmail(ctx context.Context, email string) ([]User, erro
 			&i.AvatarUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Role,
+			&i.SquadID,
+			&i.ClearanceLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -181,12 +224,43 @@ func (q *Queries) SelectByEmail(ctx context.Context, email string) ([]User, erro
 	return items, nil
 }
 
+const setUserClearance = `-- name: SetUserClearance :one
+UPDATE users
+SET clearance_level = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level
+`
+
+type SetUserClearanceParams struct {
+	ID             uuid.UUID
+	ClearanceLevel int16
+}
+
+func (q *Queries) SetUserClearance(ctx context.Context, arg SetUserClearanceParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserClearance, arg.ID, arg.ClearanceLevel)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
+	)
+	return i, err
+}
+
 const setUserPassword = `-- name: SetUserPassword :one
 UPDATE users
 SET password_hash = $2,
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at
+RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level
 `
 
 type SetUserPasswordParams struct {
@@ -205,6 +279,42 @@ func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET role = $2,
+squad_id = $3,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level
+`
+
+type SetUserRoleParams struct {
+	ID      uuid.UUID
+	Role    UserRoleEnum
+	SquadID uuid.NullUUID
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role, arg.SquadID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
 	)
 	return i, err
 }
@@ -215,7 +325,7 @@ SET name = $2,
 avatar_url = $3,
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at
+RETURNING id, email, password_hash, name, avatar_url, created_at, updated_at, role, squad_id, clearance_level
 `
 
 type UpdateUserParams struct {
@@ -235,6 +345,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.AvatarUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.SquadID,
+		&i.ClearanceLevel,
 	)
 	return i, err
 }
@@ -0,0 +1,161 @@
+// Package embedcache caches query embeddings so repeated queries skip the
+// embedding API
+package embedcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+)
+
+// Store holds cached vectors by key. A missing or expired entry is reported
+// as ok == false.
+type Store interface {
+	Get(ctx context.Context, key string) (vec []float32, ok bool, err error)
+	Put(ctx context.Context, key, model string, vec []float32) error
+}
+
+// Stats are the cache counters since startup
+type Stats struct {
+	Mode   string `json:"mode"`
+	Hits   int64  `json:"hits"`
+	Misses int64  `json:"misses"`
+}
+
+// Cache is an llm.Embedder that serves repeated texts from a Store and only
+// sends misses to the wrapped embedder. Entries are keyed by the embedder's
+// model and the normalized text, so switching models never returns vectors
+// from the old one.
+type Cache struct {
+	inner  llm.Embedder
+	store  Store
+	mode   string
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New wraps inner with store. A nil store disables caching but still counts
+// every text as a miss.
+func New(inner llm.Embedder, store Store, mode string) *Cache {
+	return &Cache{inner: inner, store: store, mode: mode}
+}
+
+// FromEnv wraps inner with the cache selected by EMBED_CACHE.
+//
+//	EMBED_CACHE       memory (default), postgres or off
+//	EMBED_CACHE_SIZE  maximum entries kept in memory, defaults to 1000
+//	EMBED_CACHE_TTL   Go duration an entry stays valid, defaults to 24h
+func FromEnv(inner llm.Embedder, queries *db.Queries) (*Cache, error) {
+	ttl := 24 * time.Hour
+	if v := os.Getenv("EMBED_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid EMBED_CACHE_TTL %q", v)
+		}
+		ttl = d
+	}
+
+	size := 1000
+	if v := os.Getenv("EMBED_CACHE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid EMBED_CACHE_SIZE %q", v)
+		}
+		size = n
+	}
+
+	mode := os.Getenv("EMBED_CACHE")
+	switch mode {
+	case "", "memory":
+		return New(inner, NewMemoryStore(size, ttl), "memory"), nil
+	case "postgres":
+		return New(inner, NewPostgresStore(queries, ttl), mode), nil
+	case "off":
+		return New(inner, nil, mode), nil
+	default:
+		return nil, fmt.Errorf("unknown EMBED_CACHE %q", mode)
+	}
+}
+
+func (c *Cache) Model() string {
+	return c.inner.Model()
+}
+
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Mode:   c.mode,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Embed returns cached vectors where it can and embeds the rest in one call
+// to the wrapped embedder. Store errors are logged and treated as misses, so
+// a cache outage only costs latency.
+func (c *Cache) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if c.store == nil {
+		c.misses.Add(int64(len(texts)))
+		return c.inner.Embed(ctx, texts)
+	}
+
+	out := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	var missing []int
+
+	for i, text := range texts {
+		keys[i] = c.key(text)
+		vec, ok, err := c.store.Get(ctx, keys[i])
+		if err != nil {
+			log.Printf("embedding cache: get failed: %v", err)
+		}
+		if ok {
+			out[i] = vec
+			c.hits.Add(1)
+			continue
+		}
+		missing = append(missing, i)
+		c.misses.Add(1)
+	}
+
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	pending := make([]string, len(missing))
+	for j, i := range missing {
+		pending[j] = texts[i]
+	}
+	vecs, err := c.inner.Embed(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		out[i] = vecs[j]
+		if err := c.store.Put(ctx, keys[i], c.inner.Model(), vecs[j]); err != nil {
+			log.Printf("embedding cache: put failed: %v", err)
+		}
+	}
+	return out, nil
+}
+
+func (c *Cache) key(text string) string {
+	sum := sha256.Sum256([]byte(c.inner.Model() + "\x00" + Normalize(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Normalize lowercases text and collapses runs of whitespace, so "Time
+// spells " and "time  spells" share an entry
+func Normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
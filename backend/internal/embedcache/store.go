@@ -0,0 +1,131 @@
+package embedcache
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// MemoryStore is an LRU of at most size entries, each valid for ttl
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	vec       []float32
+	expiresAt time.Time
+}
+
+func NewMemoryStore(size int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(el)
+	return entry.vec, true, nil
+}
+
+func (m *MemoryStore) Put(ctx context.Context, key, model string, vec []float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt := time.Now().Add(m.ttl)
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.vec = vec
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, vec: vec, expiresAt: expiresAt})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// PostgresStore keeps entries in the embedding_cache table so they survive
+// restarts. Expired rows are ignored on read and pruned at most once per
+// pruneInterval.
+type PostgresStore struct {
+	db  *db.Queries
+	ttl time.Duration
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+const pruneInterval = time.Hour
+
+func NewPostgresStore(db *db.Queries, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, ttl: ttl}
+}
+
+func (p *PostgresStore) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	vec, err := p.db.GetCachedEmbedding(ctx, db.GetCachedEmbeddingParams{
+		CacheKey:  key,
+		CreatedAt: time.Now().Add(-p.ttl),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return vec, true, nil
+}
+
+func (p *PostgresStore) Put(ctx context.Context, key, model string, vec []float32) error {
+	if err := p.db.PutCachedEmbedding(ctx, db.PutCachedEmbeddingParams{
+		CacheKey:  key,
+		Model:     model,
+		Embedding: vec,
+	}); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	prune := time.Since(p.lastPruned) > pruneInterval
+	if prune {
+		p.lastPruned = time.Now()
+	}
+	p.mu.Unlock()
+
+	if prune {
+		return p.db.DeleteExpiredEmbeddings(ctx, time.Now().Add(-p.ttl))
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNotCalendar = errors.New("not an iCalendar file")
+
+// windowsZones maps the Windows zone names Outlook and Exchange put in TZID
+// to IANA names
+var windowsZones = map[string]string{
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"Eastern Standard Time":          "America/New_York",
+	"Central Standard Time":          "America/Chicago",
+	"Mountain Standard Time":         "America/Denver",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"India Standard Time":            "Asia/Kolkata",
+	"China Standard Time":            "Asia/Shanghai",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"E. South America Standard Time": "America/Sao_Paulo",
+}
+
+// contentLine is one unfolded property
+type contentLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode reads the VEVENTs of an iCalendar stream. Floating times and dates
+// are read in floating. A VEVENT that cannot be read is left out and its
+// error returned in skipped; err is only set when the stream as a whole is
+// unreadable.
+func Decode(r io.Reader, floating *time.Location) (cal *Calendar, skipped []error, err error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, nil, ErrNotCalendar
+	}
+
+	var parsed []contentLine
+	for _, l := range lines {
+		cl, err := parseLine(l)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, cl)
+	}
+
+	d := &decoder{floating: floating, offsets: map[string]int{}}
+	d.collectTimezones(parsed)
+
+	cal = &Calendar{}
+	var current []contentLine
+	depth, inEvent := 0, false
+	for _, cl := range parsed {
+		switch cl.name {
+		case "BEGIN":
+			depth++
+			if strings.EqualFold(cl.value, "VEVENT") && !inEvent {
+				inEvent, depth, current = true, 0, nil
+				continue
+			}
+		case "END":
+			depth--
+			if inEvent && depth < 0 {
+				inEvent = false
+				ev, err := d.event(current)
+				if err != nil {
+					skipped = append(skipped, err)
+					continue
+				}
+				cal.Events = append(cal.Events, ev)
+				continue
+			}
+		case "PRODID":
+			if !inEvent {
+				cal.ProdID = cl.value
+			}
+		case "X-WR-CALNAME":
+			if !inEvent {
+				cal.Name = unescapeText(cl.value)
+			}
+		}
+		// Only the VEVENT's own properties, not those of its VALARMs
+		if inEvent && depth == 0 && cl.name != "BEGIN" && cl.name != "END" {
+			current = append(current, cl)
+		}
+	}
+	return cal, skipped, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var lines []string
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), "\r")
+		if len(l) > 0 && (l[0] == ' ' || l[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+	return lines, nil
+}
+
+// parseLine splits name;PARAM=value;...:value, honouring quoted parameter
+// values that may contain ';' or ':'
+func parseLine(l string) (contentLine, error) {
+	cl := contentLine{params: map[string]string{}}
+	inQuote := false
+	start := 0
+	var key string
+	for i := 0; i < len(l); i++ {
+		switch c := l[i]; {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == ';' || c == ':':
+			part := l[start:i]
+			if cl.name == "" {
+				cl.name = strings.ToUpper(part)
+			} else if key != "" {
+				cl.params[key] = strings.Trim(part, `"`)
+				key = ""
+			}
+			if c == ':' {
+				cl.value = l[i+1:]
+				return cl, nil
+			}
+			start = i + 1
+		case c == '=' && cl.name != "" && key == "":
+			key = strings.ToUpper(l[start:i])
+			start = i + 1
+		}
+	}
+	return cl, fmt.Errorf("malformed line %q", l)
+}
+
+type decoder struct {
+	floating *time.Location
+	// offsets holds the standard offset of each VTIMEZONE, used when its
+	// TZID is not a zone Go knows
+	offsets map[string]int
+}
+
+func (d *decoder) collectTimezones(lines []contentLine) {
+	var tzid string
+	inStandard := false
+	for _, cl := range lines {
+		switch {
+		case cl.name == "BEGIN" && strings.EqualFold(cl.value, "VTIMEZONE"):
+			tzid = ""
+		case cl.name == "TZID" && tzid == "":
+			tzid = cl.value
+		case cl.name == "BEGIN" && strings.EqualFold(cl.value, "STANDARD"):
+			inStandard = true
+		case cl.name == "END" && strings.EqualFold(cl.value, "STANDARD"):
+			inStandard = false
+		case cl.name == "TZOFFSETTO" && inStandard && tzid != "":
+			if off, err := parseOffset(cl.value); err == nil {
+				if _, ok := d.offsets[tzid]; !ok {
+					d.offsets[tzid] = off
+				}
+			}
+		}
+	}
+}
+
+func (d *decoder) event(lines []contentLine) (Event, error) {
+	var ev Event
+	var duration string
+	var startParams map[string]string
+	var startValue, endValue string
+	var endParams map[string]string
+	for _, cl := range lines {
+		switch cl.name {
+		case "UID":
+			ev.UID = strings.TrimSpace(cl.value)
+		case "SUMMARY":
+			ev.Summary = unescapeText(cl.value)
+		case "DESCRIPTION":
+			ev.Description = unescapeText(cl.value)
+		case "LOCATION":
+			ev.Location = unescapeText(cl.value)
+		case "DTSTART":
+			startValue, startParams = cl.value, cl.params
+		case "DTEND":
+			endValue, endParams = cl.value, cl.params
+		case "DURATION":
+			duration = cl.value
+		case "RRULE":
+			ev.RRule = cl.value
+		case "STATUS":
+			ev.Status = strings.ToUpper(cl.value)
+		case "SEQUENCE":
+			ev.Sequence, _ = strconv.Atoi(cl.value)
+		case "CATEGORIES":
+			for _, c := range splitText(cl.value) {
+				ev.Categories = append(ev.Categories, unescapeText(c))
+			}
+		case "GEO":
+			if lat, lon, ok := strings.Cut(cl.value, ";"); ok {
+				la, err1 := strconv.ParseFloat(lat, 64)
+				lo, err2 := strconv.ParseFloat(lon, 64)
+				if err1 == nil && err2 == nil {
+					ev.Geo = &Geo{Lat: la, Lon: lo}
+				}
+			}
+		}
+	}
+
+	if startValue == "" {
+		return Event{}, fmt.Errorf("event %q has no DTSTART", ev.UID)
+	}
+	start, loc, allDay, err := d.dateTime(startValue, startParams)
+	if err != nil {
+		return Event{}, fmt.Errorf("event %q: DTSTART: %w", ev.UID, err)
+	}
+	ev.Start, ev.TimeZone = start, loc
+
+	switch {
+	case endValue != "":
+		if ev.End, _, _, err = d.dateTime(endValue, endParams); err != nil {
+			return Event{}, fmt.Errorf("event %q: DTEND: %w", ev.UID, err)
+		}
+	case duration != "":
+		dur, err := parseDuration(duration)
+		if err != nil {
+			return Event{}, fmt.Errorf("event %q: DURATION: %w", ev.UID, err)
+		}
+		ev.End = start.Add(dur)
+	case allDay:
+		ev.End = start.AddDate(0, 0, 1)
+	}
+	if !ev.End.IsZero() && ev.End.Before(ev.Start) {
+		return Event{}, fmt.Errorf("event %q ends before it starts", ev.UID)
+	}
+
+	for _, cl := range lines {
+		switch cl.name {
+		case "EXDATE":
+			for _, v := range strings.Split(cl.value, ",") {
+				t, _, _, err := d.dateTime(v, cl.params)
+				if err != nil {
+					return Event{}, fmt.Errorf("event %q: EXDATE: %w", ev.UID, err)
+				}
+				ev.ExDates = append(ev.ExDates, t)
+			}
+		case "RECURRENCE-ID":
+			if ev.RecurrenceID, _, _, err = d.dateTime(cl.value, cl.params); err != nil {
+				return Event{}, fmt.Errorf("event %q: RECURRENCE-ID: %w", ev.UID, err)
+			}
+		}
+	}
+	return ev, nil
+}
+
+// dateTime reads a DATE or DATE-TIME value with its TZID, returning the
+// location its wall clock is in and whether it was a date
+func (d *decoder) dateTime(v string, params map[string]string) (time.Time, *time.Location, bool, error) {
+	v = strings.TrimSpace(v)
+	loc := d.floating
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = d.location(tzid); err != nil {
+			return time.Time{}, nil, false, err
+		}
+	}
+
+	if strings.EqualFold(params["VALUE"], "DATE") || len(v) == 8 {
+		t, err := time.ParseInLocation("20060102", v, loc)
+		return t, loc, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(utcLayout, v)
+		return t, time.UTC, false, err
+	}
+	t, err := time.ParseInLocation(localLayout, v, loc)
+	return t, loc, false, err
+}
+
+// location resolves a TZID: an IANA name, one behind a vendor prefix such as
+// /mozilla.org/20050126_1/America/New_York, a Windows zone name, or failing
+// those a fixed zone at the VTIMEZONE's standard offset
+func (d *decoder) location(tzid string) (*time.Location, error) {
+	tzid = strings.Trim(tzid, `"`)
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	for i := range parts {
+		name := strings.Join(parts[i:], "/")
+		if name == "" || name == "Local" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+	}
+	if off, ok := d.offsets[tzid]; ok {
+		return time.FixedZone(tzid, off), nil
+	}
+	return nil, fmt.Errorf("unknown TZID %q", tzid)
+}
+
+func parseOffset(v string) (int, error) {
+	if len(v) != 5 && len(v) != 7 || (v[0] != '+' && v[0] != '-') {
+		return 0, fmt.Errorf("invalid offset %q", v)
+	}
+	h, err1 := strconv.Atoi(v[1:3])
+	m, err2 := strconv.Atoi(v[3:5])
+	s := 0
+	var err3 error
+	if len(v) == 7 {
+		s, err3 = strconv.Atoi(v[5:7])
+	}
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("invalid offset %q", v)
+	}
+	off := h*3600 + m*60 + s
+	if v[0] == '-' {
+		off = -off
+	}
+	return off, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an RFC 5545 DURATION such as PT1H30M or P1D
+func parseDuration(v string) (time.Duration, error) {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	m := durationPattern.FindStringSubmatch(v)
+	if m == nil || strings.HasSuffix(v, "P") || strings.HasSuffix(v, "T") {
+		return 0, fmt.Errorf("invalid duration %q", v)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// splitText splits a TEXT list on commas that are not escaped
+func splitText(v string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case ',':
+			out = append(out, v[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, v[start:])
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
@@ -0,0 +1,288 @@
+// Package ical reads and writes the parts of RFC 5545 iCalendar the
+// calendar exchanges with other tools: VEVENTs with their recurrence, and
+// the VTIMEZONEs their times refer to.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+)
+
+// Calendar is a VCALENDAR of events
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+type Geo struct {
+	Lat float64
+	Lon float64
+}
+
+// Property is an extra property such as X-SINEPSIS-THREAT-LEVEL, written as
+// escaped text
+type Property struct {
+	Name  string
+	Value string
+}
+
+// Event is a VEVENT. Times are written in TimeZone, as UTC when it is nil or
+// UTC and with its TZID otherwise. An event with a RecurrenceID replaces
+// that occurrence of the series sharing its UID.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	TimeZone     *time.Location
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time
+	Categories   []string
+	Geo          *Geo
+	Priority     int
+	Status       string
+	Sequence     int
+	Created      time.Time
+	LastModified time.Time
+	Extra        []Property
+}
+
+// Encode writes the calendar with CRLF line endings and lines folded at 75
+// octets. Every timezone an event uses gets a VTIMEZONE covering the span
+// from the earliest event to ten years from now.
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	e := &encoder{w: bw}
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:" + c.ProdID)
+	e.line("CALSCALE:GREGORIAN")
+	e.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+
+	for _, tz := range c.timezones() {
+		e.timezone(tz.loc, tz.from, time.Now().AddDate(10, 0, 0))
+	}
+
+	stamp := time.Now().UTC().Format(utcLayout)
+	for _, ev := range c.Events {
+		e.event(ev, stamp)
+	}
+	e.line("END:VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+type zoneSpan struct {
+	loc  *time.Location
+	from time.Time
+}
+
+// timezones lists the non-UTC locations used, each from its earliest event
+func (c *Calendar) timezones() []zoneSpan {
+	spans := map[string]*zoneSpan{}
+	for _, ev := range c.Events {
+		if !usesTZID(ev.TimeZone) {
+			continue
+		}
+		name := ev.TimeZone.String()
+		if s, ok := spans[name]; !ok {
+			spans[name] = &zoneSpan{loc: ev.TimeZone, from: ev.Start}
+		} else if ev.Start.Before(s.from) {
+			s.from = ev.Start
+		}
+	}
+
+	out := make([]zoneSpan, 0, len(spans))
+	for _, s := range spans {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].loc.String() < out[j].loc.String() })
+	return out
+}
+
+func usesTZID(loc *time.Location) bool {
+	return loc != nil && loc != time.UTC && loc.String() != "UTC"
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes one content line, folded so no physical line exceeds 75
+// octets and no UTF-8 sequence is split
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(s[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with the folding space
+		limit = 74
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+func (e *encoder) event(ev Event, stamp string) {
+	e.line("BEGIN:VEVENT")
+	e.line("UID:" + ev.UID)
+	e.line("DTSTAMP:" + stamp)
+	e.line(dateProp("DTSTART", ev.Start, ev.TimeZone))
+	if !ev.End.IsZero() {
+		e.line(dateProp("DTEND", ev.End, ev.TimeZone))
+	}
+	if !ev.RecurrenceID.IsZero() {
+		e.line(dateProp("RECURRENCE-ID", ev.RecurrenceID, ev.TimeZone))
+	}
+	if ev.RRule != "" {
+		e.line("RRULE:" + strings.TrimPrefix(ev.RRule, "RRULE:"))
+	}
+	for _, ex := range ev.ExDates {
+		e.line(dateProp("EXDATE", ex, ev.TimeZone))
+	}
+	e.line("SUMMARY:" + escapeText(ev.Summary))
+	if ev.Description != "" {
+		e.line("DESCRIPTION:" + escapeText(ev.Description))
+	}
+	if ev.Location != "" {
+		e.line("LOCATION:" + escapeText(ev.Location))
+	}
+	if ev.Geo != nil {
+		e.line(fmt.Sprintf("GEO:%s;%s", formatFloat(ev.Geo.Lat), formatFloat(ev.Geo.Lon)))
+	}
+	if len(ev.Categories) > 0 {
+		cats := make([]string, len(ev.Categories))
+		for i, c := range ev.Categories {
+			cats[i] = escapeText(c)
+		}
+		e.line("CATEGORIES:" + strings.Join(cats, ","))
+	}
+	if ev.Priority > 0 {
+		e.line("PRIORITY:" + strconv.Itoa(ev.Priority))
+	}
+	if ev.Status != "" {
+		e.line("STATUS:" + ev.Status)
+	}
+	e.line("SEQUENCE:" + strconv.Itoa(ev.Sequence))
+	if !ev.Created.IsZero() {
+		e.line("CREATED:" + ev.Created.UTC().Format(utcLayout))
+	}
+	if !ev.LastModified.IsZero() {
+		e.line("LAST-MODIFIED:" + ev.LastModified.UTC().Format(utcLayout))
+	}
+	for _, p := range ev.Extra {
+		e.line(p.Name + ":" + escapeText(p.Value))
+	}
+	e.line("END:VEVENT")
+}
+
+// timezone writes a VTIMEZONE with one observance per offset change of loc
+// between from and to, found by probing its zone data
+func (e *encoder) timezone(loc *time.Location, from, to time.Time) {
+	e.line("BEGIN:VTIMEZONE")
+	e.line("TZID:" + loc.String())
+
+	start := time.Date(from.In(loc).Year(), 1, 1, 0, 0, 0, 0, loc)
+	name, offset := start.Zone()
+	e.observance(start.IsDST(), name, offset, offset, start)
+
+	prev := offset
+	for t := start; t.Before(to); {
+		next := t.Add(24 * time.Hour)
+		if _, off := next.Zone(); off != prev {
+			// Narrow the change down to the second
+			lo, hi := t, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.Zone(); o == prev {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+			name, off := hi.Zone()
+			e.observance(hi.IsDST(), name, prev, off, hi)
+			prev = off
+		}
+		t = next
+	}
+	e.line("END:VTIMEZONE")
+}
+
+// observance writes a STANDARD or DAYLIGHT block starting at instant at,
+// whose local onset is given in the offset in force before it
+func (e *encoder) observance(dst bool, name string, from, to int, at time.Time) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	e.line("BEGIN:" + kind)
+	e.line("DTSTART:" + at.UTC().Add(time.Duration(from)*time.Second).Format(localLayout))
+	e.line("TZOFFSETFROM:" + formatOffset(from))
+	e.line("TZOFFSETTO:" + formatOffset(to))
+	if name != "" && !strings.ContainsAny(name, "+-") {
+		e.line("TZNAME:" + name)
+	}
+	e.line("END:" + kind)
+}
+
+func dateProp(name string, t time.Time, loc *time.Location) string {
+	if usesTZID(loc) {
+		return name + ";TZID=" + loc.String() + ":" + t.In(loc).Format(localLayout)
+	}
+	return name + ":" + t.UTC().Format(utcLayout)
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	s := fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		s += fmt.Sprintf("%02d", seconds%60)
+	}
+	return s
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// escapeText escapes a TEXT value
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
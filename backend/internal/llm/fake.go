@@ -0,0 +1,203 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fake is a deterministic offline provider. Embeddings hash each word into a
+// bucket, so texts sharing words land close together; generation extracts the
+// prompt lines that best overlap the question. It needs no credentials and
+// always returns the same output for the same input.
+type Fake struct {
+	dims int
+}
+
+func NewFake(dims int) *Fake {
+	if dims <= 0 {
+		dims = 768
+	}
+	return &Fake{dims: dims}
+}
+
+func (f *Fake) Model() string {
+	return fmt.Sprintf("fake/hash@%d", f.dims)
+}
+
+func (f *Fake) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		words := tokenize(text)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty text")
+		}
+
+		vec := make([]float32, f.dims)
+		for _, w := range words {
+			h := fnv.New64a()
+			h.Write([]byte(w))
+			sum := h.Sum64()
+			sign := float32(1)
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			vec[sum%uint64(f.dims)] += sign
+		}
+
+		var norm float64
+		for _, v := range vec {
+			norm += float64(v * v)
+		}
+		if norm == 0 {
+			// Words cancelling out in one bucket leave nothing to normalise;
+			// a fixed unit vector keeps the result valid for pgvector
+			vec[0] = 1
+			out[i] = vec
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for j := range vec {
+			vec[j] = float32(float64(vec[j]) / norm)
+		}
+		out[i] = vec
+	}
+	return out, nil
+}
+
+// Generate answers with the three prompt lines sharing the most words with
+// the question, which is the line starting with "Question:" or, failing that,
+// the first line.
+func (f *Fake) Generate(ctx context.Context, prompt string) (string, error) {
+	lines := f.extract(prompt)
+	if len(lines) == 0 {
+		return "I couldn't find anything relevant in the provided context.", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// GenerateJSON returns the Generate lines as {"segments": [{"text",
+// "pageids"}]}. A line starting with a bracketed id, as the mystic prompts
+// list spells, is attributed to that id.
+func (f *Fake) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	type segment struct {
+		Text    string  `json:"text"`
+		PageIDs []int64 `json:"pageids"`
+	}
+	segments := []segment{}
+	for _, line := range f.extract(prompt) {
+		seg := segment{Text: line, PageIDs: []int64{}}
+		if m := leadingID.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.ParseInt(m[1], 10, 64)
+			seg = segment{Text: strings.TrimSpace(line[len(m[0]):]), PageIDs: []int64{id}}
+		}
+		segments = append(segments, seg)
+	}
+
+	out, err := json.Marshal(map[string]any{"segments": segments})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+var leadingID = regexp.MustCompile(`^(?:- )?\[(\d+)\]`)
+
+// extract picks the prompt lines for an answer
+func (f *Fake) extract(prompt string) []string {
+	lines := strings.Split(prompt, "\n")
+
+	question := ""
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "Question:"); ok {
+			question = rest
+			break
+		}
+	}
+	if question == "" && len(lines) > 0 {
+		question = lines[0]
+	}
+
+	want := map[string]bool{}
+	for _, w := range tokenize(question) {
+		want[w] = true
+	}
+
+	type scored struct {
+		line  string
+		score int
+		pos   int
+	}
+	var candidates []scored
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, question) {
+			continue
+		}
+		score := 0
+		for _, w := range tokenize(line) {
+			if want[w] {
+				score++
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{line: line, score: score, pos: i})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > 3 {
+		candidates = candidates[:3]
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].pos < candidates[j].pos
+	})
+
+	parts := make([]string, len(candidates))
+	for i, c := range candidates {
+		parts[i] = c.line
+	}
+	return parts
+}
+
+// GenerateStream emits the Generate answer word by word. Usage counts words
+// rather than real tokens.
+func (f *Fake) GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (Usage, error) {
+	answer, err := f.Generate(ctx, prompt)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{PromptTokens: len(strings.Fields(prompt))}
+	for _, word := range strings.SplitAfter(answer, " ") {
+		if err := ctx.Err(); err != nil {
+			return usage, err
+		}
+		if err := onToken(word); err != nil {
+			return usage, err
+		}
+		usage.CompletionTokens++
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return usage, nil
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestFakeEmbedUnitVectors(t *testing.T) {
+	f := NewFake(768)
+	// w77 and w200 hash to the same bucket with opposite signs
+	vecs, err := f.Embed(context.Background(), []string{"w77 w200", "eye of agamotto"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	for i, vec := range vecs {
+		var norm float64
+		for _, v := range vec {
+			if math.IsNaN(float64(v)) {
+				t.Fatalf("vector %d has NaN", i)
+			}
+			norm += float64(v * v)
+		}
+		if math.Abs(norm-1) > 1e-5 {
+			t.Errorf("vector %d has squared norm %v, want 1", i, norm)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+type GeminiConfig struct {
+	APIKey     string
+	Model      string
+	EmbedModel string
+	Dimensions int
+}
+
+type Gemini struct {
+	client *genai.Client
+	cfg    GeminiConfig
+}
+
+func NewGemini(ctx context.Context, cfg GeminiConfig) (*Gemini, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  cfg.APIKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	return &Gemini{client: client, cfg: cfg}, nil
+}
+
+func (g *Gemini) Model() string {
+	return fmt.Sprintf("gemini/%s@%d", g.cfg.EmbedModel, g.cfg.Dimensions)
+}
+
+func (g *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	dims := int32(g.cfg.Dimensions)
+	resp, err := g.client.Models.EmbedContent(ctx, g.cfg.EmbedModel, contents, &genai.EmbedContentConfig{
+		OutputDimensionality: &dims,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	out := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		out[i] = e.Values
+	}
+	return out, nil
+}
+
+func (g *Gemini) Generate(ctx context.Context, prompt string) (string, error) {
+	return g.generate(ctx, prompt, nil)
+}
+
+func (g *Gemini) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	return g.generate(ctx, prompt, &genai.GenerateContentConfig{ResponseMIMEType: "application/json"})
+}
+
+func (g *Gemini) generate(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (string, error) {
+	resp, err := g.client.Models.GenerateContent(ctx, g.cfg.Model, []*genai.Content{
+		genai.NewContentFromText(prompt, genai.RoleUser),
+	}, config)
+	if err != nil {
+		return "", fmt.Errorf("LLM generation failed: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String(), nil
+}
+
+func (g *Gemini) GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (Usage, error) {
+	var usage Usage
+	stream := g.client.Models.GenerateContentStream(ctx, g.cfg.Model, []*genai.Content{
+		genai.NewContentFromText(prompt, genai.RoleUser),
+	}, nil)
+
+	for resp, err := range stream {
+		if err != nil {
+			return usage, fmt.Errorf("LLM generation failed: %w", err)
+		}
+
+		for _, cand := range resp.Candidates {
+			if cand.Content == nil {
+				continue
+			}
+			for _, part := range cand.Content.Parts {
+				if part.Text == "" {
+					continue
+				}
+				if err := onToken(part.Text); err != nil {
+					return usage, err
+				}
+			}
+		}
+
+		if m := resp.UsageMetadata; m != nil {
+			usage = Usage{
+				PromptTokens:     int(m.PromptTokenCount),
+				CompletionTokens: int(m.CandidatesTokenCount),
+				TotalTokens:      int(m.TotalTokenCount),
+			}
+		}
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,104 @@
+// Package llm abstracts the embedding and text generation providers used by
+// the retrieval features
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Embedder turns texts into vectors of a fixed dimension. Model identifies
+// the vector space, so vectors from different models are never mixed.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Model() string
+}
+
+// Generator produces a text completion for a prompt. GenerateJSON is
+// Generate with the provider's JSON mode on, so the completion is a single
+// JSON object; the prompt has to describe its shape. GenerateStream calls
+// onToken with each chunk of the completion as it arrives and stops early if
+// onToken returns an error or ctx is cancelled.
+type Generator interface {
+	Generate(ctx context.Context, prompt string) (string, error)
+	GenerateJSON(ctx context.Context, prompt string) (string, error)
+	GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (Usage, error)
+}
+
+// Usage is the token accounting reported by the provider for one generation
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Provider is a backend offering both embeddings and generation
+type Provider interface {
+	Embedder
+	Generator
+}
+
+// EmbedOne embeds a single text
+func EmbedOne(ctx context.Context, e Embedder, text string) ([]float32, error) {
+	vecs, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != 1 || len(vecs[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding result")
+	}
+	return vecs[0], nil
+}
+
+// FromEnv builds the provider selected by LLM_PROVIDER (gemini, openai or
+// fake). The provider is meant to be built once at startup and shared.
+//
+//	EMBED_DIMENSIONS    vector size, defaults to 768 to match the spells table
+//	GOOGLE_API_KEY      Gemini API key
+//	GEMINI_MODEL        defaults to gemini-2.5-flash
+//	GEMINI_EMBED_MODEL  defaults to gemini-embedding-001
+//	OPENAI_BASE_URL     OpenAI-compatible server, e.g. http://localhost:11434/v1
+//	OPENAI_API_KEY      optional bearer token for that server
+//	OPENAI_MODEL        chat model name
+//	OPENAI_EMBED_MODEL  embedding model name
+func FromEnv(ctx context.Context) (Provider, error) {
+	dims := 768
+	if v := os.Getenv("EMBED_DIMENSIONS"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid EMBED_DIMENSIONS %q", v)
+		}
+		dims = d
+	}
+
+	switch provider := getenv("LLM_PROVIDER", "gemini"); provider {
+	case "gemini":
+		return NewGemini(ctx, GeminiConfig{
+			APIKey:     os.Getenv("GOOGLE_API_KEY"),
+			Model:      getenv("GEMINI_MODEL", "gemini-2.5-flash"),
+			EmbedModel: getenv("GEMINI_EMBED_MODEL", "gemini-embedding-001"),
+			Dimensions: dims,
+		})
+	case "openai":
+		return NewOpenAI(OpenAIConfig{
+			BaseURL:    getenv("OPENAI_BASE_URL", "http://localhost:11434/v1"),
+			APIKey:     os.Getenv("OPENAI_API_KEY"),
+			Model:      getenv("OPENAI_MODEL", "llama3.1"),
+			EmbedModel: getenv("OPENAI_EMBED_MODEL", "nomic-embed-text"),
+			Dimensions: dims,
+		})
+	case "fake":
+		return NewFake(dims), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q", provider)
+	}
+}
+
+func getenv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIConfig points at any server speaking the OpenAI embeddings and chat
+// completions API, such as Ollama, vLLM or llama.cpp
+type OpenAIConfig struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	EmbedModel string
+	Dimensions int
+}
+
+// openAITimeout bounds an embedding or non-streaming completion request.
+// Streams are bounded by their caller's context alone, since a long answer
+// may take longer to read.
+const openAITimeout = 120 * time.Second
+
+type OpenAI struct {
+	cfg        OpenAIConfig
+	httpClient *http.Client
+}
+
+func NewOpenAI(cfg OpenAIConfig) (*OpenAI, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("missing OpenAI base URL")
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &OpenAI{
+		cfg:        cfg,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (o *OpenAI) Model() string {
+	return fmt.Sprintf("openai/%s@%d", o.cfg.EmbedModel, o.cfg.Dimensions)
+}
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	req := map[string]any{
+		"model": o.cfg.EmbedModel,
+		"input": texts,
+	}
+	if o.cfg.Dimensions > 0 {
+		req["dimensions"] = o.cfg.Dimensions
+	}
+
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := o.post(ctx, "/embeddings", req, &resp); err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	out := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(out) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
+
+func (o *OpenAI) Generate(ctx context.Context, prompt string) (string, error) {
+	return o.generate(ctx, prompt, false)
+}
+
+func (o *OpenAI) GenerateJSON(ctx context.Context, prompt string) (string, error) {
+	return o.generate(ctx, prompt, true)
+}
+
+func (o *OpenAI) generate(ctx context.Context, prompt string, jsonMode bool) (string, error) {
+	req := map[string]any{
+		"model": o.cfg.Model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+	if jsonMode {
+		req["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := o.post(ctx, "/chat/completions", req, &resp); err != nil {
+		return "", fmt.Errorf("LLM generation failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// GenerateStream reads the server-sent chat completion chunks
+func (o *OpenAI) GenerateStream(ctx context.Context, prompt string, onToken func(string) error) (Usage, error) {
+	var usage Usage
+	req := map[string]any{
+		"model": o.cfg.Model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	}
+
+	body, err := o.do(ctx, "/chat/completions", req)
+	if err != nil {
+		return usage, fmt.Errorf("LLM generation failed: %w", err)
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+				TotalTokens      int `json:"total_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return usage, fmt.Errorf("invalid stream chunk: %w", err)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := onToken(choice.Delta.Content); err != nil {
+				return usage, err
+			}
+		}
+		if chunk.Usage != nil {
+			usage = Usage(*chunk.Usage)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return usage, fmt.Errorf("LLM stream failed: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (o *OpenAI) post(ctx context.Context, path string, body any, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, openAITimeout)
+	defer cancel()
+
+	respBody, err := o.do(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+
+	return json.NewDecoder(respBody).Decode(out)
+}
+
+// do sends a JSON request and returns the body of a successful response
+func (o *OpenAI) do(ctx context.Context, path string, body any) (io.ReadCloser, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.cfg.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return resp.Body, nil
+}
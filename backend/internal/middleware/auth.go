@@ -1,45 +1,82 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/response"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/token"
 )
 
-func JwtAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// SessionChecker reports whether the session an access token was issued for
+// has been revoked
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+type Auth struct {
+	tokens   *token.Manager
+	sessions SessionChecker
+}
+
+func NewAuth(tokens *token.Manager, sessions SessionChecker) *Auth {
+	return &Auth{tokens: tokens, sessions: sessions}
+}
+
+func (a *Auth) JwtAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			response.RespondWithError(w, http.StatusUnauthorized, "Missing Authorization header")
+		tokenString, err := tokenFromRequest(r)
+		if err != nil {
+			response.RespondWithError(w, http.StatusUnauthorized, err.Error())
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			response.RespondWithError(w, http.StatusUnauthorized, "Invalid Authorization header format")
+		claims, err := a.tokens.Verify(tokenString)
+		if err != nil {
+			response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte("secret"), nil
-		})
+		principal := Principal{
+			UserID:    uuid.MustParse(claims.UserID),
+			Email:     claims.Email,
+			SessionID: uuid.MustParse(claims.SessionID),
+			Roles:     claims.Roles,
+			Clearance: claims.Clearance,
+		}
 
+		active, err := a.sessions.IsSessionActive(r.Context(), principal.SessionID)
 		if err != nil {
-			response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+			response.RespondWithError(w, http.StatusInternalServerError, "Failed to check session")
 			return
 		}
-
-		if !token.Valid {
-			response.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		if !active {
+			response.RespondWithError(w, http.StatusUnauthorized, "Session has been revoked")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// tokenFromRequest extracts the token from the Authorization header, falling
+// back to the auth_token cookie set by the login handlers
+func tokenFromRequest(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		if cookie, err := r.Cookie("auth_token"); err == nil && cookie.Value != "" {
+			return cookie.Value, nil
+		}
+		return "", fmt.Errorf("Missing Authorization header")
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return "", fmt.Errorf("Invalid Authorization header format")
 	}
+
+	return tokenString, nil
 }
@@ -0,0 +1,79 @@
+// Package password provides password hashing and verification
+package password
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrMismatch is returned when a password does not match the stored hash
+var ErrMismatch = errors.New("password does not match")
+
+// Hasher hashes passwords and verifies them against stored hashes
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) error
+	NeedsRehash(hash string) bool
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a bcrypt hasher, falling back to the default cost
+// when cost is outside the range bcrypt accepts
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (b *BcryptHasher) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+// NeedsRehash reports whether the hash was produced with a different cost
+// than the one this hasher is configured with
+func (b *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != b.cost
+}
+
+// IsLegacy reports whether a stored value predates hashing, i.e. it is the
+// raw password rather than a bcrypt hash
+func IsLegacy(stored string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(stored, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyLegacy compares a plaintext stored value in constant time
+func VerifyLegacy(stored, password string) error {
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
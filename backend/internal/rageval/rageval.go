@@ -0,0 +1,246 @@
+// Package rageval scores spell retrieval and answers against a golden set of
+// questions, so prompt and model changes can be compared
+package rageval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+	"gopkg.in/yaml.v3"
+)
+
+// Question is a golden question with the spells a good retrieval returns and
+// the facts a good answer states
+type Question struct {
+	ID              string   `json:"id"`
+	Question        string   `json:"question"`
+	ExpectedPageIDs []int32  `json:"expected_pageids"`
+	ExpectedFacts   []string `json:"expected_facts"`
+}
+
+type GoldenSet struct {
+	Questions []Question `json:"questions"`
+}
+
+// Config is one setup under evaluation. Env is applied while the provider
+// and service are built, so it can switch LLM_PROVIDER, models or
+// MYSTIC_GRAPH_CONTEXT; the other fields tune each query. Spells are scored
+// regardless of access level unless filters.max_access_level is set.
+type Config struct {
+	Name    string             `json:"name"`
+	Env     map[string]string  `json:"env"`
+	Limit   int                `json:"limit"`
+	Weights *retrieval.Weights `json:"weights"`
+	Filters retrieval.Filters  `json:"filters"`
+}
+
+// Options returns the retrieval options for k results
+func (c Config) Options(k int) retrieval.Options {
+	limit := c.Limit
+	if limit <= 0 {
+		limit = k
+	}
+	return retrieval.Options{Limit: limit, Weights: c.Weights, Filters: c.Filters}
+}
+
+// LoadGoldenSet reads a golden set from a .yaml, .yml or .json file
+func LoadGoldenSet(path string) (*GoldenSet, error) {
+	var set GoldenSet
+	if err := load(path, &set); err != nil {
+		return nil, err
+	}
+	if len(set.Questions) == 0 {
+		return nil, fmt.Errorf("%s has no questions", path)
+	}
+	for i, q := range set.Questions {
+		if strings.TrimSpace(q.Question) == "" {
+			return nil, fmt.Errorf("question %d in %s is empty", i+1, path)
+		}
+		if q.ID == "" {
+			set.Questions[i].ID = fmt.Sprintf("q%d", i+1)
+		}
+	}
+	return &set, nil
+}
+
+// LoadConfig reads a configuration from YAML or JSON, naming it after the
+// file if it has no name
+func LoadConfig(path string) (*Config, error) {
+	var cfg Config
+	if err := load(path, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Name == "" {
+		cfg.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if cfg.Weights != nil {
+		if err := cfg.Weights.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return &cfg, nil
+}
+
+func load(path string, out any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// YAML goes through JSON so both formats use the json field names, which
+	// the embedded retrieval types already have
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		var doc any
+		if err := yaml.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if raw, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Outcome is what a configuration returned for one question. Answer is empty
+// when only retrieval is evaluated.
+type Outcome struct {
+	Retrieved []int32
+	Answer    string
+}
+
+// Runner answers one golden question
+type Runner func(ctx context.Context, question string) (Outcome, error)
+
+type QuestionResult struct {
+	ID             string        `json:"id"`
+	Retrieved      []int32       `json:"retrieved"`
+	Recall         float64       `json:"recall"`
+	ReciprocalRank float64       `json:"reciprocal_rank"`
+	FactCoverage   float64       `json:"fact_coverage"`
+	MissingFacts   []string      `json:"missing_facts,omitempty"`
+	Answer         string        `json:"answer,omitempty"`
+	Latency        time.Duration `json:"latency"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Report holds the per-question results and their means. Questions that
+// failed score zero, so an error never improves a configuration.
+type Report struct {
+	Config       string           `json:"config"`
+	K            int              `json:"k"`
+	Questions    []QuestionResult `json:"questions"`
+	RecallAtK    float64          `json:"recall_at_k"`
+	MRR          float64          `json:"mrr"`
+	FactCoverage float64          `json:"fact_coverage"`
+	MeanLatency  time.Duration    `json:"mean_latency"`
+	Errors       int              `json:"errors"`
+	Generated    bool             `json:"generated"`
+}
+
+// Evaluate runs every question through run and scores the top k spells it
+// retrieved. Fact coverage is only scored if answers were generated.
+func Evaluate(ctx context.Context, name string, set *GoldenSet, k int, run Runner) (*Report, error) {
+	report := &Report{Config: name, K: k, Questions: make([]QuestionResult, 0, len(set.Questions))}
+
+	var latency time.Duration
+	for _, q := range set.Questions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		out, err := run(ctx, q.Question)
+		res := QuestionResult{ID: q.ID, Latency: time.Since(start), Retrieved: []int32{}}
+		latency += res.Latency
+
+		if err != nil {
+			res.Error = err.Error()
+			report.Errors++
+		} else {
+			res.Retrieved = out.Retrieved
+			res.Answer = out.Answer
+			res.Recall = recallAt(q.ExpectedPageIDs, out.Retrieved, k)
+			res.ReciprocalRank = reciprocalRank(q.ExpectedPageIDs, out.Retrieved)
+			if out.Answer != "" {
+				report.Generated = true
+				res.FactCoverage, res.MissingFacts = factCoverage(q.ExpectedFacts, out.Answer)
+			}
+		}
+
+		report.RecallAtK += res.Recall
+		report.MRR += res.ReciprocalRank
+		report.FactCoverage += res.FactCoverage
+		report.Questions = append(report.Questions, res)
+	}
+
+	n := float64(len(set.Questions))
+	report.RecallAtK /= n
+	report.MRR /= n
+	report.FactCoverage /= n
+	report.MeanLatency = latency / time.Duration(len(set.Questions))
+	return report, nil
+}
+
+// recallAt is the share of expected spells among the top k retrieved. A
+// question without expected spells counts as fully recalled.
+func recallAt(expected, retrieved []int32, k int) float64 {
+	if len(expected) == 0 {
+		return 1
+	}
+	top := retrieved[:min(k, len(retrieved))]
+	found := 0
+	for _, id := range expected {
+		for _, got := range top {
+			if got == id {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(len(expected))
+}
+
+// reciprocalRank is 1/rank of the first expected spell retrieved, or 0
+func reciprocalRank(expected, retrieved []int32) float64 {
+	if len(expected) == 0 {
+		return 1
+	}
+	want := make(map[int32]bool, len(expected))
+	for _, id := range expected {
+		want[id] = true
+	}
+	for i, id := range retrieved {
+		if want[id] {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// factCoverage is the share of expected facts the answer contains,
+// compared case-insensitively with whitespace collapsed
+func factCoverage(facts []string, answer string) (float64, []string) {
+	if len(facts) == 0 {
+		return 1, nil
+	}
+	answer = normalize(answer)
+	var missing []string
+	for _, f := range facts {
+		if !strings.Contains(answer, normalize(f)) {
+			missing = append(missing, f)
+		}
+	}
+	return float64(len(facts)-len(missing)) / float64(len(facts)), missing
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,114 @@
+package rageval
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Print writes the metrics of one or more reports side by side, followed by
+// a per-question breakdown if verbose. With two reports a delta column shows
+// how the second compares to the first.
+func Print(w io.Writer, verbose bool, reports ...*Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	compare := len(reports) == 2
+
+	header := []string{"metric"}
+	for _, r := range reports {
+		header = append(header, r.Config)
+	}
+	if compare {
+		header = append(header, "delta")
+	}
+	row(tw, header...)
+
+	metric := func(name string, value func(*Report) float64, scored func(*Report) bool) {
+		cells := []string{name}
+		for _, r := range reports {
+			cells = append(cells, score(value(r), scored(r)))
+		}
+		if compare {
+			a, b := reports[0], reports[1]
+			if scored(a) && scored(b) {
+				cells = append(cells, fmt.Sprintf("%+.3f", value(b)-value(a)))
+			} else {
+				cells = append(cells, "-")
+			}
+		}
+		row(tw, cells...)
+	}
+	always := func(*Report) bool { return true }
+
+	metric(fmt.Sprintf("recall@%d", reports[0].K), func(r *Report) float64 { return r.RecallAtK }, always)
+	metric("mrr", func(r *Report) float64 { return r.MRR }, always)
+	metric("fact coverage", func(r *Report) float64 { return r.FactCoverage }, func(r *Report) bool { return r.Generated })
+
+	cells := []string{"mean latency"}
+	for _, r := range reports {
+		cells = append(cells, r.MeanLatency.Round(time.Millisecond).String())
+	}
+	if compare {
+		cells = append(cells, (reports[1].MeanLatency - reports[0].MeanLatency).Round(time.Millisecond).String())
+	}
+	row(tw, cells...)
+
+	cells = []string{"errors"}
+	for _, r := range reports {
+		cells = append(cells, fmt.Sprint(r.Errors))
+	}
+	row(tw, cells...)
+
+	if verbose {
+		row(tw)
+		header = []string{"question"}
+		for _, r := range reports {
+			header = append(header, r.Config+" recall", r.Config+" rr", r.Config+" facts")
+		}
+		row(tw, header...)
+		for i, q := range reports[0].Questions {
+			cells := []string{q.ID}
+			for _, r := range reports {
+				res := r.Questions[i]
+				if res.Error != "" {
+					cells = append(cells, "error", "error", "error")
+					continue
+				}
+				cells = append(cells,
+					score(res.Recall, true),
+					score(res.ReciprocalRank, true),
+					score(res.FactCoverage, r.Generated))
+			}
+			row(tw, cells...)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if verbose {
+		for _, r := range reports {
+			for _, q := range r.Questions {
+				switch {
+				case q.Error != "":
+					fmt.Fprintf(w, "%s %s: %s\n", r.Config, q.ID, q.Error)
+				case len(q.MissingFacts) > 0:
+					fmt.Fprintf(w, "%s %s: missing %s\n", r.Config, q.ID, strings.Join(q.MissingFacts, "; "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func row(w io.Writer, cells ...string) {
+	fmt.Fprintln(w, strings.Join(cells, "\t")+"\t")
+}
+
+func score(v float64, scored bool) string {
+	if !scored {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", v)
+}
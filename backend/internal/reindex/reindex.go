@@ -0,0 +1,232 @@
+// Package reindex recomputes spell embeddings with the configured model and
+// switches retrieval over to them once every spell is covered
+package reindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+)
+
+const maxAttempts = 5
+
+type Job struct {
+	db       *sql.DB
+	queries  *db.Queries
+	embedder llm.Embedder
+
+	// BatchSize is how many spells are embedded per API call
+	BatchSize int
+	// Interval is the minimum time between embedding calls
+	Interval time.Duration
+	// Restart discards the model's checkpoint and re-embeds every spell
+	Restart bool
+	// Switch copies the new vectors into spells.embedding once the model
+	// covers every spell
+	Switch bool
+}
+
+func New(sqlDB *sql.DB, embedder llm.Embedder) *Job {
+	return &Job{
+		db:        sqlDB,
+		queries:   db.New(sqlDB),
+		embedder:  embedder,
+		BatchSize: 32,
+		Interval:  time.Second,
+		Switch:    true,
+	}
+}
+
+// Run embeds every spell that has no vector for the embedder's model yet,
+// checkpointing after each batch so an interrupted run resumes where it
+// stopped
+func (j *Job) Run(ctx context.Context) error {
+	model := j.embedder.Model()
+
+	if j.Restart {
+		if err := j.queries.DeleteSpellEmbeddings(ctx, model); err != nil {
+			return fmt.Errorf("failed to clear old vectors: %w", err)
+		}
+	}
+
+	var job db.ReindexJob
+	var err error
+	if j.Restart {
+		job, err = j.queries.ResetReindexJob(ctx, model)
+	} else {
+		job, err = j.queries.StartReindexJob(ctx, model)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	log.Printf("reindex %s: resuming after pageid %d (%d embedded so far)", model, job.LastPageid, job.Embedded)
+
+	limiter := time.NewTicker(j.Interval)
+	defer limiter.Stop()
+
+	cursor := job.LastPageid
+	wrapped := cursor == 0
+	for {
+		spells, err := j.queries.ListSpellsToEmbed(ctx, db.ListSpellsToEmbedParams{
+			AfterPageid: cursor,
+			Model:       model,
+			Lim:         int32(j.BatchSize),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list spells: %w", err)
+		}
+
+		if len(spells) == 0 {
+			// Spells added behind the cursor since the run started are
+			// picked up by one more pass from the beginning
+			if wrapped {
+				break
+			}
+			cursor, wrapped = 0, true
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-limiter.C:
+		}
+
+		if err := j.embedBatch(ctx, model, spells); err != nil {
+			return err
+		}
+		cursor = spells[len(spells)-1].Pageid
+	}
+
+	missing, err := j.queries.CountSpellsMissingEmbedding(ctx, model)
+	if err != nil {
+		return fmt.Errorf("failed to check coverage: %w", err)
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d spells still have no %s vector", missing, model)
+	}
+
+	if err := j.queries.CompleteReindexJob(ctx, model); err != nil {
+		return fmt.Errorf("failed to complete checkpoint: %w", err)
+	}
+	log.Printf("reindex %s: every spell is embedded", model)
+
+	if !j.Switch {
+		return nil
+	}
+	return j.switchModel(ctx, model)
+}
+
+// embedBatch embeds one batch with retries and stores the vectors together
+// with the advanced checkpoint
+func (j *Job) embedBatch(ctx context.Context, model string, spells []db.ListSpellsToEmbedRow) error {
+	texts := make([]string, len(spells))
+	for i, s := range spells {
+		texts[i] = SpellText(s.Title, s.Summary.String, s.Aliases, s.Categories)
+	}
+
+	var vecs [][]float32
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		vecs, err = j.embedder.Embed(ctx, texts)
+		if err == nil || attempt == maxAttempts {
+			break
+		}
+		backoff := time.Duration(1<<attempt) * time.Second
+		log.Printf("reindex %s: embedding failed (attempt %d/%d), retrying in %s: %v", model, attempt, maxAttempts, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("embedding failed: %w", err)
+	}
+
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := j.queries.WithTx(tx)
+	for i, s := range spells {
+		if err := qtx.UpsertSpellEmbedding(ctx, db.UpsertSpellEmbeddingParams{
+			Pageid:    s.Pageid,
+			Model:     model,
+			Embedding: retrieval.VectorLiteral(vecs[i]),
+		}); err != nil {
+			return fmt.Errorf("failed to store vector for %d: %w", s.Pageid, err)
+		}
+	}
+
+	last := spells[len(spells)-1].Pageid
+	if err := qtx.AdvanceReindexJob(ctx, db.AdvanceReindexJobParams{
+		Model:      model,
+		LastPageid: last,
+		Embedded:   int32(len(spells)),
+	}); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("reindex %s: embedded %d spells up to pageid %d", model, len(spells), last)
+	return nil
+}
+
+// switchModel copies the model's vectors into spells.embedding and records
+// the model in one transaction, so searches never see a mix of models
+func (j *Job) switchModel(ctx context.Context, model string) error {
+	tx, err := j.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := j.queries.WithTx(tx)
+	if err := qtx.CopySpellEmbeddings(ctx, model); err != nil {
+		return fmt.Errorf("failed to copy vectors: %w", err)
+	}
+	if err := qtx.SetSpellEmbeddingModel(ctx, sql.NullString{String: model, Valid: true}); err != nil {
+		return fmt.Errorf("failed to record model: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("reindex %s: retrieval switched over", model)
+	return nil
+}
+
+// SpellText is the text a spell's embedding is computed from
+func SpellText(title, summary string, aliases, categories []string) string {
+	var b strings.Builder
+	b.WriteString(title)
+	if len(aliases) > 0 {
+		b.WriteString("\nAliases: ")
+		b.WriteString(strings.Join(aliases, ", "))
+	}
+	if len(categories) > 0 {
+		b.WriteString("\nCategories: ")
+		b.WriteString(strings.Join(categories, ", "))
+	}
+	if summary != "" {
+		b.WriteString("\n")
+		b.WriteString(summary)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"errors"
+	"sort"
+)
+
+// rrfK dampens the influence of the very top ranks in reciprocal-rank fusion;
+// 60 is the value from the original RRF paper
+const rrfK = 60
+
+// Weights scale each ranked list's contribution to the fused score. A zero
+// weight skips that list entirely.
+type Weights struct {
+	Vector float64 `json:"vector"`
+	Text   float64 `json:"text"`
+	Name   float64 `json:"name"`
+}
+
+// DefaultWeights give semantic, full-text and name matches equal say
+var DefaultWeights = Weights{Vector: 1, Text: 1, Name: 1}
+
+// Validate rejects negative weights and weights that switch every list off
+func (w Weights) Validate() error {
+	if w.Vector < 0 || w.Text < 0 || w.Name < 0 {
+		return errors.New("weights must not be negative")
+	}
+	if w.Vector+w.Text+w.Name == 0 {
+		return errors.New("at least one weight must be positive")
+	}
+	return nil
+}
+
+// fuseRanks merges ranked lists with weighted reciprocal-rank fusion: each
+// spell scores the sum of weight / (rrfK + rank) over the lists it appears in
+func fuseRanks(lists [][]Hit, weights []float64, limit int) []Hit {
+	byID := map[int32]*Hit{}
+	var order []int32
+
+	for i, list := range lists {
+		for rank, hit := range list {
+			fused, ok := byID[hit.PageID]
+			if !ok {
+				fused = &hit
+				byID[hit.PageID] = fused
+				order = append(order, hit.PageID)
+			} else if fused.Distance == nil {
+				fused.Distance = hit.Distance
+			}
+			fused.Score += weights[i] / float64(rrfK+rank+1)
+		}
+	}
+
+	hits := make([]Hit, len(order))
+	for i, id := range order {
+		hits[i] = *byID[id]
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
@@ -0,0 +1,415 @@
+// Package retrieval ranks spells for a query by fusing pgvector similarity
+// with full-text and name matching. It is shared by the API server and the
+// standalone searchspell binary.
+package retrieval
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+)
+
+// Filters narrow a search. Zero values leave a filter off; Realities and
+// Categories match spells sharing any of the listed values.
+type Filters struct {
+	Realities           []string `json:"realities,omitempty"`
+	Categories          []string `json:"categories,omitempty"`
+	PowerClass          string   `json:"power_class,omitempty"`
+	Origin              string   `json:"origin,omitempty"`
+	UsedByDoctorStrange *bool    `json:"used_by_doctor_strange,omitempty"`
+	MaxAccessLevel      *int16   `json:"max_access_level,omitempty"`
+	// MinAccessLevel is only set by SearchCleared to look for restricted
+	// spells, never by clients
+	MinAccessLevel *int16 `json:"-"`
+	// MaxDistance drops semantic matches further than this from the query.
+	// Lexical matches are kept, since an exact name is relevant however far
+	// its embedding is.
+	MaxDistance *float64 `json:"max_distance,omitempty"`
+}
+
+// MaxLimit caps Options.Limit; larger limits are cut down to it
+const MaxLimit = 20
+
+// Options tune a search. A nil Weights uses DefaultWeights.
+type Options struct {
+	Limit   int
+	Weights *Weights
+	Filters Filters
+}
+
+func (o Options) weights() Weights {
+	if o.Weights == nil {
+		return DefaultWeights
+	}
+	return *o.Weights
+}
+
+// limit is the number of hits returned, defaulting to 5 and at most MaxLimit
+func (o Options) limit() int {
+	if o.Limit <= 0 {
+		return 5
+	}
+	return min(o.Limit, MaxLimit)
+}
+
+// candidateDepth is how many hits each list contributes to the fusion
+func (o Options) candidateDepth() int32 {
+	return int32(max(o.limit()*4, 20))
+}
+
+// Hit is a ranked spell. Distance is only set for spells the vector search
+// found. Restricted spells are returned like any other; callers decide what
+// a user is cleared to see.
+type Hit struct {
+	PageID              int32    `json:"pageid"`
+	Title               string   `json:"title"`
+	Summary             string   `json:"summary"`
+	URL                 string   `json:"url"`
+	ImageURL            *string  `json:"image_url,omitempty"`
+	Categories          []string `json:"categories"`
+	Realities           []string `json:"realities"`
+	Origin              string   `json:"origin,omitempty"`
+	PowerClass          string   `json:"power_class,omitempty"`
+	UsedByDoctorStrange bool     `json:"used_by_doctor_strange"`
+	AccessLevel         int16    `json:"access_level"`
+	RestrictedReason    string   `json:"-"`
+	Distance            *float64 `json:"distance,omitempty"`
+	Score               float64  `json:"score"`
+}
+
+type Engine struct {
+	db            *db.Queries
+	embedder      llm.Embedder
+	embedTimeout  time.Duration
+	queryTimeout  time.Duration
+	alertDistance float64
+}
+
+// New builds an engine over the shared connection pool. Timeouts for the
+// embedding call and for each database query are read from
+// RETRIEVAL_EMBED_TIMEOUT and RETRIEVAL_QUERY_TIMEOUT, and how close a
+// restricted spell's vector must be to count as matched from
+// RETRIEVAL_ALERT_DISTANCE.
+func New(db *db.Queries, embedder llm.Embedder) *Engine {
+	return &Engine{
+		db:            db,
+		embedder:      embedder,
+		embedTimeout:  durationEnv("RETRIEVAL_EMBED_TIMEOUT", 20*time.Second),
+		queryTimeout:  durationEnv("RETRIEVAL_QUERY_TIMEOUT", 15*time.Second),
+		alertDistance: floatEnv("RETRIEVAL_ALERT_DISTANCE", 0.8),
+	}
+}
+
+// CheckModel reports an error if the spell vectors were built by a different
+// embedding model than the one queries are embedded with, which would make
+// vector distances meaningless. Vectors from the original scraper carry no
+// model and are not checked.
+func (e *Engine) CheckModel(ctx context.Context) error {
+	model, err := e.db.GetSpellEmbeddingModel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read spell embedding model: %w", err)
+	}
+	if model.Valid && model.String != e.embedder.Model() {
+		return fmt.Errorf("spell vectors were built with %s but queries are embedded with %s; run cmd/reindex",
+			model.String, e.embedder.Model())
+	}
+	return nil
+}
+
+// Search ranks spells by vector similarity, full text and name/alias
+// trigrams, applies the filters to every list and fuses them
+func (e *Engine) Search(ctx context.Context, query string, opts Options) ([]Hit, error) {
+	query, opts, err := prepare(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := e.embed(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.rank(ctx, query, vec, opts)
+}
+
+// SearchCleared is Search bounded to spells at or below clearance, so
+// restricted spells take none of the top hits. Restricted lists the spells
+// above clearance that match the query by text or name, or whose vectors lie
+// within the alert distance of it, for callers that alert on such matches.
+// Nearest neighbours alone do not count, since every query has some.
+func (e *Engine) SearchCleared(ctx context.Context, query string, opts Options, clearance int16) (hits, restricted []Hit, err error) {
+	query, opts, err = prepare(query, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	vec, err := e.embed(ctx, query, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A bound the caller already set at or below clearance leaves nothing
+	// restricted to find
+	bound := opts.Filters.MaxAccessLevel
+	if bound != nil && *bound <= clearance {
+		hits, err = e.rank(ctx, query, vec, opts)
+		return hits, nil, err
+	}
+
+	cleared := opts
+	cleared.Filters.MaxAccessLevel = &clearance
+	hits, err = e.rank(ctx, query, vec, cleared)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	qctx, cancel := context.WithTimeout(ctx, e.queryTimeout)
+	defer cancel()
+	highest, err := e.db.GetMaxSpellAccessLevel(qctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("access level query failed: %w", err)
+	}
+	if highest <= clearance {
+		return hits, nil, nil
+	}
+
+	above := clearance + 1
+	alert := opts
+	alert.Filters.MinAccessLevel = &above
+	alert.Filters.MaxDistance = &e.alertDistance
+	if d := opts.Filters.MaxDistance; d != nil && *d < e.alertDistance {
+		alert.Filters.MaxDistance = d
+	}
+	restricted, err = e.rank(ctx, query, vec, alert)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hits, restricted, nil
+}
+
+func prepare(query string, opts Options) (string, Options, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", opts, fmt.Errorf("empty query")
+	}
+	opts.Limit = opts.limit()
+	weights := opts.weights()
+	if err := weights.Validate(); err != nil {
+		return "", opts, err
+	}
+	return query, opts, nil
+}
+
+// embed returns the query vector, or nil when the vector list is weighted
+// out
+func (e *Engine) embed(ctx context.Context, query string, opts Options) ([]float32, error) {
+	if opts.weights().Vector <= 0 {
+		return nil, nil
+	}
+	ectx, cancel := context.WithTimeout(ctx, e.embedTimeout)
+	defer cancel()
+	return llm.EmbedOne(ectx, e.embedder, query)
+}
+
+func (e *Engine) rank(ctx context.Context, query string, vec []float32, opts Options) ([]Hit, error) {
+	weights := opts.weights()
+	f := opts.Filters
+	depth := opts.candidateDepth()
+	var lists [][]Hit
+	var listWeights []float64
+
+	if weights.Vector > 0 {
+		hits, err := e.searchVector(ctx, vec, f, depth)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, hits)
+		listWeights = append(listWeights, weights.Vector)
+	}
+
+	if weights.Text > 0 {
+		qctx, cancel := context.WithTimeout(ctx, e.queryTimeout)
+		defer cancel()
+
+		rows, err := e.db.SearchSpellsFullText(qctx, db.SearchSpellsFullTextParams{
+			Query:               query,
+			Realities:           nonNil(f.Realities),
+			Categories:          nonNil(f.Categories),
+			PowerClass:          nullString(f.PowerClass),
+			Origin:              nullString(f.Origin),
+			UsedByDoctorStrange: nullBool(f.UsedByDoctorStrange),
+			MaxAccessLevel:      nullInt16(f.MaxAccessLevel),
+			MinAccessLevel:      nullInt16(f.MinAccessLevel),
+			Lim:                 depth,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("full-text query failed: %w", err)
+		}
+		hits := make([]Hit, len(rows))
+		for i, r := range rows {
+			hits[i] = lexicalHit(db.SearchSpellsByNameRow(r))
+		}
+		lists = append(lists, hits)
+		listWeights = append(listWeights, weights.Text)
+	}
+
+	if weights.Name > 0 {
+		qctx, cancel := context.WithTimeout(ctx, e.queryTimeout)
+		defer cancel()
+
+		rows, err := e.db.SearchSpellsByName(qctx, db.SearchSpellsByNameParams{
+			Query:               query,
+			Realities:           nonNil(f.Realities),
+			Categories:          nonNil(f.Categories),
+			PowerClass:          nullString(f.PowerClass),
+			Origin:              nullString(f.Origin),
+			UsedByDoctorStrange: nullBool(f.UsedByDoctorStrange),
+			MaxAccessLevel:      nullInt16(f.MaxAccessLevel),
+			MinAccessLevel:      nullInt16(f.MinAccessLevel),
+			Lim:                 depth,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("name query failed: %w", err)
+		}
+		hits := make([]Hit, len(rows))
+		for i, r := range rows {
+			hits[i] = lexicalHit(r)
+		}
+		lists = append(lists, hits)
+		listWeights = append(listWeights, weights.Name)
+	}
+
+	return fuseRanks(lists, listWeights, opts.limit()), nil
+}
+
+func (e *Engine) searchVector(ctx context.Context, vec []float32, f Filters, depth int32) ([]Hit, error) {
+	qctx, cancel := context.WithTimeout(ctx, e.queryTimeout)
+	defer cancel()
+
+	var maxDistance sql.NullFloat64
+	if f.MaxDistance != nil {
+		maxDistance = sql.NullFloat64{Float64: *f.MaxDistance, Valid: true}
+	}
+
+	rows, err := e.db.SearchSpells(qctx, db.SearchSpellsParams{
+		Embedding:           VectorLiteral(vec),
+		MaxDistance:         maxDistance,
+		Realities:           nonNil(f.Realities),
+		Categories:          nonNil(f.Categories),
+		PowerClass:          nullString(f.PowerClass),
+		Origin:              nullString(f.Origin),
+		UsedByDoctorStrange: nullBool(f.UsedByDoctorStrange),
+		MaxAccessLevel:      nullInt16(f.MaxAccessLevel),
+		MinAccessLevel:      nullInt16(f.MinAccessLevel),
+		Lim:                 depth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vector query failed: %w", err)
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, r := range rows {
+		distance := r.Distance
+		hits[i] = Hit{
+			PageID:              r.Pageid,
+			Title:               r.Title,
+			Summary:             r.Summary.String,
+			URL:                 r.Url,
+			ImageURL:            stringPtr(r.ImageUrl),
+			Categories:          r.Categories,
+			Realities:           r.Realities,
+			Origin:              r.Origin.String,
+			PowerClass:          r.PowerClass.String,
+			UsedByDoctorStrange: r.UsedByDoctorStrange,
+			AccessLevel:         r.AccessLevel.Int16,
+			RestrictedReason:    r.RestrictedReason.String,
+			Distance:            &distance,
+		}
+	}
+	return hits, nil
+}
+
+func lexicalHit(r db.SearchSpellsByNameRow) Hit {
+	return Hit{
+		PageID:              r.Pageid,
+		Title:               r.Title,
+		Summary:             r.Summary.String,
+		URL:                 r.Url,
+		ImageURL:            stringPtr(r.ImageUrl),
+		Categories:          r.Categories,
+		Realities:           r.Realities,
+		Origin:              r.Origin.String,
+		PowerClass:          r.PowerClass.String,
+		UsedByDoctorStrange: r.UsedByDoctorStrange,
+		AccessLevel:         r.AccessLevel.Int16,
+		RestrictedReason:    r.RestrictedReason.String,
+	}
+}
+
+// VectorLiteral formats a vector as a pgvector text literal
+func VectorLiteral(vec []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vec {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func nullInt16(n *int16) sql.NullInt16 {
+	if n == nil {
+		return sql.NullInt16{}
+	}
+	return sql.NullInt16{Int16: *n, Valid: true}
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+func floatEnv(name string, def float64) float64 {
+	if v := os.Getenv(name); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return def
+}
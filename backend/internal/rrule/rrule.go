@@ -0,0 +1,486 @@
+// Package rrule parses and expands the subset of RFC 5545 recurrence rules
+// the calendar supports: FREQ of DAILY, WEEKLY, MONTHLY or YEARLY with
+// INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// MaxCount bounds COUNT so a series can always be expanded in full
+const MaxCount = 5000
+
+// maxPeriods stops expansion of rules that can never match, such as
+// BYMONTH=2;BYMONTHDAY=30
+const maxPeriods = 100000
+
+// WeekdayNum is a BYDAY entry. N is the ordinal within the month or year,
+// negative counting from the end, or 0 for every such weekday.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed RRULE. Until is zero and Count 0 when unset.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10",
+// with or without the "RRULE:" prefix. A date-only UNTIL is read as the end
+// of that day in UTC.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		name = strings.ToUpper(name)
+		if seen[name] {
+			return nil, fmt.Errorf("%s given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(strings.ToUpper(value))
+			switch r.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = errors.New("INTERVAL must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && (r.Count < 1 || r.Count > MaxCount) {
+				err = fmt.Errorf("COUNT must be between 1 and %d", MaxCount)
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(value, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(value, 1, 12)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", value)
+			}
+			r.WeekStart = wd
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot both be set")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, errors.New("numbered BYDAY needs FREQ=MONTHLY or YEARLY")
+		}
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return nil, errors.New("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	if r.Freq == Yearly && len(r.ByDay) > 0 && len(r.ByMonth) == 0 {
+		return nil, errors.New("BYDAY with FREQ=YEARLY needs BYMONTH")
+	}
+	return r, nil
+}
+
+func parseUntil(v string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse("20060102", v); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", v)
+}
+
+func parseByDay(v string) ([]WeekdayNum, error) {
+	var out []WeekdayNum
+	for _, item := range strings.Split(v, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		wd, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+		}
+		out = append(out, WeekdayNum{N: n, Weekday: wd})
+	}
+	return out, nil
+}
+
+func parseInts(v string, lo, hi int) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || n == 0 || n < lo || n > hi {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// String formats the rule back into an RRULE value without the prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayCodes[d.Weekday]
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCodes[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// Bounded reports whether the rule has a last occurrence
+func (r *Rule) Bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Iterator yields the occurrence starts of a rule in order
+type Iterator struct {
+	rule    *Rule
+	start   time.Time
+	period  int
+	pending []time.Time
+	emitted int
+	done    bool
+}
+
+// Iter expands the rule from dtstart. Occurrences keep dtstart's wall clock
+// time in its location, so a 09:00 meeting stays at 09:00 across DST
+// changes.
+func (r *Rule) Iter(dtstart time.Time) *Iterator {
+	return &Iterator{rule: r, start: dtstart}
+}
+
+// Next returns the next occurrence, or false once the rule is exhausted
+func (it *Iterator) Next() (time.Time, bool) {
+	r := it.rule
+	for !it.done {
+		if len(it.pending) > 0 {
+			t := it.pending[0]
+			it.pending = it.pending[1:]
+			if !r.Until.IsZero() && t.After(r.Until) {
+				it.done = true
+				break
+			}
+			it.emitted++
+			if r.Count > 0 && it.emitted >= r.Count {
+				it.done = true
+			}
+			return t, true
+		}
+		if it.period >= maxPeriods {
+			it.done = true
+			break
+		}
+		for _, t := range it.candidates(it.period) {
+			if !t.Before(it.start) {
+				it.pending = append(it.pending, t)
+			}
+		}
+		it.period++
+	}
+	return time.Time{}, false
+}
+
+// candidates lists the occurrences in the nth period after dtstart's, in
+// order, before COUNT and UNTIL are applied
+func (it *Iterator) candidates(n int) []time.Time {
+	r, s := it.rule, it.start
+	y, m, d := s.Date()
+	hh, mm, ss := s.Clock()
+	loc := s.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, s.Nanosecond(), loc)
+	}
+	step := n * r.Interval
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{at(y, m, d+step)}
+
+	case Weekly:
+		// The week containing dtstart begins on WKST
+		offset := (int(s.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := d - offset + 7*step
+		if len(r.ByDay) == 0 {
+			days = []time.Time{at(y, m, d+7*step)}
+		} else {
+			for i := 0; i < 7; i++ {
+				t := at(y, m, weekStart+i)
+				if r.hasWeekday(t.Weekday()) {
+					days = append(days, t)
+				}
+			}
+		}
+
+	case Monthly:
+		first := at(y, m+time.Month(step), 1)
+		days = r.monthDays(first, d, at)
+
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			days = append(days, r.monthDays(at(y+step, month, 1), d, at)...)
+		}
+	}
+
+	var out []time.Time
+	for _, t := range days {
+		if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, t.Month()) {
+			continue
+		}
+		if r.Freq == Daily && len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			continue
+		}
+		if r.Freq == Daily && len(r.ByMonthDay) > 0 && !matchesMonthDay(t, r.ByMonthDay) {
+			continue
+		}
+		out = append(out, t)
+	}
+	slices.SortFunc(out, func(a, b time.Time) int { return a.Compare(b) })
+	return slices.CompactFunc(out, func(a, b time.Time) bool { return a.Equal(b) })
+}
+
+// monthDays lists the matching days of the month starting at first. With
+// neither BYDAY nor BYMONTHDAY that is dtstart's day of the month, skipped in
+// months too short for it.
+func (r *Rule) monthDays(first time.Time, startDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	y, m, _ := first.Date()
+	last := daysIn(y, m)
+
+	var byDay []int
+	for _, wd := range r.ByDay {
+		byDay = append(byDay, weekdayDays(y, m, last, wd)...)
+	}
+	var byMonthDay []int
+	for _, md := range r.ByMonthDay {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if md >= 1 && md <= last {
+			byMonthDay = append(byMonthDay, md)
+		}
+	}
+
+	var days []int
+	switch {
+	case len(r.ByDay) > 0 && len(r.ByMonthDay) > 0:
+		for _, d := range byDay {
+			if slices.Contains(byMonthDay, d) {
+				days = append(days, d)
+			}
+		}
+	case len(r.ByDay) > 0:
+		days = byDay
+	case len(r.ByMonthDay) > 0:
+		days = byMonthDay
+	case startDay <= last:
+		days = []int{startDay}
+	}
+
+	out := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		out = append(out, at(y, m, d))
+	}
+	return out
+}
+
+// weekdayDays lists the days of a month falling on wd.Weekday, or only the
+// Nth of them when wd.N is set
+func weekdayDays(y int, m time.Month, last int, wd WeekdayNum) []int {
+	firstWeekday := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	var all []int
+	for d := 1 + (int(wd.Weekday)-int(firstWeekday)+7)%7; d <= last; d += 7 {
+		all = append(all, d)
+	}
+	switch {
+	case wd.N == 0:
+		return all
+	case wd.N > 0 && wd.N <= len(all):
+		return []int{all[wd.N-1]}
+	case wd.N < 0 && -wd.N <= len(all):
+		return []int{all[len(all)+wd.N]}
+	}
+	return nil
+}
+
+func (r *Rule) hasWeekday(wd time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesMonthDay(t time.Time, days []int) bool {
+	last := daysIn(t.Year(), t.Month())
+	for _, md := range days {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if t.Day() == md {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Between returns the occurrences from dtstart that start in [from, to)
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var out []time.Time
+	it := r.Iter(dtstart)
+	for t, ok := it.Next(); ok && t.Before(to); t, ok = it.Next() {
+		if !t.Before(from) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Last returns the final occurrence of a bounded rule
+func (r *Rule) Last(dtstart time.Time) (time.Time, bool) {
+	if !r.Bounded() {
+		return time.Time{}, false
+	}
+	var last time.Time
+	found := false
+	it := r.Iter(dtstart)
+	for t, ok := it.Next(); ok; t, ok = it.Next() {
+		last, found = t, true
+	}
+	return last, found
+}
+
+// Includes reports whether t is an occurrence of the rule from dtstart
+func (r *Rule) Includes(dtstart, t time.Time) bool {
+	return len(r.Between(dtstart, t, t.Add(time.Second))) > 0
+}
+
+// CountBefore counts the occurrences starting before t
+func (r *Rule) CountBefore(dtstart, t time.Time) int {
+	n := 0
+	it := r.Iter(dtstart)
+	for o, ok := it.Next(); ok && o.Before(t); o, ok = it.Next() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,235 @@
+package rrule
+
+import (
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func mustParse(t *testing.T, v string) *Rule {
+	t.Helper()
+	r, err := Parse(v)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", v, err)
+	}
+	return r
+}
+
+func format(ts []time.Time) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.Format(time.RFC3339)
+	}
+	return out
+}
+
+// first takes up to n occurrences from the iterator
+func first(r *Rule, dtstart time.Time, n int) []time.Time {
+	var out []time.Time
+	it := r.Iter(dtstart)
+	for t, ok := it.Next(); ok && len(out) < n; t, ok = it.Next() {
+		out = append(out, t)
+	}
+	return out
+}
+
+func TestIter(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		want    []string
+	}{
+		{
+			name:    "daily keeps wall clock across spring forward",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: time.Date(2026, 3, 7, 9, 0, 0, 0, ny),
+			want:    []string{"2026-03-07T09:00:00-05:00", "2026-03-08T09:00:00-04:00", "2026-03-09T09:00:00-04:00"},
+		},
+		{
+			name:    "weekly keeps wall clock across fall back",
+			rule:    "FREQ=WEEKLY;BYDAY=SU;COUNT=2",
+			dtstart: time.Date(2026, 10, 25, 9, 0, 0, 0, ny),
+			want:    []string{"2026-10-25T09:00:00-04:00", "2026-11-01T09:00:00-05:00"},
+		},
+		{
+			name:    "last friday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-30T18:00:00Z", "2026-02-27T18:00:00Z", "2026-03-27T18:00:00Z"},
+		},
+		{
+			name:    "second to last monday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=-2MO;COUNT=2",
+			dtstart: time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-19T18:00:00Z", "2026-02-16T18:00:00Z"},
+		},
+		{
+			name:    "BYMONTHDAY=31 skips short months",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=4",
+			dtstart: time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-31T08:00:00Z", "2026-03-31T08:00:00Z", "2026-05-31T08:00:00Z", "2026-07-31T08:00:00Z"},
+		},
+		{
+			name:    "monthly on dtstart's day skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-31T08:00:00Z", "2026-03-31T08:00:00Z", "2026-05-31T08:00:00Z"},
+		},
+		{
+			name:    "last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-31T08:00:00Z", "2026-02-28T08:00:00Z", "2026-03-31T08:00:00Z"},
+		},
+		{
+			name:    "yearly on february 29th",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			want:    []string{"2024-02-29T12:00:00Z", "2028-02-29T12:00:00Z"},
+		},
+		{
+			name:    "every other week on two days",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4",
+			dtstart: time.Date(2026, 1, 6, 9, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-06T09:00:00Z", "2026-01-08T09:00:00Z", "2026-01-20T09:00:00Z", "2026-01-22T09:00:00Z"},
+		},
+		{
+			name:    "date-only UNTIL includes that day",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20260114",
+			dtstart: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-05T09:00:00Z", "2026-01-07T09:00:00Z", "2026-01-12T09:00:00Z", "2026-01-14T09:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := format(first(mustParse(t, tt.rule), tt.dtstart, 10))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	daily := mustParse(t, "FREQ=DAILY")
+
+	tests := []struct {
+		name     string
+		rule     *Rule
+		dtstart  time.Time
+		from, to time.Time
+		want     []string
+	}{
+		{
+			name:    "window is half open",
+			rule:    daily,
+			dtstart: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 3, 10, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-03T10:00:00Z", "2026-01-04T10:00:00Z"},
+		},
+		{
+			name:    "occurrence starting before the window is left out",
+			rule:    daily,
+			dtstart: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 3, 10, 0, 1, 0, time.UTC),
+			to:      time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-04T10:00:00Z"},
+		},
+		{
+			name:    "day of the spring forward",
+			rule:    daily,
+			dtstart: time.Date(2026, 3, 1, 9, 0, 0, 0, ny),
+			from:    time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+			to:      time.Date(2026, 3, 9, 0, 0, 0, 0, ny),
+			want:    []string{"2026-03-08T09:00:00-04:00"},
+		},
+		{
+			name:    "window before dtstart",
+			rule:    daily,
+			dtstart: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			from:    time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			want:    []string{},
+		},
+		{
+			name:    "COUNT ends the series inside the window",
+			rule:    mustParse(t, "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2"),
+			dtstart: time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:    []string{"2026-01-30T18:00:00Z", "2026-02-27T18:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := format(tt.rule.Between(tt.dtstart, tt.from, tt.to))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountBefore(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	monthEnd := mustParse(t, "FREQ=MONTHLY;BYMONTHDAY=31")
+	monthEndStart := time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    *Rule
+		dtstart time.Time
+		t       time.Time
+		want    int
+	}{
+		{"at dtstart", monthEnd, monthEndStart, monthEndStart, 0},
+		{"short months are not counted", monthEnd, monthEndStart, time.Date(2026, 5, 31, 8, 0, 0, 0, time.UTC), 2},
+		{"just after an occurrence", monthEnd, monthEndStart, time.Date(2026, 5, 31, 8, 0, 1, 0, time.UTC), 3},
+		{
+			"negative BYDAY",
+			mustParse(t, "FREQ=MONTHLY;BYDAY=-1FR"),
+			time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC),
+			time.Date(2026, 3, 27, 18, 0, 0, 0, time.UTC),
+			2,
+		},
+		{
+			"across DST",
+			mustParse(t, "FREQ=DAILY"),
+			time.Date(2026, 3, 7, 9, 0, 0, 0, ny),
+			time.Date(2026, 3, 9, 9, 0, 0, 0, ny),
+			2,
+		},
+		{
+			"COUNT caps the total",
+			mustParse(t, "FREQ=WEEKLY;COUNT=3"),
+			time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC),
+			time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.CountBefore(tt.dtstart, tt.t); got != tt.want {
+				t.Errorf("CountBefore = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
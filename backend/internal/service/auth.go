@@ -12,21 +12,34 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/password"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
 type AuthService struct {
 	db                *db.Queries
+	hasher            password.Hasher
+	refreshTTL        time.Duration
 	GoogleOAuthConfig *oauth2.Config
 }
 
 func New(db *db.Queries) *AuthService {
+	cost, _ := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+
+	refreshTTL, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL"))
+	if err != nil || refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+
 	return &AuthService{
-		db: db,
+		db:         db,
+		hasher:     password.NewBcryptHasher(cost),
+		refreshTTL: refreshTTL,
 		GoogleOAuthConfig: &oauth2.Config{
 			RedirectURL:  "http://localhost:8080/api/auth/google/callback",
 			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
@@ -149,7 +162,7 @@ func (a *AuthService) HandleGoogleCallback(ctx context.Context, code string) (*d
 func (a *AuthService) Register(
 	ctx context.Context,
 	email string,
-	password string,
+	pass string,
 	name string,
 	file multipart.File,
 	handler *multipart.FileHeader,
@@ -175,15 +188,19 @@ func (a *AuthService) Register(
 		if _, err := io.Copy(dst, file); err != nil {
 			return nil, fmt.Errorf("failed to save profile picture: %w", err)
 		}
-	} 
+	}
 
 	avatarUrl := filepath[len("uploads/"):]
 
-	// TODO: hash password before storing
+	passwordHash, err := a.hasher.Hash(pass)
+	if err != nil {
+		return nil, err
+	}
+
 	student, err := a.db.CreateUser(ctx, db.CreateUserParams{
 		Email: email,
 		PasswordHash: sql.NullString{
-			String: password,
+			String: passwordHash,
 			Valid:  true,
 		},
 		Name: sql.NullString{
@@ -202,16 +219,53 @@ func (a *AuthService) Register(
 	return &student, nil
 }
 
-func (a *AuthService) Login(ctx context.Context, email, password string) (*db.User, error) {
+func (a *AuthService) Login(ctx context.Context, email, pass string) (*db.User, error) {
 	user, err := a.db.GetUserByEmail(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Handle password hash
-	if password != user.PasswordHash.String {
+	// OAuth-only accounts have no password to check against
+	if !user.PasswordHash.Valid || user.PasswordHash.String == "" {
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	stored := user.PasswordHash.String
+
+	if password.IsLegacy(stored) {
+		// Rows written before hashing was introduced hold the raw password.
+		// Verify it once and replace it with a proper hash.
+		if err := password.VerifyLegacy(stored, pass); err != nil {
+			return nil, fmt.Errorf("invalid credentials")
+		}
+		return a.rehashPassword(ctx, user, pass)
+	}
+
+	if err := a.hasher.Verify(stored, pass); err != nil {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	if a.hasher.NeedsRehash(stored) {
+		return a.rehashPassword(ctx, user, pass)
+	}
+
 	return &user, nil
 }
+
+// rehashPassword stores a fresh hash of an already verified password
+func (a *AuthService) rehashPassword(ctx context.Context, user db.User, pass string) (*db.User, error) {
+	hash, err := a.hasher.Hash(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := a.db.SetUserPassword(ctx, db.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: sql.NullString{String: hash, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not upgrade password hash: %w", err)
+	}
+
+	return &updated, nil
+}
@@ -0,0 +1,268 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// Kinds of Busy items
+const (
+	BusyEvent   = "event"
+	BusyMission = "mission"
+)
+
+// Busy is an event occurrence or a mission taking up the user's time. One
+// without an end takes up only the instant it starts.
+type Busy struct {
+	Kind         string     `json:"kind"`
+	ID           uuid.UUID  `json:"id"`
+	Title        string     `json:"title"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
+}
+
+// ConflictError is returned by a create or update whose event or mission
+// overlaps others of the user's; the change is rolled back
+type ConflictError struct {
+	Conflicts []Busy
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("overlaps %d other events or missions", len(e.Conflicts))
+}
+
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeBusy splits a window into the periods the user is busy and the free
+// time between them
+type FreeBusy struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	Busy []Period  `json:"busy"`
+	Free []Period  `json:"free"`
+}
+
+func (b Busy) end() time.Time {
+	if b.EndTime != nil {
+		return *b.EndTime
+	}
+	return b.StartTime
+}
+
+// overlaps reports whether two items share any time. Items starting together
+// always do, so an item without an end still clashes with one at its start.
+func (b Busy) overlaps(o Busy) bool {
+	if b.StartTime.Equal(o.StartTime) {
+		return true
+	}
+	return b.StartTime.Before(o.end()) && o.StartTime.Before(b.end())
+}
+
+// busyTimes lists the user's event occurrences and missions overlapping
+// [from, to) by start
+func busyTimes(ctx context.Context, q *db.Queries, userID uuid.UUID, from, to time.Time) ([]Busy, error) {
+	occurrences, err := expandEvents(ctx, q, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	missions, err := q.GetMissionsInRange(ctx, db.GetMissionsInRangeParams{
+		UserID:     userID,
+		RangeStart: from,
+		RangeEnd:   to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]Busy, 0, len(occurrences)+len(missions))
+	for _, o := range occurrences {
+		busy = append(busy, Busy{
+			Kind:         BusyEvent,
+			ID:           o.EventID,
+			Title:        o.Title,
+			StartTime:    o.StartTime,
+			EndTime:      o.EndTime,
+			RecurrenceID: o.RecurrenceID,
+		})
+	}
+	for _, m := range missions {
+		b := Busy{Kind: BusyMission, ID: m.ID, Title: m.Title, StartTime: m.StartTime}
+		if m.EndTime.Valid {
+			b.EndTime = &m.EndTime.Time
+		}
+		busy = append(busy, b)
+	}
+	sort.SliceStable(busy, func(i, j int) bool { return busy[i].StartTime.Before(busy[j].StartTime) })
+	return busy, nil
+}
+
+// FreeBusy merges the user's events and missions in [from, to) into busy
+// periods, with the free time left between them. Items without an end take
+// up no time here.
+func (c *CalendarService) FreeBusy(ctx context.Context, userID uuid.UUID, from, to time.Time) (*FreeBusy, error) {
+	busy, err := busyTimes(ctx, c.db, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := from.Location()
+	fb := &FreeBusy{From: from, To: to, Busy: []Period{}, Free: []Period{}}
+	for _, b := range busy {
+		start, end := b.StartTime, b.end()
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			continue
+		}
+		if n := len(fb.Busy); n > 0 && !start.After(fb.Busy[n-1].End) {
+			if end.After(fb.Busy[n-1].End) {
+				fb.Busy[n-1].End = end.In(loc)
+			}
+			continue
+		}
+		fb.Busy = append(fb.Busy, Period{Start: start.In(loc), End: end.In(loc)})
+	}
+
+	free := from
+	for _, p := range fb.Busy {
+		if p.Start.After(free) {
+			fb.Free = append(fb.Free, Period{Start: free, End: p.Start})
+		}
+		free = p.End
+	}
+	if to.After(free) {
+		fb.Free = append(fb.Free, Period{Start: free, End: to.In(loc)})
+	}
+	return fb, nil
+}
+
+// conflictTarget is the event or mission a write made and the window its
+// overlaps are looked for in. With a recurrenceID only that occurrence of
+// the event is checked.
+type conflictTarget struct {
+	userID       uuid.UUID
+	kind         string
+	id           uuid.UUID
+	recurrenceID *time.Time
+	from         time.Time
+	to           time.Time
+}
+
+func (t conflictTarget) matches(b Busy) bool {
+	if b.Kind != t.kind || b.ID != t.id {
+		return false
+	}
+	return t.recurrenceID == nil || (b.RecurrenceID != nil && b.RecurrenceID.Equal(*t.recurrenceID))
+}
+
+// eventTarget checks every occurrence of a stored event: a single event
+// over its own span, a series from its start or, once running, from now,
+// for at most MaxExpandWindow
+func eventTarget(e db.CalendarEvent) (conflictTarget, error) {
+	s, err := eventSeries(e)
+	if err != nil {
+		return conflictTarget{}, err
+	}
+	t := conflictTarget{userID: e.UserID, kind: BusyEvent, id: e.ID, from: s.start}
+	if s.rule == nil {
+		t.to = spanTo(s.start, s.endOf(s.start))
+		return t, nil
+	}
+	if now := time.Now(); t.from.Before(now) {
+		t.from = now
+	}
+	t.to = t.from.Add(MaxExpandWindow)
+	return t, nil
+}
+
+func occurrenceTarget(e db.CalendarEvent, ov db.CalendarEventOverride) conflictTarget {
+	return conflictTarget{
+		userID:       e.UserID,
+		kind:         BusyEvent,
+		id:           e.ID,
+		recurrenceID: &ov.OriginalStart,
+		from:         ov.StartTime,
+		to:           spanTo(ov.StartTime, ov.EndTime),
+	}
+}
+
+func missionTarget(m db.Mission) conflictTarget {
+	return conflictTarget{
+		userID: m.UserID,
+		kind:   BusyMission,
+		id:     m.ID,
+		from:   m.StartTime,
+		to:     spanTo(m.StartTime, m.EndTime),
+	}
+}
+
+// spanTo is the end of the window checked for an item, at least a second
+// past its start so one without an end is still found
+func spanTo(start time.Time, end sql.NullTime) time.Time {
+	to := start.Add(time.Second)
+	if end.Valid && end.Time.After(to) {
+		to = end.Time
+	}
+	if limit := start.Add(MaxExpandWindow); to.After(limit) {
+		to = limit
+	}
+	return to
+}
+
+// checkConflicts returns a ConflictError listing the items the target
+// overlaps, as q sees them
+func checkConflicts(ctx context.Context, q *db.Queries, t conflictTarget) error {
+	busy, err := busyTimes(ctx, q, t.userID, t.from, t.to)
+	if err != nil {
+		return err
+	}
+
+	var own, others []Busy
+	for _, b := range busy {
+		if t.matches(b) {
+			own = append(own, b)
+		} else {
+			others = append(others, b)
+		}
+	}
+
+	var conflicts []Busy
+	for _, o := range others {
+		for _, b := range own {
+			if b.overlaps(o) {
+				conflicts = append(conflicts, o)
+				break
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// withConflictCheck runs write in a transaction and, unless allowConflict,
+// rolls it back with a ConflictError when what it wrote overlaps other items
+func (c *CalendarService) withConflictCheck(ctx context.Context, allowConflict bool, write func(q *db.Queries) (conflictTarget, error)) error {
+	return c.withTx(ctx, func(q *db.Queries) error {
+		t, err := write(q)
+		if err != nil || allowConflict {
+			return err
+		}
+		return checkConflicts(ctx, q, t)
+	})
+}
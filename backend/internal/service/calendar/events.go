@@ -3,27 +3,51 @@ package calendar
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
 )
 
+var (
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	ErrInvalidTimezone   = errors.New("unknown timezone")
+	ErrEndBeforeStart    = errors.New("end time is before start time")
+)
+
+// EventInput is a new event. RRule makes it recurring, expanded in Timezone,
+// an IANA name defaulting to UTC. Unless AllowConflict is set, an event
+// overlapping others is refused with a ConflictError.
+type EventInput struct {
+	Title         string
+	Description   string
+	StartTime     time.Time
+	EndTime       time.Time
+	RRule         string
+	Timezone      string
+	AllowConflict bool
+}
+
 func (c *CalendarService) CreateEvent(
 	ctx context.Context,
 	userID uuid.UUID,
-	title string,
-	description string,
-	startTime time.Time,
-	endTime time.Time,
+	in EventInput,
 ) (db.CalendarEvent, error) {
-	return c.db.CreateCalendarEvent(ctx, db.CreateCalendarEventParams{
-		UserID:      userID,
-		Title:       title,
-		Description: sql.NullString{String: description, Valid: description != ""},
-		StartTime:   startTime,
-		EndTime:     sql.NullTime{Time: endTime, Valid: !endTime.IsZero()},
+	s, err := newSeries(in.StartTime, in.EndTime, in.RRule, in.Timezone)
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+
+	var created db.CalendarEvent
+	err = c.withConflictCheck(ctx, in.AllowConflict, func(q *db.Queries) (conflictTarget, error) {
+		created, err = q.CreateCalendarEvent(ctx, s.createParams(userID, in.Title, nullString(in.Description), nil))
+		if err != nil {
+			return conflictTarget{}, err
+		}
+		return eventTarget(created)
 	})
+	return created, err
 }
 
 func (c *CalendarService) GetEventsByUser(
@@ -33,13 +57,7 @@ func (c *CalendarService) GetEventsByUser(
 	return c.db.GetEventsByUser(ctx, userID)
 }
 
-func (c *CalendarService) UpdateEvent(
-	ctx context.Context,
-	params db.UpdateCalendarEventParams,
-) (db.CalendarEvent, error) {
-	return c.db.UpdateCalendarEvent(ctx, params)
-}
-
+// DeleteEvent removes an event, every occurrence of a series included
 func (c *CalendarService) DeleteEvent(
 	ctx context.Context,
 	eventID uuid.UUID,
@@ -57,3 +75,7 @@ func (c *CalendarService) GetCalendarEventByID(
 func (c *CalendarService) GetAllEvents(ctx context.Context) ([]db.CalendarEvent, error) {
 	return c.db.GetAllCalendarEvents(ctx)
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,174 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/ical"
+)
+
+// uidDomain qualifies the UIDs of exported events so they stay unique in the
+// calendars they are imported into
+const uidDomain = "sinepsis"
+
+// ICalendar renders the user's events and missions as one calendar. A
+// recurring event keeps its RRULE, its cancelled occurrences become EXDATEs
+// and each edited occurrence a VEVENT with the series' UID and a
+// RECURRENCE-ID.
+func (c *CalendarService) ICalendar(ctx context.Context, userID uuid.UUID) (*ical.Calendar, error) {
+	events, err := c.db.GetEventsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	missions, err := c.db.GetMissionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recurring []uuid.UUID
+	for _, e := range events {
+		if e.Rrule.Valid {
+			recurring = append(recurring, e.ID)
+		}
+	}
+	overrides := map[uuid.UUID][]db.CalendarEventOverride{}
+	if len(recurring) > 0 {
+		rows, err := c.db.ListEventOverrides(ctx, recurring)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range rows {
+			overrides[o.EventID] = append(overrides[o.EventID], o)
+		}
+	}
+
+	cal := &ical.Calendar{ProdID: "-//Sinepsis//Calendar//EN", Name: "Sinepsis"}
+	for _, e := range events {
+		vevents, err := eventVEvents(e, overrides[e.ID])
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		cal.Events = append(cal.Events, vevents...)
+	}
+	for _, m := range missions {
+		cal.Events = append(cal.Events, missionVEvent(m))
+	}
+	return cal, nil
+}
+
+func eventVEvents(e db.CalendarEvent, overrides []db.CalendarEventOverride) ([]ical.Event, error) {
+	s, err := eventSeries(e)
+	if err != nil {
+		return nil, err
+	}
+
+	master := ical.Event{
+		UID:          eventUID(e),
+		Summary:      e.Title,
+		Description:  e.Description.String,
+		Start:        s.start,
+		End:          s.endOf(s.start).Time,
+		TimeZone:     s.loc,
+		RRule:        e.Rrule.String,
+		Status:       "CONFIRMED",
+		Sequence:     sequence(e.CreatedAt, e.UpdatedAt),
+		Created:      e.CreatedAt,
+		LastModified: e.UpdatedAt,
+	}
+
+	var edited []ical.Event
+	for _, o := range overrides {
+		if o.Cancelled {
+			master.ExDates = append(master.ExDates, o.OriginalStart)
+			continue
+		}
+		ev := master
+		ev.RRule, ev.ExDates = "", nil
+		ev.RecurrenceID = o.OriginalStart
+		ev.Start, ev.End = o.StartTime, o.EndTime.Time
+		if o.Title.Valid {
+			ev.Summary = o.Title.String
+		}
+		if o.Description.Valid {
+			ev.Description = o.Description.String
+		}
+		ev.Sequence = max(master.Sequence, sequence(o.CreatedAt, o.UpdatedAt))
+		ev.LastModified = o.UpdatedAt
+		edited = append(edited, ev)
+	}
+	return append([]ical.Event{master}, edited...), nil
+}
+
+func missionVEvent(m db.Mission) ical.Event {
+	ev := ical.Event{
+		UID:          fmt.Sprintf("mission-%s@%s", m.ID, uidDomain),
+		Summary:      "Mission: " + m.Title,
+		Start:        m.StartTime,
+		End:          m.EndTime.Time,
+		TimeZone:     time.UTC,
+		Categories:   []string{"Mission"},
+		Status:       "CONFIRMED",
+		Sequence:     sequence(m.CreatedAt, m.UpdatedAt),
+		Created:      m.CreatedAt,
+		LastModified: m.UpdatedAt,
+	}
+
+	var details []string
+	if m.Description.Valid && m.Description.String != "" {
+		details = append(details, m.Description.String, "")
+	}
+	if m.MissionType.Valid {
+		t := string(m.MissionType.MissionTypeEnum)
+		ev.Categories = append(ev.Categories, t)
+		ev.Extra = append(ev.Extra, ical.Property{Name: "X-SINEPSIS-MISSION-TYPE", Value: t})
+		details = append(details, "Type: "+t)
+	}
+	if m.ThreatLevel.Valid {
+		l := string(m.ThreatLevel.ThreatLevelEnum)
+		ev.Categories = append(ev.Categories, "threat:"+l)
+		ev.Priority = threatPriority[m.ThreatLevel.ThreatLevelEnum]
+		ev.Extra = append(ev.Extra, ical.Property{Name: "X-SINEPSIS-THREAT-LEVEL", Value: l})
+		details = append(details, "Threat level: "+l)
+	}
+	if m.Success.Valid {
+		outcome := "failed"
+		if m.Success.Bool {
+			outcome = "succeeded"
+		}
+		details = append(details, "Outcome: "+outcome)
+	}
+	if m.Latitude.Valid && m.Longitude.Valid {
+		ev.Geo = &ical.Geo{Lat: m.Latitude.Float64, Lon: m.Longitude.Float64}
+		ev.Location = fmt.Sprintf("%.6f, %.6f", m.Latitude.Float64, m.Longitude.Float64)
+	}
+	ev.Description = strings.TrimSpace(strings.Join(details, "\n"))
+	return ev
+}
+
+// threatPriority maps threat levels onto the iCalendar PRIORITY scale, where
+// 1 is the most urgent
+var threatPriority = map[db.ThreatLevelEnum]int{
+	db.ThreatLevelEnumCritical: 1,
+	db.ThreatLevelEnumHigh:     3,
+	db.ThreatLevelEnumMedium:   5,
+	db.ThreatLevelEnumLow:      7,
+}
+
+// eventUID keeps the UID an imported event came with, so the tool it came
+// from recognises it
+func eventUID(e db.CalendarEvent) string {
+	if e.Uid.Valid {
+		return e.Uid.String
+	}
+	return fmt.Sprintf("%s@%s", e.ID, uidDomain)
+}
+
+// sequence is the SEQUENCE of a record: the seconds between its creation and
+// last update, which grows with every edit so clients replace their copy
+func sequence(created, updated time.Time) int {
+	return max(0, int(updated.Sub(created)/time.Second))
+}
@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+var (
+	ErrFeedTokenInvalid  = errors.New("feed token is invalid or revoked")
+	ErrFeedTokenNotFound = errors.New("feed token not found")
+)
+
+// FeedToken is a subscription feed credential. Token is only filled in when
+// the token is created; afterwards just its hash is kept.
+type FeedToken struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name,omitempty"`
+	Token      string     `json:"token,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateFeedToken issues a new feed token for the user
+func (c *CalendarService) CreateFeedToken(ctx context.Context, userID uuid.UUID, name string) (FeedToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return FeedToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	row, err := c.db.CreateFeedToken(ctx, db.CreateFeedTokenParams{
+		UserID:    userID,
+		TokenHash: hashFeedToken(token),
+		Name:      nullString(name),
+	})
+	if err != nil {
+		return FeedToken{}, err
+	}
+
+	ft := toFeedToken(row)
+	ft.Token = token
+	return ft, nil
+}
+
+func (c *CalendarService) ListFeedTokens(ctx context.Context, userID uuid.UUID) ([]FeedToken, error) {
+	rows, err := c.db.ListFeedTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]FeedToken, len(rows))
+	for i, r := range rows {
+		tokens[i] = toFeedToken(r)
+	}
+	return tokens, nil
+}
+
+// RevokeFeedToken revokes one of the user's active feed tokens
+func (c *CalendarService) RevokeFeedToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	n, err := c.db.RevokeFeedToken(ctx, db.RevokeFeedTokenParams{ID: tokenID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrFeedTokenNotFound
+	}
+	return nil
+}
+
+// FeedOwner resolves an active feed token to the user whose calendar it
+// opens
+func (c *CalendarService) FeedOwner(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, err := c.db.UseFeedToken(ctx, hashFeedToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrFeedTokenInvalid
+	}
+	return userID, err
+}
+
+func toFeedToken(r db.CalendarFeedToken) FeedToken {
+	ft := FeedToken{ID: r.ID, Name: r.Name.String, CreatedAt: r.CreatedAt}
+	if r.LastUsedAt.Valid {
+		ft.LastUsedAt = &r.LastUsedAt.Time
+	}
+	if r.RevokedAt.Valid {
+		ft.RevokedAt = &r.RevokedAt.Time
+	}
+	return ft
+}
+
+// Feed tokens are 256 bits of randomness like refresh tokens, so a plain
+// SHA-256 is enough
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,377 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/ical"
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar file")
+
+// ImportAction is what an import does with one VEVENT
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "create"
+	ImportUpdate ImportAction = "update"
+	ImportDelete ImportAction = "delete"
+	ImportSkip   ImportAction = "skip"
+)
+
+// ImportOptions controls an import. Timezone reads floating times and
+// all-day dates, defaulting to UTC.
+type ImportOptions struct {
+	DryRun   bool
+	Timezone string
+}
+
+// ImportItem is the outcome for one event of the file, with its edited and
+// cancelled occurrences counted in Overrides and Cancelled
+type ImportItem struct {
+	UID       string       `json:"uid,omitempty"`
+	Action    ImportAction `json:"action"`
+	EventID   *uuid.UUID   `json:"event_id,omitempty"`
+	Title     string       `json:"title,omitempty"`
+	StartTime *time.Time   `json:"start_time,omitempty"`
+	EndTime   *time.Time   `json:"end_time,omitempty"`
+	RRule     string       `json:"rrule,omitempty"`
+	Timezone  string       `json:"timezone,omitempty"`
+	Overrides int          `json:"overrides,omitempty"`
+	Cancelled int          `json:"cancelled,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty"`
+}
+
+// ImportResult is the preview of a dry run, or what an import did
+type ImportResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Deleted int          `json:"deleted"`
+	Skipped int          `json:"skipped"`
+	Items   []ImportItem `json:"items"`
+}
+
+// importPlan is the change one VEVENT makes, worked out before anything is
+// written
+type importPlan struct {
+	item        ImportItem
+	existing    *db.CalendarEvent
+	series      series
+	title       string
+	description sql.NullString
+	overrides   []db.CalendarEventOverride
+}
+
+func (p *importPlan) warn(format string, args ...any) {
+	p.item.Warnings = append(p.item.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Import reads the VEVENTs of an .ics file into the user's calendar. Events
+// are matched by UID, so importing a file again updates the events it
+// created: the file's version replaces the event along with its edited and
+// cancelled occurrences, and a VEVENT with STATUS:CANCELLED deletes it.
+// Events this calendar exported are matched back to themselves. A dry run
+// returns the same result without writing anything; otherwise every change
+// is made in one transaction.
+func (c *CalendarService) Import(ctx context.Context, userID uuid.UUID, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	floating, err := loadLocation(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	cal, skipped, err := ical.Decode(r, floating)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	// Occurrences edited in the source come as VEVENTs sharing the series'
+	// UID, told apart by their RECURRENCE-ID
+	var masters []ical.Event
+	edits := map[string][]ical.Event{}
+	for _, ev := range cal.Events {
+		if !ev.RecurrenceID.IsZero() && ev.UID != "" {
+			edits[ev.UID] = append(edits[ev.UID], ev)
+		} else {
+			masters = append(masters, ev)
+		}
+	}
+
+	var plans []*importPlan
+	seen := map[string]bool{}
+	for _, ev := range masters {
+		if ev.UID != "" && seen[ev.UID] {
+			p := &importPlan{item: ImportItem{UID: ev.UID, Action: ImportSkip, Title: ev.Summary}}
+			p.warn("duplicate UID in the file")
+			plans = append(plans, p)
+			continue
+		}
+		seen[ev.UID] = true
+		p, err := c.planImport(ctx, userID, ev, edits[ev.UID])
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, p)
+	}
+	for _, ev := range cal.Events {
+		if !ev.RecurrenceID.IsZero() && ev.UID != "" && !seen[ev.UID] {
+			p := &importPlan{item: ImportItem{UID: ev.UID, Action: ImportSkip, Title: ev.Summary}}
+			p.warn("edited occurrence without its recurring event in the file")
+			plans = append(plans, p)
+		}
+	}
+
+	if !opts.DryRun {
+		err := c.withTx(ctx, func(q *db.Queries) error {
+			for _, p := range plans {
+				if err := p.apply(ctx, q, userID); err != nil {
+					return fmt.Errorf("import %q: %w", p.item.UID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ImportResult{DryRun: opts.DryRun, Items: []ImportItem{}}
+	for _, err := range skipped {
+		result.Items = append(result.Items, ImportItem{Action: ImportSkip, Warnings: []string{err.Error()}})
+		result.Skipped++
+	}
+	for _, p := range plans {
+		result.Items = append(result.Items, p.item)
+		switch p.item.Action {
+		case ImportCreate:
+			result.Created++
+		case ImportUpdate:
+			result.Updated++
+		case ImportDelete:
+			result.Deleted++
+		default:
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// planImport works out what a VEVENT and its edited occurrences change
+func (c *CalendarService) planImport(ctx context.Context, userID uuid.UUID, ev ical.Event, edits []ical.Event) (*importPlan, error) {
+	p := &importPlan{item: ImportItem{UID: ev.UID, Title: ev.Summary}}
+
+	if strings.HasPrefix(ev.UID, "mission-") && strings.HasSuffix(ev.UID, "@"+uidDomain) {
+		p.item.Action = ImportSkip
+		p.warn("missions are not imported as events")
+		return p, nil
+	}
+
+	existing, err := c.importedEvent(ctx, userID, ev.UID)
+	if err != nil {
+		return nil, err
+	}
+	p.existing = existing
+	if existing != nil {
+		p.item.EventID = &existing.ID
+	}
+
+	if ev.Status == "CANCELLED" {
+		p.item.Action = ImportSkip
+		if existing != nil {
+			p.item.Action = ImportDelete
+		}
+		return p, nil
+	}
+
+	if ev.UID == "" {
+		p.warn("no UID, importing the file again adds another copy")
+	}
+	p.title = strings.TrimSpace(ev.Summary)
+	if p.title == "" {
+		p.title = "Untitled event"
+		p.warn("no SUMMARY, titled %q", p.title)
+	}
+	p.description = nullString(ev.Description)
+
+	timezone := "UTC"
+	if ev.TimeZone != nil {
+		if _, err := loadLocation(ev.TimeZone.String()); err == nil {
+			timezone = ev.TimeZone.String()
+		} else {
+			p.warn("timezone %q is not an IANA zone, recurrence is expanded in UTC", ev.TimeZone.String())
+		}
+	}
+
+	p.series, err = newSeries(ev.Start, ev.End, ev.RRule, timezone)
+	if errors.Is(err, ErrInvalidRecurrence) {
+		p.warn("%v, only the first occurrence is imported", err)
+		p.series, err = newSeries(ev.Start, ev.End, "", timezone)
+	}
+	if err != nil {
+		p.item.Action = ImportSkip
+		p.warn("%v", err)
+		return p, nil
+	}
+
+	p.planOverrides(ev, edits)
+
+	p.item.Action = ImportCreate
+	if existing != nil {
+		p.item.Action = ImportUpdate
+	}
+	p.item.Title = p.title
+	p.item.StartTime = &p.series.start
+	if end := p.series.endOf(p.series.start); end.Valid {
+		p.item.EndTime = &end.Time
+	}
+	p.item.RRule = p.series.rruleValue().String
+	p.item.Timezone = p.series.loc.String()
+	return p, nil
+}
+
+// planOverrides turns EXDATEs into cancelled occurrences and the VEVENTs
+// with a RECURRENCE-ID into edited ones
+func (p *importPlan) planOverrides(ev ical.Event, edits []ical.Event) {
+	s := p.series
+	if s.rule == nil {
+		if len(ev.ExDates) > 0 || len(edits) > 0 {
+			p.warn("EXDATE and edited occurrences of a non-recurring event are ignored")
+		}
+		return
+	}
+
+	byStart := map[int64]db.CalendarEventOverride{}
+	cancel := func(t time.Time) {
+		byStart[t.UnixNano()] = db.CalendarEventOverride{
+			OriginalStart: t,
+			StartTime:     t,
+			EndTime:       s.endOf(t),
+			Cancelled:     true,
+		}
+	}
+	for _, t := range ev.ExDates {
+		if s.check(t) != nil {
+			p.warn("EXDATE %s is not an occurrence", t.Format(time.RFC3339))
+			continue
+		}
+		cancel(t)
+	}
+	for _, ed := range edits {
+		t := ed.RecurrenceID
+		if s.check(t) != nil {
+			p.warn("RECURRENCE-ID %s is not an occurrence", t.Format(time.RFC3339))
+			continue
+		}
+		if ed.Status == "CANCELLED" {
+			cancel(t)
+			continue
+		}
+		o := db.CalendarEventOverride{
+			OriginalStart: t,
+			StartTime:     ed.Start,
+			EndTime:       sql.NullTime{Time: ed.End, Valid: !ed.End.IsZero()},
+		}
+		if title := strings.TrimSpace(ed.Summary); title != "" && title != p.title {
+			o.Title = sql.NullString{String: title, Valid: true}
+		}
+		if ed.Description != p.description.String {
+			o.Description = sql.NullString{String: ed.Description, Valid: true}
+		}
+		byStart[t.UnixNano()] = o
+	}
+
+	for _, o := range byStart {
+		p.overrides = append(p.overrides, o)
+		if o.Cancelled {
+			p.item.Cancelled++
+		} else {
+			p.item.Overrides++
+		}
+	}
+	sort.Slice(p.overrides, func(i, j int) bool {
+		return p.overrides[i].OriginalStart.Before(p.overrides[j].OriginalStart)
+	})
+}
+
+// importedEvent finds the event a UID was imported as, or the event it was
+// exported from
+func (c *CalendarService) importedEvent(ctx context.Context, userID uuid.UUID, uid string) (*db.CalendarEvent, error) {
+	if uid == "" {
+		return nil, nil
+	}
+	e, err := c.db.GetEventByUID(ctx, db.GetEventByUIDParams{UserID: userID, Uid: nullString(uid)})
+	if err == nil {
+		return &e, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	id, ok := strings.CutSuffix(uid, "@"+uidDomain)
+	if !ok {
+		return nil, nil
+	}
+	eventID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil
+	}
+	e, err = c.db.GetCalendarEventByID(ctx, eventID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case e.UserID != userID:
+		return nil, nil
+	}
+	return &e, nil
+}
+
+func (p *importPlan) apply(ctx context.Context, q *db.Queries, userID uuid.UUID) error {
+	var eventID uuid.UUID
+	switch p.item.Action {
+	case ImportDelete:
+		return q.DeleteCalendarEvent(ctx, p.existing.ID)
+	case ImportCreate:
+		params := p.series.createParams(userID, p.title, p.description, p.overrides)
+		params.Uid = nullString(p.item.UID)
+		created, err := q.CreateCalendarEvent(ctx, params)
+		if err != nil {
+			return err
+		}
+		eventID = created.ID
+		p.item.EventID = &eventID
+	case ImportUpdate:
+		eventID = p.existing.ID
+		if err := q.DeleteEventOverrides(ctx, eventID); err != nil {
+			return err
+		}
+		if _, err := q.UpdateCalendarEvent(ctx, p.series.updateParams(eventID, p.title, p.description, p.overrides)); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	for _, o := range p.overrides {
+		if _, err := q.UpsertEventOverride(ctx, db.UpsertEventOverrideParams{
+			EventID:       eventID,
+			OriginalStart: o.OriginalStart,
+			Title:         o.Title,
+			Description:   o.Description,
+			StartTime:     o.StartTime,
+			EndTime:       o.EndTime,
+			Cancelled:     o.Cancelled,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
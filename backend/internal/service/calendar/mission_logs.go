@@ -28,6 +28,13 @@ func (c *CalendarService) GetMissionLogs(
 	return c.db.GetLogsByMission(ctx, missionID)
 }
 
+func (c *CalendarService) GetMissionLogByID(
+	ctx context.Context,
+	logID uuid.UUID,
+) (db.MissionLog, error) {
+	return c.db.GetMissionLogByID(ctx, logID)
+}
+
 func (c *CalendarService) DeleteMissionLog(
 	ctx context.Context,
 	logID uuid.UUID,
@@ -9,6 +9,8 @@ import (
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
 )
 
+// CreateMission adds a mission. Unless allowConflict, one overlapping the
+// user's other events and missions is refused with a ConflictError.
 func (c *CalendarService) CreateMission(
 	ctx context.Context,
 	userID uuid.UUID,
@@ -21,19 +23,26 @@ func (c *CalendarService) CreateMission(
 	endTime time.Time,
 	threatLevel db.NullThreatLevelEnum,
 	success sql.NullBool,
+	allowConflict bool,
 ) (db.Mission, error) {
-	return c.db.CreateMission(ctx, db.CreateMissionParams{
-		UserID:      userID,
-		Title:       title,
-		Description: sql.NullString{String: description, Valid: description != ""},
-		MissionType: missionType,
-		Latitude:    latitude,
-		Longitude:   longitude,
-		StartTime:   startTime,
-		EndTime:     sql.NullTime{Time: endTime, Valid: !endTime.IsZero()},
-		ThreatLevel: threatLevel,
-		Success:     success,
+	var mission db.Mission
+	err := c.withConflictCheck(ctx, allowConflict, func(q *db.Queries) (conflictTarget, error) {
+		var err error
+		mission, err = q.CreateMission(ctx, db.CreateMissionParams{
+			UserID:      userID,
+			Title:       title,
+			Description: sql.NullString{String: description, Valid: description != ""},
+			MissionType: missionType,
+			Latitude:    latitude,
+			Longitude:   longitude,
+			StartTime:   startTime,
+			EndTime:     sql.NullTime{Time: endTime, Valid: !endTime.IsZero()},
+			ThreatLevel: threatLevel,
+			Success:     success,
+		})
+		return missionTarget(mission), err
 	})
+	return mission, err
 }
 
 func (c *CalendarService) GetMissionByID(
@@ -50,11 +59,20 @@ func (c *CalendarService) GetMissionsByUser(
 	return c.db.GetMissionsByUser(ctx, userID)
 }
 
+// UpdateMission edits a mission, refusing an edit that leaves it
+// overlapping other items unless allowConflict
 func (c *CalendarService) UpdateMission(
 	ctx context.Context,
 	params db.UpdateMissionParams,
+	allowConflict bool,
 ) (db.Mission, error) {
-	return c.db.UpdateMission(ctx, params)
+	var mission db.Mission
+	err := c.withConflictCheck(ctx, allowConflict, func(q *db.Queries) (conflictTarget, error) {
+		var err error
+		mission, err = q.UpdateMission(ctx, params)
+		return missionTarget(mission), err
+	})
+	return mission, err
 }
 
 func (c *CalendarService) DeleteMission(
@@ -0,0 +1,215 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page selects one page of a listing. Cursor is the NextCursor of the
+// previous page, empty for the first.
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+// MissionQuery narrows a mission listing. From and To keep the missions
+// overlapping that window and may be set alone; zero filters match all.
+// IncludeSquad lists the missions of members of squads the user commands
+// along with their own.
+type MissionQuery struct {
+	Page
+	IncludeSquad bool
+	From         time.Time
+	To           time.Time
+	MissionType  db.NullMissionTypeEnum
+	ThreatLevel  db.NullThreatLevelEnum
+	Success      sql.NullBool
+}
+
+type EventPage struct {
+	Events     []db.CalendarEvent `json:"events"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type OccurrencePage struct {
+	Occurrences []Occurrence `json:"occurrences"`
+	NextCursor  string       `json:"next_cursor,omitempty"`
+}
+
+type MissionPage struct {
+	Missions   []db.Mission `json:"missions"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// pageCursor is the sort key of the last item on a page. RecurrenceID tells
+// apart the occurrences of one series starting at the same time.
+type pageCursor struct {
+	Start        time.Time  `json:"s"`
+	ID           uuid.UUID  `json:"i"`
+	RecurrenceID *time.Time `json:"r,omitempty"`
+}
+
+func (p Page) limit() int {
+	if p.Limit <= 0 {
+		return defaultPageLimit
+	}
+	return min(p.Limit, maxPageLimit)
+}
+
+// after decodes the cursor, returning nil for the first page
+func (p Page) after() (*pageCursor, error) {
+	if p.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func (c *pageCursor) keyset() (sql.NullTime, uuid.NullUUID) {
+	if c == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: c.Start, Valid: true}, uuid.NullUUID{UUID: c.ID, Valid: true}
+}
+
+func (c pageCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// ListEvents returns a page of the user's events as stored, by start time
+func (c *CalendarService) ListEvents(ctx context.Context, userID uuid.UUID, p Page) (*EventPage, error) {
+	after, err := p.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := p.limit()
+	afterStart, afterID := after.keyset()
+
+	rows, err := c.db.ListEventsPage(ctx, db.ListEventsPageParams{
+		UserID:     userID,
+		AfterStart: afterStart,
+		AfterID:    afterID,
+		// One extra row tells whether there is a next page
+		Lim: int32(limit + 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EventPage{Events: append([]db.CalendarEvent{}, rows...)}
+	if len(rows) > limit {
+		last := rows[limit-1]
+		page.Events = page.Events[:limit]
+		page.NextCursor = pageCursor{Start: last.StartTime, ID: last.ID}.encode()
+	}
+	return page, nil
+}
+
+// ListOccurrences returns a page of the user's occurrences overlapping
+// [from, to), in the order of ExpandEvents
+func (c *CalendarService) ListOccurrences(ctx context.Context, userID uuid.UUID, from, to time.Time, p Page) (*OccurrencePage, error) {
+	after, err := p.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := p.limit()
+
+	all, err := c.ExpandEvents(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	first := 0
+	if after != nil {
+		first = sort.Search(len(all), func(i int) bool { return compareOccurrence(occurrenceKey(all[i]), *after) > 0 })
+	}
+
+	page := &OccurrencePage{Occurrences: all[first:]}
+	if len(page.Occurrences) > limit {
+		page.Occurrences = page.Occurrences[:limit]
+		page.NextCursor = occurrenceKey(page.Occurrences[limit-1]).encode()
+	}
+	return page, nil
+}
+
+func occurrenceKey(o Occurrence) pageCursor {
+	return pageCursor{Start: o.StartTime, ID: o.EventID, RecurrenceID: o.RecurrenceID}
+}
+
+// compareOccurrence orders occurrences by start, then event, then original
+// start
+func compareOccurrence(a, b pageCursor) int {
+	if c := a.Start.Compare(b.Start); c != 0 {
+		return c
+	}
+	if c := bytes.Compare(a.ID[:], b.ID[:]); c != 0 {
+		return c
+	}
+	var ar, br time.Time
+	if a.RecurrenceID != nil {
+		ar = *a.RecurrenceID
+	}
+	if b.RecurrenceID != nil {
+		br = *b.RecurrenceID
+	}
+	return ar.Compare(br)
+}
+
+// ListMissions returns a page of the user's missions matching q, newest
+// first
+func (c *CalendarService) ListMissions(ctx context.Context, userID uuid.UUID, q MissionQuery) (*MissionPage, error) {
+	after, err := q.after()
+	if err != nil {
+		return nil, err
+	}
+	limit := q.limit()
+	afterStart, afterID := after.keyset()
+
+	rows, err := c.db.ListMissionsPage(ctx, db.ListMissionsPageParams{
+		UserID:       userID,
+		IncludeSquad: q.IncludeSquad,
+		RangeStart:   sql.NullTime{Time: q.From, Valid: !q.From.IsZero()},
+		RangeEnd:     sql.NullTime{Time: q.To, Valid: !q.To.IsZero()},
+		MissionType:  q.MissionType,
+		ThreatLevel:  q.ThreatLevel,
+		Success:      q.Success,
+		AfterStart:   afterStart,
+		AfterID:      afterID,
+		Lim:          int32(limit + 1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &MissionPage{Missions: append([]db.Mission{}, rows...)}
+	if len(rows) > limit {
+		last := rows[limit-1]
+		page.Missions = page.Missions[:limit]
+		page.NextCursor = pageCursor{Start: last.StartTime, ID: last.ID}.encode()
+	}
+	return page, nil
+}
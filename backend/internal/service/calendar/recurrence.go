@@ -0,0 +1,617 @@
+package calendar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/rrule"
+)
+
+// MaxExpandWindow caps the range occurrences are expanded over in one call
+const MaxExpandWindow = 366 * 24 * time.Hour
+
+var (
+	ErrInvalidWindow   = errors.New("to must be after from and at most 366 days later")
+	ErrInvalidScope    = errors.New("scope must be this, following or all")
+	ErrNotAnOccurrence = errors.New("not an occurrence of this event")
+	ErrRecurrenceScope = errors.New("rrule and timezone can only change for following or all occurrences")
+)
+
+// Scope is how much of a recurring event an edit or deletion applies to
+type Scope string
+
+const (
+	ScopeAll       Scope = "all"
+	ScopeThis      Scope = "this"
+	ScopeFollowing Scope = "following"
+)
+
+// ParseScope reads a scope, defaulting to all
+func ParseScope(v string) (Scope, error) {
+	switch Scope(v) {
+	case "", ScopeAll:
+		return ScopeAll, nil
+	case ScopeThis, ScopeFollowing:
+		return Scope(v), nil
+	}
+	return "", ErrInvalidScope
+}
+
+// Occurrence is one instance of an event. RecurrenceID is the original
+// start of a recurring event's occurrence, which names it in scoped edits
+// even after it was moved.
+type Occurrence struct {
+	EventID      uuid.UUID  `json:"event_id"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	RecurrenceID *time.Time `json:"recurrence_id,omitempty"`
+	RRule        string     `json:"rrule,omitempty"`
+	Timezone     string     `json:"timezone"`
+	Overridden   bool       `json:"overridden,omitempty"`
+}
+
+// EventChanges is a partial edit; nil fields keep their value. An empty
+// RRule turns a series into a single event. Unless AllowConflict is set, an
+// edit leaving the event overlapping others is refused with a ConflictError.
+type EventChanges struct {
+	Title         *string
+	Description   *string
+	StartTime     *time.Time
+	EndTime       *time.Time
+	RRule         *string
+	Timezone      *string
+	AllowConflict bool
+}
+
+// series is an event's timing: its first start in its own timezone, its
+// duration and, when recurring, its rule
+type series struct {
+	rule     *rrule.Rule
+	loc      *time.Location
+	start    time.Time
+	duration time.Duration
+	hasEnd   bool
+}
+
+func newSeries(start, end time.Time, rule, timezone string) (series, error) {
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return series{}, err
+	}
+	s := series{loc: loc, start: start.In(loc)}
+	if !end.IsZero() {
+		if end.Before(start) {
+			return series{}, ErrEndBeforeStart
+		}
+		s.duration = end.Sub(start)
+		s.hasEnd = true
+	}
+	if strings.TrimSpace(rule) != "" {
+		if s.rule, err = parseRule(rule); err != nil {
+			return series{}, err
+		}
+	}
+	return s, nil
+}
+
+func eventSeries(e db.CalendarEvent) (series, error) {
+	return newSeries(e.StartTime, e.EndTime.Time, e.Rrule.String, e.Timezone)
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w %q", ErrInvalidTimezone, name)
+	}
+	return loc, nil
+}
+
+func parseRule(v string) (*rrule.Rule, error) {
+	r, err := rrule.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+	return r, nil
+}
+
+func (s series) endOf(start time.Time) sql.NullTime {
+	return sql.NullTime{Time: start.Add(s.duration), Valid: s.hasEnd}
+}
+
+func (s series) rruleValue() sql.NullString {
+	if s.rule == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: s.rule.String(), Valid: true}
+}
+
+// seriesEnd is the latest end of any occurrence, counting edited ones, or
+// NULL for single events and unbounded series
+func (s series) seriesEnd(overrides []db.CalendarEventOverride) sql.NullTime {
+	if s.rule == nil || !s.rule.Bounded() {
+		return sql.NullTime{}
+	}
+	end := s.start
+	if last, ok := s.rule.Last(s.start); ok {
+		end = last.Add(s.duration)
+	}
+	for _, o := range overrides {
+		if e := overrideEnd(o); !o.Cancelled && e.After(end) {
+			end = e
+		}
+	}
+	return sql.NullTime{Time: end, Valid: true}
+}
+
+func overrideEnd(o db.CalendarEventOverride) time.Time {
+	if o.EndTime.Valid {
+		return o.EndTime.Time
+	}
+	return o.StartTime
+}
+
+// check reports whether t is the original start of one of the occurrences
+func (s series) check(t time.Time) error {
+	if s.rule == nil || !s.rule.Includes(s.start, t) {
+		return ErrNotAnOccurrence
+	}
+	return nil
+}
+
+// sameTiming reports whether two series generate occurrences at the same
+// original starts, so their edited occurrences still line up
+func (s series) sameTiming(o series) bool {
+	return s.start.Equal(o.start) && s.loc.String() == o.loc.String() &&
+		s.rruleValue() == o.rruleValue()
+}
+
+// retime applies the time changes of an edit made from the occurrence
+// starting at ref. Moving that occurrence moves the series start by as much.
+func (s series) retime(ref time.Time, ch EventChanges) (series, error) {
+	out := s
+	start := ref
+	if ch.StartTime != nil {
+		start = *ch.StartTime
+		out.start = s.start.Add(start.Sub(ref))
+	}
+	if ch.EndTime != nil {
+		if ch.EndTime.Before(start) {
+			return series{}, ErrEndBeforeStart
+		}
+		out.duration = ch.EndTime.Sub(start)
+		out.hasEnd = true
+	}
+	if ch.Timezone != nil {
+		loc, err := loadLocation(*ch.Timezone)
+		if err != nil {
+			return series{}, err
+		}
+		out.loc = loc
+	}
+	out.start = out.start.In(out.loc)
+	if ch.RRule != nil {
+		out.rule = nil
+		if strings.TrimSpace(*ch.RRule) != "" {
+			rule, err := parseRule(*ch.RRule)
+			if err != nil {
+				return series{}, err
+			}
+			out.rule = rule
+		}
+	}
+	return out, nil
+}
+
+// split divides a series at the occurrence starting at t, preceded by
+// before others. COUNT is shared between the halves; otherwise the first
+// half ends with UNTIL just before t.
+func (s series) split(t time.Time, before int) (head, tail series) {
+	headRule, tailRule := *s.rule, *s.rule
+	if s.rule.Count > 0 {
+		headRule.Count = before
+		tailRule.Count = s.rule.Count - before
+	} else {
+		headRule.Until = t.Add(-time.Second)
+	}
+	head, tail = s, s
+	head.rule, tail.rule = &headRule, &tailRule
+	tail.start = t.In(s.loc)
+	return head, tail
+}
+
+func (s series) createParams(userID uuid.UUID, title string, description sql.NullString, overrides []db.CalendarEventOverride) db.CreateCalendarEventParams {
+	return db.CreateCalendarEventParams{
+		UserID:      userID,
+		Title:       title,
+		Description: description,
+		StartTime:   s.start,
+		EndTime:     s.endOf(s.start),
+		Rrule:       s.rruleValue(),
+		Timezone:    s.loc.String(),
+		SeriesEnd:   s.seriesEnd(overrides),
+	}
+}
+
+func (s series) updateParams(id uuid.UUID, title string, description sql.NullString, overrides []db.CalendarEventOverride) db.UpdateCalendarEventParams {
+	return db.UpdateCalendarEventParams{
+		ID:          id,
+		Title:       title,
+		Description: description,
+		StartTime:   s.start,
+		EndTime:     s.endOf(s.start),
+		Rrule:       s.rruleValue(),
+		Timezone:    s.loc.String(),
+		SeriesEnd:   s.seriesEnd(overrides),
+	}
+}
+
+// occurrence is the unedited occurrence of e starting at t
+func (s series) occurrence(e db.CalendarEvent, t time.Time) Occurrence {
+	o := Occurrence{
+		EventID:     e.ID,
+		Title:       e.Title,
+		Description: e.Description.String,
+		StartTime:   t.In(s.loc),
+		RRule:       e.Rrule.String,
+		Timezone:    s.loc.String(),
+	}
+	if s.hasEnd {
+		end := o.StartTime.Add(s.duration)
+		o.EndTime = &end
+	}
+	if s.rule != nil {
+		id := o.StartTime
+		o.RecurrenceID = &id
+	}
+	return o
+}
+
+// edited is the occurrence of e an override replaces
+func (s series) edited(e db.CalendarEvent, ov db.CalendarEventOverride) Occurrence {
+	o := s.occurrence(e, ov.OriginalStart)
+	o.StartTime = ov.StartTime.In(s.loc)
+	o.EndTime = nil
+	if ov.EndTime.Valid {
+		end := ov.EndTime.Time.In(s.loc)
+		o.EndTime = &end
+	}
+	if ov.Title.Valid {
+		o.Title = ov.Title.String
+	}
+	if ov.Description.Valid {
+		o.Description = ov.Description.String
+	}
+	o.Overridden = true
+	return o
+}
+
+// expand lists the occurrences of e overlapping [from, to)
+func (s series) expand(e db.CalendarEvent, overrides []db.CalendarEventOverride, from, to time.Time) []Occurrence {
+	if s.rule == nil {
+		return []Occurrence{s.occurrence(e, s.start)}
+	}
+
+	replaced := make(map[int64]bool, len(overrides))
+	for _, o := range overrides {
+		replaced[o.OriginalStart.UnixNano()] = true
+	}
+
+	var out []Occurrence
+	for _, t := range s.rule.Between(s.start, from.Add(-s.duration), to) {
+		if !replaced[t.UnixNano()] {
+			out = append(out, s.occurrence(e, t))
+		}
+	}
+	for _, o := range overrides {
+		if !o.Cancelled && o.StartTime.Before(to) && !overrideEnd(o).Before(from) {
+			out = append(out, s.edited(e, o))
+		}
+	}
+	return out
+}
+
+// ExpandEvents lists the user's event occurrences overlapping [from, to),
+// recurring events expanded and their edits and cancellations applied,
+// ordered by start
+func (c *CalendarService) ExpandEvents(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]Occurrence, error) {
+	return expandEvents(ctx, c.db, userID, from, to)
+}
+
+// expandEvents is ExpandEvents on q, so a transaction sees its own writes
+func expandEvents(ctx context.Context, q *db.Queries, userID uuid.UUID, from, to time.Time) ([]Occurrence, error) {
+	if !to.After(from) || to.Sub(from) > MaxExpandWindow {
+		return nil, ErrInvalidWindow
+	}
+
+	events, err := q.GetEventsInRange(ctx, db.GetEventsInRangeParams{
+		UserID:     userID,
+		RangeStart: from,
+		RangeEnd:   to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var recurring []uuid.UUID
+	for _, e := range events {
+		if e.Rrule.Valid {
+			recurring = append(recurring, e.ID)
+		}
+	}
+	overrides := map[uuid.UUID][]db.CalendarEventOverride{}
+	if len(recurring) > 0 {
+		rows, err := q.ListEventOverrides(ctx, recurring)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range rows {
+			overrides[o.EventID] = append(overrides[o.EventID], o)
+		}
+	}
+
+	out := []Occurrence{}
+	for _, e := range events {
+		s, err := eventSeries(e)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		out = append(out, s.expand(e, overrides[e.ID], from, to)...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return compareOccurrence(occurrenceKey(out[i]), occurrenceKey(out[j])) < 0
+	})
+	return out, nil
+}
+
+// UpdateEvent edits every occurrence of an event. With an occurrence given,
+// start and end changes refer to that occurrence and shift the whole series
+// by as much. Changing a series' timing discards its edited and cancelled
+// occurrences, which no longer line up with it.
+func (c *CalendarService) UpdateEvent(ctx context.Context, e db.CalendarEvent, occurrence time.Time, ch EventChanges) (db.CalendarEvent, error) {
+	s, err := eventSeries(e)
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+	ref := s.start
+	if s.rule != nil && !occurrence.IsZero() {
+		if err := s.check(occurrence); err != nil {
+			return db.CalendarEvent{}, err
+		}
+		ref = occurrence
+	}
+	next, err := s.retime(ref, ch)
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+	title, description := ch.apply(e)
+
+	var updated db.CalendarEvent
+	err = c.withConflictCheck(ctx, ch.AllowConflict, func(q *db.Queries) (conflictTarget, error) {
+		var overrides []db.CalendarEventOverride
+		if next.rule == nil || !s.sameTiming(next) {
+			if err := q.DeleteEventOverrides(ctx, e.ID); err != nil {
+				return conflictTarget{}, err
+			}
+		} else if overrides, err = q.ListEventOverrides(ctx, []uuid.UUID{e.ID}); err != nil {
+			return conflictTarget{}, err
+		}
+		if updated, err = q.UpdateCalendarEvent(ctx, next.updateParams(e.ID, title, description, overrides)); err != nil {
+			return conflictTarget{}, err
+		}
+		return eventTarget(updated)
+	})
+	return updated, err
+}
+
+// UpdateOccurrence edits only the occurrence originally starting at
+// occurrence, leaving the rest of the series as it is
+func (c *CalendarService) UpdateOccurrence(ctx context.Context, e db.CalendarEvent, occurrence time.Time, ch EventChanges) (Occurrence, error) {
+	if ch.RRule != nil || ch.Timezone != nil {
+		return Occurrence{}, ErrRecurrenceScope
+	}
+	s, err := eventSeries(e)
+	if err != nil {
+		return Occurrence{}, err
+	}
+	if err := s.check(occurrence); err != nil {
+		return Occurrence{}, err
+	}
+
+	ov, err := c.db.GetEventOverride(ctx, db.GetEventOverrideParams{EventID: e.ID, OriginalStart: occurrence})
+	current := s.occurrence(e, occurrence)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		ov = db.CalendarEventOverride{EventID: e.ID, OriginalStart: occurrence}
+	case err != nil:
+		return Occurrence{}, err
+	case ov.Cancelled:
+		return Occurrence{}, ErrNotAnOccurrence
+	default:
+		current = s.edited(e, ov)
+	}
+
+	start := current.StartTime
+	if ch.StartTime != nil {
+		start = *ch.StartTime
+	}
+	var end sql.NullTime
+	switch {
+	case ch.EndTime != nil:
+		if ch.EndTime.Before(start) {
+			return Occurrence{}, ErrEndBeforeStart
+		}
+		end = sql.NullTime{Time: *ch.EndTime, Valid: true}
+	case current.EndTime != nil:
+		end = sql.NullTime{Time: start.Add(current.EndTime.Sub(current.StartTime)), Valid: true}
+	}
+	if ch.Title != nil {
+		ov.Title = sql.NullString{String: *ch.Title, Valid: true}
+	}
+	if ch.Description != nil {
+		ov.Description = sql.NullString{String: *ch.Description, Valid: true}
+	}
+
+	err = c.withConflictCheck(ctx, ch.AllowConflict, func(q *db.Queries) (conflictTarget, error) {
+		ov, err = q.UpsertEventOverride(ctx, db.UpsertEventOverrideParams{
+			EventID:       e.ID,
+			OriginalStart: occurrence,
+			Title:         ov.Title,
+			Description:   ov.Description,
+			StartTime:     start,
+			EndTime:       end,
+		})
+		if err != nil {
+			return conflictTarget{}, err
+		}
+		if err := q.ExtendSeriesEnd(ctx, db.ExtendSeriesEndParams{ID: e.ID, OccurrenceEnd: overrideEnd(ov)}); err != nil {
+			return conflictTarget{}, err
+		}
+		return occurrenceTarget(e, ov), nil
+	})
+	if err != nil {
+		return Occurrence{}, err
+	}
+	return s.edited(e, ov), nil
+}
+
+// UpdateFollowing edits the occurrence originally starting at occurrence and
+// every later one by ending the series before it and starting a new series
+// from it, which it returns. Later edited and cancelled occurrences move to
+// the new series unless its timing changes.
+func (c *CalendarService) UpdateFollowing(ctx context.Context, e db.CalendarEvent, occurrence time.Time, ch EventChanges) (db.CalendarEvent, error) {
+	s, err := eventSeries(e)
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+	if err := s.check(occurrence); err != nil {
+		return db.CalendarEvent{}, err
+	}
+	before := s.rule.CountBefore(s.start, occurrence)
+	if before == 0 {
+		return c.UpdateEvent(ctx, e, occurrence, ch)
+	}
+
+	head, tail := s.split(occurrence, before)
+	next, err := tail.retime(occurrence, ch)
+	if err != nil {
+		return db.CalendarEvent{}, err
+	}
+	keep := next.rule != nil && tail.sameTiming(next)
+	title, description := ch.apply(e)
+
+	var created db.CalendarEvent
+	err = c.withConflictCheck(ctx, ch.AllowConflict, func(q *db.Queries) (conflictTarget, error) {
+		overrides, err := q.ListEventOverrides(ctx, []uuid.UUID{e.ID})
+		if err != nil {
+			return conflictTarget{}, err
+		}
+		var earlier, later []db.CalendarEventOverride
+		for _, o := range overrides {
+			if o.OriginalStart.Before(occurrence) {
+				earlier = append(earlier, o)
+			} else if keep {
+				later = append(later, o)
+			}
+		}
+
+		created, err = q.CreateCalendarEvent(ctx, next.createParams(e.UserID, title, description, later))
+		if err != nil {
+			return conflictTarget{}, err
+		}
+		for _, o := range later {
+			if _, err := q.UpsertEventOverride(ctx, db.UpsertEventOverrideParams{
+				EventID:       created.ID,
+				OriginalStart: o.OriginalStart,
+				Title:         o.Title,
+				Description:   o.Description,
+				StartTime:     o.StartTime,
+				EndTime:       o.EndTime,
+				Cancelled:     o.Cancelled,
+			}); err != nil {
+				return conflictTarget{}, err
+			}
+		}
+
+		if err := q.DeleteEventOverridesFrom(ctx, db.DeleteEventOverridesFromParams{EventID: e.ID, OriginalStart: occurrence}); err != nil {
+			return conflictTarget{}, err
+		}
+		if _, err := q.UpdateCalendarEvent(ctx, head.updateParams(e.ID, e.Title, e.Description, earlier)); err != nil {
+			return conflictTarget{}, err
+		}
+		// Only the new series is checked; the head kept its old timing
+		return eventTarget(created)
+	})
+	return created, err
+}
+
+// DeleteOccurrence cancels the occurrence originally starting at occurrence
+func (c *CalendarService) DeleteOccurrence(ctx context.Context, e db.CalendarEvent, occurrence time.Time) error {
+	s, err := eventSeries(e)
+	if err != nil {
+		return err
+	}
+	if err := s.check(occurrence); err != nil {
+		return err
+	}
+	_, err = c.db.UpsertEventOverride(ctx, db.UpsertEventOverrideParams{
+		EventID:       e.ID,
+		OriginalStart: occurrence,
+		StartTime:     occurrence,
+		EndTime:       s.endOf(occurrence),
+		Cancelled:     true,
+	})
+	return err
+}
+
+// DeleteFollowing ends a series before the occurrence originally starting at
+// occurrence, deleting the event if that is its first
+func (c *CalendarService) DeleteFollowing(ctx context.Context, e db.CalendarEvent, occurrence time.Time) error {
+	s, err := eventSeries(e)
+	if err != nil {
+		return err
+	}
+	if err := s.check(occurrence); err != nil {
+		return err
+	}
+	before := s.rule.CountBefore(s.start, occurrence)
+	if before == 0 {
+		return c.DeleteEvent(ctx, e.ID)
+	}
+
+	head, _ := s.split(occurrence, before)
+	return c.withTx(ctx, func(q *db.Queries) error {
+		if err := q.DeleteEventOverridesFrom(ctx, db.DeleteEventOverridesFromParams{EventID: e.ID, OriginalStart: occurrence}); err != nil {
+			return err
+		}
+		earlier, err := q.ListEventOverrides(ctx, []uuid.UUID{e.ID})
+		if err != nil {
+			return err
+		}
+		_, err = q.UpdateCalendarEvent(ctx, head.updateParams(e.ID, e.Title, e.Description, earlier))
+		return err
+	})
+}
+
+// apply merges the title and description changes into e's
+func (ch EventChanges) apply(e db.CalendarEvent) (string, sql.NullString) {
+	title, description := e.Title, e.Description
+	if ch.Title != nil {
+		title = *ch.Title
+	}
+	if ch.Description != nil {
+		description = nullString(*ch.Description)
+	}
+	return title, description
+}
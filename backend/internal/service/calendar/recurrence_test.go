@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// occurrences expands a series in full, or its first 50 occurrences when it
+// is unbounded
+func occurrences(s series) []string {
+	var out []string
+	it := s.rule.Iter(s.start)
+	for t, ok := it.Next(); ok && len(out) < 50; t, ok = it.Next() {
+		out = append(out, t.Format(time.RFC3339))
+	}
+	return out
+}
+
+func TestSeriesSplit(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		rule      string
+		at        time.Time
+		headCount int
+		tailCount int
+		headLast  string
+		tailFirst string
+	}{
+		{
+			name:      "COUNT is shared between the halves",
+			start:     time.Date(2026, 1, 5, 9, 0, 0, 0, ny),
+			rule:      "FREQ=WEEKLY;COUNT=10",
+			at:        time.Date(2026, 1, 26, 9, 0, 0, 0, ny),
+			headCount: 3,
+			tailCount: 7,
+			headLast:  "2026-01-19T09:00:00-05:00",
+			tailFirst: "2026-01-26T09:00:00-05:00",
+		},
+		{
+			name:      "COUNT split after the spring forward",
+			start:     time.Date(2026, 3, 5, 9, 0, 0, 0, ny),
+			rule:      "FREQ=DAILY;COUNT=8",
+			at:        time.Date(2026, 3, 10, 9, 0, 0, 0, ny),
+			headCount: 5,
+			tailCount: 3,
+			headLast:  "2026-03-09T09:00:00-04:00",
+			tailFirst: "2026-03-10T09:00:00-04:00",
+		},
+		{
+			name:      "COUNT split with skipped short months",
+			start:     time.Date(2026, 1, 31, 8, 0, 0, 0, time.UTC),
+			rule:      "FREQ=MONTHLY;BYMONTHDAY=31;COUNT=5",
+			at:        time.Date(2026, 5, 31, 8, 0, 0, 0, time.UTC),
+			headCount: 2,
+			tailCount: 3,
+			headLast:  "2026-03-31T08:00:00Z",
+			tailFirst: "2026-05-31T08:00:00Z",
+		},
+		{
+			name:      "unbounded series ends with UNTIL",
+			start:     time.Date(2026, 1, 1, 18, 0, 0, 0, ny),
+			rule:      "FREQ=MONTHLY;BYDAY=-1FR",
+			at:        time.Date(2026, 3, 27, 18, 0, 0, 0, ny),
+			headLast:  "2026-02-27T18:00:00-05:00",
+			tailFirst: "2026-03-27T18:00:00-04:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newSeries(tt.start, tt.start.Add(time.Hour), tt.rule, tt.start.Location().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			whole, rule := occurrences(s), s.rule.String()
+			before := s.rule.CountBefore(s.start, tt.at)
+
+			head, tail := s.split(tt.at, before)
+
+			if head.rule.Count != tt.headCount || tail.rule.Count != tt.tailCount {
+				t.Errorf("COUNT = %d and %d, want %d and %d", head.rule.Count, tail.rule.Count, tt.headCount, tt.tailCount)
+			}
+			if s.rule.String() != rule {
+				t.Errorf("split changed the original rule to %q", s.rule.String())
+			}
+			if !tail.start.Equal(tt.at) || tail.start.Location() != s.loc {
+				t.Errorf("tail starts at %v, want %v in %v", tail.start, tt.at, s.loc)
+			}
+
+			h, tl := occurrences(head), occurrences(tail)
+			if len(h) == 0 || h[len(h)-1] != tt.headLast {
+				t.Errorf("head = %v, want it to end at %s", h, tt.headLast)
+			}
+			if len(tl) == 0 || tl[0] != tt.tailFirst {
+				t.Errorf("tail = %v, want it to start at %s", tl, tt.tailFirst)
+			}
+			if got := append(h, tl...); s.rule.Bounded() && !slices.Equal(got, whole) {
+				t.Errorf("halves = %v, want %v", got, whole)
+			}
+		})
+	}
+}
@@ -2,20 +2,38 @@ package calendar
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
 )
 
 type CalendarService struct {
-	db *db.Queries
+	conn *sql.DB
+	db   *db.Queries
 }
 
-func New(db *db.Queries) *CalendarService {
+func New(conn *sql.DB) *CalendarService {
 	return &CalendarService{
-		db: db,
+		conn: conn,
+		db:   db.New(conn),
 	}
 }
 
+// withTx runs fn on queries bound to one transaction, committing if it
+// returns nil
+func (c *CalendarService) withTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(c.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // Context wrapper for easy usage
 type ServiceContext struct {
 	context.Context
@@ -0,0 +1,142 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+)
+
+// DefaultChatLimit is how many chunks ground an answer unless asked otherwise
+const DefaultChatLimit = 6
+
+// MaxChatLimit caps the chunks a caller may ask for
+const MaxChatLimit = 20
+
+var ErrEmptyQuery = errors.New("query is required")
+
+var sourceMark = regexp.MustCompile(`\[(\d+)\]`)
+
+// Scope is the set of documents a chat searches. DocumentIDs takes
+// precedence over OwnerID, which narrows the search to one user's documents.
+// MissionID narrows it to a mission's attachments, which are left out of
+// every other search; with none of them set every other document is searched.
+type Scope struct {
+	DocumentIDs []uuid.UUID
+	OwnerID     uuid.NullUUID
+	MissionID   uuid.NullUUID
+}
+
+// Source is a chunk an answer was grounded on. Ref is its bracketed number
+// in the answer and Cited whether the answer used it.
+type Source struct {
+	Ref        int       `json:"ref"`
+	DocumentID uuid.UUID `json:"document_id"`
+	Title      string    `json:"title"`
+	ChunkIndex int32     `json:"chunk_index"`
+	Content    string    `json:"content"`
+	Distance   float64   `json:"distance"`
+	Cited      bool      `json:"cited"`
+}
+
+type ChatAnswer struct {
+	Answer  string   `json:"answer"`
+	Sources []Source `json:"sources"`
+}
+
+// Chat answers a question from the chunks nearest to it within scope
+func (s *DocumentService) Chat(ctx context.Context, scope Scope, query string, limit int) (*ChatAnswer, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	sources, err := s.search(ctx, scope, query, limit, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return &ChatAnswer{Answer: "No relevant briefing excerpts found.", Sources: []Source{}}, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("You are a mission analyst. Answer the question clearly using only the briefing excerpts below. " +
+		"Cite the excerpts supporting each sentence by their bracketed number, like [2]. " +
+		"If the excerpts do not answer the question, say so.\n\n")
+	fmt.Fprintf(&prompt, "Question: %s\n\nExcerpts:\n", query)
+	writeSources(&prompt, sources)
+
+	answer, err := s.generator.Generate(ctx, prompt.String())
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+	cited := citedRefs(answer)
+	for i := range sources {
+		sources[i].Cited = cited[sources[i].Ref]
+	}
+	return &ChatAnswer{Answer: answer, Sources: sources}, nil
+}
+
+// search embeds the query and returns the nearest chunks, numbered from
+// firstRef
+func (s *DocumentService) search(ctx context.Context, scope Scope, query string, limit, firstRef int) ([]Source, error) {
+	if limit <= 0 {
+		limit = DefaultChatLimit
+	}
+	vec, err := llm.EmbedOne(ctx, s.embedder, query)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+
+	params := db.SearchChunksParams{
+		Embedding:   retrieval.VectorLiteral(vec),
+		Model:       s.embedder.Model(),
+		DocumentIds: scope.DocumentIDs,
+		MissionID:   scope.MissionID,
+		Lim:         int32(limit),
+	}
+	if params.DocumentIds == nil {
+		params.DocumentIds = []uuid.UUID{}
+		params.OwnerID = scope.OwnerID
+	}
+	rows, err := s.queries.SearchChunks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]Source, len(rows))
+	for i, r := range rows {
+		sources[i] = Source{
+			Ref:        firstRef + i,
+			DocumentID: r.DocumentID.UUID,
+			Title:      r.Title.String,
+			ChunkIndex: r.ChunkIndex.Int32,
+			Content:    r.Content.String,
+			Distance:   r.Distance,
+		}
+	}
+	return sources, nil
+}
+
+func writeSources(b *strings.Builder, sources []Source) {
+	for _, src := range sources {
+		fmt.Fprintf(b, "[%d] %s, part %d:\n%s\n\n", src.Ref, src.Title, src.ChunkIndex+1, src.Content)
+	}
+}
+
+// citedRefs collects the bracketed source numbers an answer refers to
+func citedRefs(answer string) map[int]bool {
+	cited := map[int]bool{}
+	for _, m := range sourceMark.FindAllStringSubmatch(answer, -1) {
+		if ref, err := strconv.Atoi(m[1]); err == nil {
+			cited[ref] = true
+		}
+	}
+	return cited
+}
@@ -0,0 +1,168 @@
+package documents
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+var (
+	ErrUnsupportedType = errors.New("only text, markdown and PDF documents are supported")
+	ErrNoText          = errors.New("no text could be extracted from the document")
+)
+
+var (
+	spacePattern     = regexp.MustCompile(`[\t\v\f\x{00A0}]+`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// Kind names the extractor for a file from its extension, falling back to
+// its content type. It is empty for files that cannot be indexed.
+func Kind(filename, contentType string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt", ".text":
+		return "text/plain"
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".pdf":
+		return "application/pdf"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/plain":
+		return "text/plain"
+	case "text/markdown", "text/x-markdown":
+		return "text/markdown"
+	case "application/pdf":
+		return "application/pdf"
+	}
+	return ""
+}
+
+// Extract returns the plain text of a document
+func Extract(filename, contentType string, data []byte) (string, error) {
+	var text string
+	switch Kind(filename, contentType) {
+	case "text/plain", "text/markdown":
+		text = strings.ToValidUTF8(string(data), "")
+	case "application/pdf":
+		var err error
+		if text, err = pdfText(data); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupportedType
+	}
+
+	text = normalizeText(text)
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// pdfText joins the text of every page, separating pages with a blank line so
+// the splitter prefers to break between them. The PDF reader panics on some
+// malformed files, which is reported as an error instead.
+func pdfText(data []byte) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unreadable PDF: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("unreadable PDF: %w", err)
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	var pages []string
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				f := p.Font(name)
+				fonts[name] = &f
+			}
+		}
+		t, err := p.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("unreadable PDF page %d: %w", i, err)
+		}
+		pages = append(pages, t)
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// normalizeText matches the Python RAGService: unify line endings, collapse
+// odd whitespace and at most one blank line in a row
+func normalizeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.ReplaceAll(s, "\x00", "")
+	s = spacePattern.ReplaceAllString(s, " ")
+	s = blankLinePattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// Split cuts text into chunks of at most size characters, each starting
+// overlap characters before the previous one ended. Chunks end at a
+// paragraph, line or sentence break where one falls in their second half.
+func Split(text string, size, overlap int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			end = breakPoint(runes, start, end)
+		}
+		if c := strings.TrimSpace(string(runes[start:end])); c != "" {
+			chunks = append(chunks, c)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		// Start the overlap on a word boundary
+		for next < end && !isSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint finds where to end a chunk, preferring the latest paragraph
+// break, then line break, then sentence end, then space past the middle of
+// [start, end)
+func breakPoint(runes []rune, start, end int) int {
+	half := start + (end-start)/2
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		s := []rune(sep)
+		for i := end - len(s); i >= half; i-- {
+			if string(runes[i:i+len(s)]) == sep {
+				return i + len(s)
+			}
+		}
+	}
+	return end
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\n'
+}
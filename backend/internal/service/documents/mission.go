@@ -0,0 +1,192 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// AttachmentDir is where mission attachments are stored, relative to the
+// working directory like the rest of uploads
+const AttachmentDir = "uploads/mission_attachments"
+
+// missionLogBudget caps the characters of mission logs put in a prompt. The
+// most recent logs are kept when a mission has more.
+const missionLogBudget = 6000
+
+// LogSource is a mission log an answer was grounded on. Refs are shared with
+// the attachment sources, logs first.
+type LogSource struct {
+	Ref     int       `json:"ref"`
+	LogID   uuid.UUID `json:"log_id"`
+	LogDate time.Time `json:"log_date"`
+	Note    string    `json:"note"`
+	Cited   bool      `json:"cited"`
+}
+
+type MissionAnswer struct {
+	Answer  string      `json:"answer"`
+	Logs    []LogSource `json:"logs"`
+	Sources []Source    `json:"sources"`
+}
+
+// IndexAttachment extracts and embeds a mission attachment from disk. Files
+// that carry no text, such as images or scanned PDFs, are skipped without
+// error. Either way the attachment is marked indexed under the current model
+// so it is not read again. The document is kept apart from the owner's own
+// documents and only searched by AskMission.
+func (s *DocumentService) IndexAttachment(ctx context.Context, mission db.Mission, att db.MissionAttachment) error {
+	filename := filepath.Base(att.FileUrl)
+	if Kind(filename, att.FileType.String) != "" {
+		data, err := os.ReadFile(filepath.Join(AttachmentDir, filename))
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Ingest(ctx, mission.UserID, Upload{
+			Title:        fmt.Sprintf("%s: %s", mission.Title, filename),
+			Filename:     filename,
+			MimeType:     att.FileType.String,
+			Data:         data,
+			MissionID:    uuid.NullUUID{UUID: mission.ID, Valid: true},
+			AttachmentID: uuid.NullUUID{UUID: att.ID, Valid: true},
+		})
+		if err != nil && !errors.Is(err, ErrNoText) {
+			return err
+		}
+	}
+
+	return s.queries.MarkAttachmentIndexed(ctx, db.MarkAttachmentIndexedParams{
+		Model: s.embedder.Model(),
+		ID:    att.ID,
+	})
+}
+
+// IndexMissionAttachments indexes the mission's attachments that are not yet
+// embedded with the current model. A failing attachment is logged and left
+// for the next call.
+func (s *DocumentService) IndexMissionAttachments(ctx context.Context, mission db.Mission) error {
+	pending, err := s.queries.ListUnindexedMissionAttachments(ctx, db.ListUnindexedMissionAttachmentsParams{
+		MissionID: mission.ID,
+		Model:     s.embedder.Model(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, att := range pending {
+		if err := s.IndexAttachment(ctx, mission, att); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("documents: attachment %s of mission %s: %v", att.ID, mission.ID, err)
+		}
+	}
+	return nil
+}
+
+// AskMission answers a question from a mission's details, its logs and the
+// attachment excerpts nearest to the question, and nothing else
+func (s *DocumentService) AskMission(ctx context.Context, mission db.Mission, query string, limit int) (*MissionAnswer, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if err := s.IndexMissionAttachments(ctx, mission); err != nil {
+		return nil, fmt.Errorf("failed to index attachments: %w", err)
+	}
+
+	logs, err := s.queries.GetLogsByMission(ctx, mission.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+	logSources := recentLogs(logs, missionLogBudget)
+
+	sources, err := s.search(ctx, Scope{MissionID: uuid.NullUUID{UUID: mission.ID, Valid: true}}, query, limit, len(logSources)+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(logSources) == 0 && len(sources) == 0 {
+		return &MissionAnswer{
+			Answer:  "This mission has no logs or text attachments to answer from yet.",
+			Logs:    logSources,
+			Sources: sources,
+		}, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("You are a mission analyst. Answer the question about the mission below using only its details, " +
+		"logs and attachment excerpts. Cite the logs and excerpts supporting each sentence by their bracketed number, " +
+		"like [2]. If they do not answer the question, say so.\n\n")
+	fmt.Fprintf(&prompt, "Question: %s\n\n", query)
+	writeMission(&prompt, mission)
+	if len(logSources) > 0 {
+		prompt.WriteString("\nLogs:\n")
+		for _, l := range logSources {
+			fmt.Fprintf(&prompt, "[%d] %s: %s\n", l.Ref, l.LogDate.UTC().Format(time.RFC3339), l.Note)
+		}
+	}
+	if len(sources) > 0 {
+		prompt.WriteString("\nAttachment excerpts:\n")
+		writeSources(&prompt, sources)
+	}
+
+	answer, err := s.generator.Generate(ctx, prompt.String())
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+
+	cited := citedRefs(answer)
+	for i := range logSources {
+		logSources[i].Cited = cited[logSources[i].Ref]
+	}
+	for i := range sources {
+		sources[i].Cited = cited[sources[i].Ref]
+	}
+	return &MissionAnswer{Answer: answer, Logs: logSources, Sources: sources}, nil
+}
+
+// recentLogs keeps the latest logs fitting in budget characters, in
+// chronological order and numbered from 1
+func recentLogs(logs []db.MissionLog, budget int) []LogSource {
+	first := len(logs)
+	for used := 0; first > 0; first-- {
+		used += len(logs[first-1].Note)
+		if used > budget && first < len(logs) {
+			break
+		}
+	}
+
+	out := make([]LogSource, 0, len(logs)-first)
+	for i, l := range logs[first:] {
+		out = append(out, LogSource{Ref: i + 1, LogID: l.ID, LogDate: l.LogDate, Note: l.Note})
+	}
+	return out
+}
+
+func writeMission(b *strings.Builder, m db.Mission) {
+	fmt.Fprintf(b, "Mission: %s\n", m.Title)
+	if m.Description.Valid {
+		fmt.Fprintf(b, "Description: %s\n", m.Description.String)
+	}
+	if m.MissionType.Valid {
+		fmt.Fprintf(b, "Type: %s\n", m.MissionType.MissionTypeEnum)
+	}
+	if m.ThreatLevel.Valid {
+		fmt.Fprintf(b, "Threat level: %s\n", m.ThreatLevel.ThreatLevelEnum)
+	}
+	fmt.Fprintf(b, "Starts: %s\n", m.StartTime.UTC().Format(time.RFC3339))
+	if m.EndTime.Valid {
+		fmt.Fprintf(b, "Ends: %s\n", m.EndTime.Time.UTC().Format(time.RFC3339))
+	}
+	if m.Success.Valid {
+		fmt.Fprintf(b, "Succeeded: %t\n", m.Success.Bool)
+	}
+}
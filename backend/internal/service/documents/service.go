@@ -0,0 +1,251 @@
+// Package documents ingests briefings into the documents and chunks tables
+// and answers questions over them. It replaces the Python RAGService's
+// upload and chat endpoints inside the Go API's auth domain.
+package documents
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+)
+
+var ErrDocumentNotFound = errors.New("document not found")
+
+// embedBatch is how many chunks are embedded per provider call
+const embedBatch = 32
+
+type Document struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	Filename       string     `json:"filename"`
+	MimeType       string     `json:"mime_type"`
+	OwnerID        *uuid.UUID `json:"owner_id,omitempty"`
+	SizeBytes      int64      `json:"size_bytes"`
+	ChunkCount     int32      `json:"chunk_count"`
+	EmbeddingModel string     `json:"embedding_model,omitempty"`
+	MissionID      *uuid.UUID `json:"mission_id,omitempty"`
+	AttachmentID   *uuid.UUID `json:"attachment_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Upload is a file to ingest. Title defaults to the filename. A mission
+// attachment carries its mission and attachment IDs and replaces any earlier
+// document indexed from the same attachment.
+type Upload struct {
+	Title        string
+	Filename     string
+	MimeType     string
+	Data         []byte
+	MissionID    uuid.NullUUID
+	AttachmentID uuid.NullUUID
+}
+
+type DocumentService struct {
+	db           *sql.DB
+	queries      *db.Queries
+	embedder     llm.Embedder
+	generator    llm.Generator
+	chunkSize    int
+	chunkOverlap int
+}
+
+// New builds the service. DOCUMENT_CHUNK_SIZE and DOCUMENT_CHUNK_OVERLAP
+// set the chunk length and overlap in characters, defaulting to the Python
+// RAGService's 1400 and 200.
+func New(sqlDB *sql.DB, embedder llm.Embedder, generator llm.Generator) (*DocumentService, error) {
+	size, err := intEnv("DOCUMENT_CHUNK_SIZE", 1400)
+	if err != nil {
+		return nil, err
+	}
+	overlap, err := intEnv("DOCUMENT_CHUNK_OVERLAP", 200)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 || overlap < 0 || overlap >= size {
+		return nil, fmt.Errorf("DOCUMENT_CHUNK_OVERLAP (%d) must be below DOCUMENT_CHUNK_SIZE (%d)", overlap, size)
+	}
+
+	return &DocumentService{
+		db:           sqlDB,
+		queries:      db.New(sqlDB),
+		embedder:     embedder,
+		generator:    generator,
+		chunkSize:    size,
+		chunkOverlap: overlap,
+	}, nil
+}
+
+// Ingest extracts, chunks and embeds a file and stores it for ownerID. The
+// document and its chunks are written in one transaction, so a failed upload
+// leaves nothing behind.
+func (s *DocumentService) Ingest(ctx context.Context, ownerID uuid.UUID, up Upload) (Document, error) {
+	text, err := Extract(up.Filename, up.MimeType, up.Data)
+	if err != nil {
+		return Document{}, err
+	}
+	chunks := Split(text, s.chunkSize, s.chunkOverlap)
+	if len(chunks) == 0 {
+		return Document{}, ErrNoText
+	}
+
+	vectors := make([][]float32, 0, len(chunks))
+	for i := 0; i < len(chunks); i += embedBatch {
+		batch, err := s.embedder.Embed(ctx, chunks[i:min(i+embedBatch, len(chunks))])
+		if err != nil {
+			return Document{}, fmt.Errorf("embedding failed: %w", err)
+		}
+		vectors = append(vectors, batch...)
+	}
+	if len(vectors) != len(chunks) {
+		return Document{}, fmt.Errorf("embedding returned %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+
+	title := strings.TrimSpace(up.Title)
+	if title == "" {
+		title = up.Filename
+	}
+	mimeType := up.MimeType
+	if mimeType == "" {
+		mimeType = Kind(up.Filename, "")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Document{}, err
+	}
+	defer tx.Rollback()
+	qtx := s.queries.WithTx(tx)
+
+	if up.AttachmentID.Valid {
+		if err := qtx.DeleteAttachmentDocument(ctx, up.AttachmentID); err != nil {
+			return Document{}, fmt.Errorf("failed to replace document: %w", err)
+		}
+	}
+
+	row, err := qtx.CreateDocument(ctx, db.CreateDocumentParams{
+		Title:          nullString(title),
+		Filename:       nullString(up.Filename),
+		MimeType:       nullString(mimeType),
+		OwnerID:        uuid.NullUUID{UUID: ownerID, Valid: true},
+		SizeBytes:      int64(len(up.Data)),
+		ChunkCount:     int32(len(chunks)),
+		EmbeddingModel: nullString(s.embedder.Model()),
+		MissionID:      up.MissionID,
+		AttachmentID:   up.AttachmentID,
+		DocEmbedding:   retrieval.VectorLiteral(average(vectors)),
+	})
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	for i, chunk := range chunks {
+		if err := qtx.InsertChunk(ctx, db.InsertChunkParams{
+			DocumentID: uuid.NullUUID{UUID: row.ID, Valid: true},
+			ChunkIndex: sql.NullInt32{Int32: int32(i), Valid: true},
+			Content:    nullString(chunk),
+			Embedding:  retrieval.VectorLiteral(vectors[i]),
+		}); err != nil {
+			return Document{}, fmt.Errorf("failed to store chunk %d: %w", i, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Document{}, err
+	}
+	return toDocument(db.GetDocumentByIDRow(row)), nil
+}
+
+// List returns the documents owned by ownerID, or every document if ownerID
+// is not valid. Documents indexed from mission attachments are left out of
+// List, Get and Delete; they are only reached through their mission.
+func (s *DocumentService) List(ctx context.Context, ownerID uuid.NullUUID) ([]Document, error) {
+	rows, err := s.queries.ListDocuments(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]Document, len(rows))
+	for i, r := range rows {
+		docs[i] = toDocument(db.GetDocumentByIDRow(r))
+	}
+	return docs, nil
+}
+
+func (s *DocumentService) Get(ctx context.Context, id uuid.UUID) (Document, error) {
+	row, err := s.queries.GetDocumentByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Document{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return Document{}, err
+	}
+	return toDocument(row), nil
+}
+
+// Delete removes a document; its chunks go with it
+func (s *DocumentService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.queries.DeleteDocument(ctx, id)
+}
+
+func toDocument(r db.GetDocumentByIDRow) Document {
+	doc := Document{
+		ID:             r.ID,
+		Title:          r.Title.String,
+		Filename:       r.Filename.String,
+		MimeType:       r.MimeType.String,
+		SizeBytes:      r.SizeBytes,
+		ChunkCount:     r.ChunkCount,
+		EmbeddingModel: r.EmbeddingModel.String,
+		CreatedAt:      r.CreatedAt.Time,
+	}
+	if r.OwnerID.Valid {
+		doc.OwnerID = &r.OwnerID.UUID
+	}
+	if r.MissionID.Valid {
+		doc.MissionID = &r.MissionID.UUID
+	}
+	if r.AttachmentID.Valid {
+		doc.AttachmentID = &r.AttachmentID.UUID
+	}
+	return doc
+}
+
+// average is the document vector, the mean of its chunk vectors as the
+// Python RAGService computes it
+func average(vectors [][]float32) []float32 {
+	out := make([]float32, len(vectors[0]))
+	for _, v := range vectors {
+		for i, x := range v {
+			out[i] += x
+		}
+	}
+	for i := range out {
+		out[i] /= float32(len(vectors))
+	}
+	return out
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func intEnv(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
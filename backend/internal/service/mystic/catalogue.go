@@ -0,0 +1,316 @@
+package mystic
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/sqlc-dev/pqtype"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+const (
+	defaultCatalogueLimit = 20
+	maxCatalogueLimit     = 100
+)
+
+// CatalogueSorts are the orders the catalogue can be listed in; a leading
+// "-" sorts descending
+var CatalogueSorts = []string{"title", "-title", "pageid", "-pageid"}
+
+type Spell struct {
+	Pageid              int32                 `json:"pageid"`
+	Title               string                `json:"title"`
+	Url                 string                `json:"url"`
+	Summary             string                `json:"summary"`
+	ImageURL            *string               `json:"image_url,omitempty"`
+	Categories          []string              `json:"categories"`
+	Realities           []string              `json:"realities"`
+	Origin              string                `json:"origin"`
+	PowerClass          string                `json:"power_class"`
+	AccessLevel         int16                 `json:"access_level"`
+	Aliases             []string              `json:"aliases"`
+	UsedByDoctorStrange bool                  `json:"used_by_doctor_strange"`
+	Infobox             pqtype.NullRawMessage `json:"infobox"`
+}
+
+// CatalogueQuery selects a page of the catalogue. Realities and Categories
+// match spells sharing any of the values; Alias matches part of any alias.
+type CatalogueQuery struct {
+	Limit               int
+	Cursor              string
+	Sort                string
+	Realities           []string
+	Categories          []string
+	PowerClass          string
+	Origin              string
+	Alias               string
+	UsedByDoctorStrange *bool
+}
+
+type Facet struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facets count the matching spells per reality and category. Each list
+// ignores its own filter, so a sidebar can show how many spells selecting
+// another value would add.
+type Facets struct {
+	Realities  []Facet `json:"realities"`
+	Categories []Facet `json:"categories"`
+}
+
+type CataloguePage struct {
+	Spells     []Spell `json:"spells"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	Total      int64   `json:"total"`
+	Facets     Facets  `json:"facets"`
+}
+
+// catalogueCursor is the sort key of the last spell on a page
+type catalogueCursor struct {
+	Sort   string `json:"s"`
+	Title  string `json:"t,omitempty"`
+	PageID int32  `json:"p"`
+}
+
+// ListSpells returns a page of the spells the caller is cleared to see
+func (s *SearchService) ListSpells(ctx context.Context, caller Caller, q CatalogueQuery) (*CataloguePage, error) {
+	if q.Sort == "" {
+		q.Sort = "title"
+	}
+	if !slices.Contains(CatalogueSorts, q.Sort) {
+		return nil, ErrInvalidSort
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultCatalogueLimit
+	}
+	q.Limit = min(q.Limit, maxCatalogueLimit)
+
+	filters := db.CountSpellCatalogueParams{
+		Clearance:           caller.Clearance,
+		Realities:           nonNil(q.Realities),
+		Categories:          nonNil(q.Categories),
+		PowerClass:          sql.NullString{String: q.PowerClass, Valid: q.PowerClass != ""},
+		Origin:              sql.NullString{String: q.Origin, Valid: q.Origin != ""},
+		Alias:               sql.NullString{String: q.Alias, Valid: q.Alias != ""},
+		UsedByDoctorStrange: nullBool(q.UsedByDoctorStrange),
+	}
+
+	params := db.ListSpellCatalogueParams{
+		Clearance:           filters.Clearance,
+		Realities:           filters.Realities,
+		Categories:          filters.Categories,
+		PowerClass:          filters.PowerClass,
+		Origin:              filters.Origin,
+		Alias:               filters.Alias,
+		UsedByDoctorStrange: filters.UsedByDoctorStrange,
+		Sort:                q.Sort,
+		// One extra row tells whether there is a next page
+		Lim: int32(q.Limit + 1),
+	}
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil || cursor.Sort != q.Sort {
+			return nil, ErrInvalidCursor
+		}
+		params.AfterPageid = sql.NullInt32{Int32: cursor.PageID, Valid: true}
+		params.AfterTitle = sql.NullString{String: cursor.Title, Valid: true}
+	}
+
+	rows, err := s.db.ListSpellCatalogue(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("db query failed: %w", err)
+	}
+
+	page := &CataloguePage{Spells: make([]Spell, 0, min(len(rows), q.Limit))}
+	for i, r := range rows {
+		if i == q.Limit {
+			page.NextCursor = encodeCursor(catalogueCursor{Sort: q.Sort, Title: rows[i-1].Title, PageID: rows[i-1].Pageid})
+			break
+		}
+		page.Spells = append(page.Spells, Spell{
+			Pageid:              r.Pageid,
+			Title:               r.Title,
+			Url:                 r.Url,
+			Summary:             r.Summary.String,
+			ImageURL:            stringPtr(r.ImageUrl),
+			Categories:          r.Categories,
+			Realities:           r.Realities,
+			Origin:              r.Origin.String,
+			PowerClass:          r.PowerClass.String,
+			AccessLevel:         r.AccessLevel,
+			Aliases:             r.Aliases,
+			UsedByDoctorStrange: r.UsedByDoctorStrange,
+			Infobox:             r.Infobox,
+		})
+	}
+
+	if page.Total, err = s.db.CountSpellCatalogue(ctx, filters); err != nil {
+		return nil, fmt.Errorf("db query failed: %w", err)
+	}
+
+	realityFilters := filters
+	realityFilters.Realities = []string{}
+	realities, err := s.db.CountSpellRealityFacets(ctx, db.CountSpellRealityFacetsParams(realityFilters))
+	if err != nil {
+		return nil, fmt.Errorf("db query failed: %w", err)
+	}
+	page.Facets.Realities = make([]Facet, len(realities))
+	for i, f := range realities {
+		page.Facets.Realities[i] = Facet(f)
+	}
+
+	categoryFilters := filters
+	categoryFilters.Categories = []string{}
+	categories, err := s.db.CountSpellCategoryFacets(ctx, db.CountSpellCategoryFacetsParams(categoryFilters))
+	if err != nil {
+		return nil, fmt.Errorf("db query failed: %w", err)
+	}
+	page.Facets.Categories = make([]Facet, len(categories))
+	for i, f := range categories {
+		page.Facets.Categories[i] = Facet(f)
+	}
+
+	return page, nil
+}
+
+type SpellSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type SpellLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// SpellDetail is the full record of a spell
+type SpellDetail struct {
+	Pageid              int32                 `json:"pageid"`
+	Title               string                `json:"title"`
+	Url                 string                `json:"url"`
+	Summary             string                `json:"summary"`
+	ImageURL            *string               `json:"image_url,omitempty"`
+	Categories          []string              `json:"categories"`
+	Realities           []string              `json:"realities"`
+	FirstAppearance     string                `json:"first_appearance,omitempty"`
+	Aliases             []string              `json:"aliases"`
+	Origin              string                `json:"origin"`
+	PowerClass          string                `json:"power_class"`
+	AccessLevel         int16                 `json:"access_level"`
+	UsedByDoctorStrange bool                  `json:"used_by_doctor_strange"`
+	Infobox             pqtype.NullRawMessage `json:"infobox"`
+	Sections            []SpellSection        `json:"sections"`
+	Outlinks            []SpellLink           `json:"outlinks"`
+	PageRevID           *int64                `json:"page_rev_id,omitempty"`
+	LastRevTs           *time.Time            `json:"last_rev_ts,omitempty"`
+	LastFetchedAt       time.Time             `json:"last_fetched_at"`
+}
+
+// GetSpell returns a spell's full record. A spell above the caller's
+// clearance is reported as not found and raises the same alert as a
+// restricted search match.
+func (s *SearchService) GetSpell(ctx context.Context, caller Caller, pageid int32) (*SpellDetail, error) {
+	r, err := s.db.GetSpellByPageID(ctx, pageid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSpellNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db query failed: %w", err)
+	}
+
+	if r.AccessLevel > caller.Clearance {
+		s.raiseRestrictedAlerts(ctx, caller, []SearchResult{{
+			PageID:           r.Pageid,
+			Title:            r.Title,
+			AccessLevel:      r.AccessLevel,
+			RestrictedReason: r.RestrictedReason.String,
+		}})
+		return nil, ErrSpellNotFound
+	}
+
+	spell := &SpellDetail{
+		Pageid:              r.Pageid,
+		Title:               r.Title,
+		Url:                 r.Url,
+		Summary:             r.Summary.String,
+		ImageURL:            stringPtr(r.ImageUrl),
+		Categories:          r.Categories,
+		Realities:           r.Realities,
+		FirstAppearance:     r.FirstAppearance.String,
+		Aliases:             r.Aliases,
+		Origin:              r.Origin.String,
+		PowerClass:          r.PowerClass.String,
+		AccessLevel:         r.AccessLevel,
+		UsedByDoctorStrange: r.UsedByDoctorStrange,
+		Infobox:             r.Infobox,
+		Sections:            []SpellSection{},
+		Outlinks:            []SpellLink{},
+		LastFetchedAt:       r.LastFetchedAt,
+	}
+	if r.Sections.Valid {
+		if err := json.Unmarshal(r.Sections.RawMessage, &spell.Sections); err != nil {
+			return nil, fmt.Errorf("invalid sections for spell %d: %w", pageid, err)
+		}
+	}
+	if r.Outlinks.Valid {
+		if err := json.Unmarshal(r.Outlinks.RawMessage, &spell.Outlinks); err != nil {
+			return nil, fmt.Errorf("invalid outlinks for spell %d: %w", pageid, err)
+		}
+	}
+	if r.PageRevID.Valid {
+		spell.PageRevID = &r.PageRevID.Int64
+	}
+	if r.LastRevTs.Valid {
+		spell.LastRevTs = &r.LastRevTs.Time
+	}
+	return spell, nil
+}
+
+func encodeCursor(c catalogueCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (catalogueCursor, error) {
+	var c catalogueCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,291 @@
+package mystic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Citation is a footnote pointing at a retrieved spell. Index is the spell's
+// position in the results.
+type Citation struct {
+	Footnote int    `json:"footnote"`
+	Index    int    `json:"index"`
+	PageID   int32  `json:"pageid"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+}
+
+// AnswerSegment is a sentence or two of an answer with the footnotes of the
+// spells supporting it
+type AnswerSegment struct {
+	Text      string `json:"text"`
+	Footnotes []int  `json:"footnotes"`
+	// Unsupported is set when no retrieved spell backs the segment
+	Unsupported bool `json:"unsupported,omitempty"`
+	// RejectedPageIDs are ids the model cited that were not retrieved
+	RejectedPageIDs []int32 `json:"rejected_pageids,omitempty"`
+}
+
+// VerifiedAnswer is an answer whose citations were all checked against the
+// retrieved spells. Answer is the segment texts with footnote marks.
+type VerifiedAnswer struct {
+	Answer    string          `json:"answer"`
+	Segments  []AnswerSegment `json:"segments"`
+	Citations []Citation      `json:"citations"`
+}
+
+// structuredAnswerFormat asks for the segments generateCited verifies
+const structuredAnswerFormat = `Reply with a JSON object of the form {"segments": [{"text": "...", "pageids": [123]}]}. ` +
+	"Split the answer into segments of one or two sentences. In pageids list the bracketed ids of the retrieved spells " +
+	"that support the segment, and leave it empty if none do. Never cite an id that is not listed above."
+
+var citationMark = regexp.MustCompile(`\s*\[(\d+)\]`)
+
+// footnotes numbers the retrieved spells in the order they are first cited
+type footnotes struct {
+	results   []SearchResult
+	index     map[int32]int
+	numbers   map[int32]int
+	citations []Citation
+}
+
+func newFootnotes(results []SearchResult) *footnotes {
+	f := &footnotes{
+		results:   results,
+		index:     make(map[int32]int, len(results)),
+		numbers:   map[int32]int{},
+		citations: []Citation{},
+	}
+	for i, r := range results {
+		if _, ok := f.index[r.PageID]; !ok {
+			f.index[r.PageID] = i
+		}
+	}
+	return f
+}
+
+// cite returns the footnote for a pageid, or false if it was not retrieved
+func (f *footnotes) cite(pageid int32) (int, bool) {
+	if n, ok := f.numbers[pageid]; ok {
+		return n, true
+	}
+	i, ok := f.index[pageid]
+	if !ok {
+		return 0, false
+	}
+
+	r := f.results[i]
+	n := len(f.citations) + 1
+	f.numbers[pageid] = n
+	f.citations = append(f.citations, Citation{Footnote: n, Index: i, PageID: r.PageID, Title: r.Title, URL: r.URL})
+	return n, true
+}
+
+// generateCited asks for a structured answer and keeps only citations of
+// retrieved spells. Segments left without support are flagged rather than
+// dropped, so the caller can show them with a warning.
+func (s *SearchService) generateCited(ctx context.Context, prompt string, results []SearchResult) (*VerifiedAnswer, error) {
+	raw, err := s.generator.GenerateJSON(ctx, prompt+"\n"+structuredAnswerFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := verifySegments(raw, results)
+	if err != nil {
+		if strings.HasPrefix(stripCodeFence(raw), "{") {
+			return nil, fmt.Errorf("unusable structured answer: %w", err)
+		}
+		// A model that ignored the format still answered; fall back to the
+		// ids it wrote inline
+		log.Printf("structured answer: %v", err)
+		return verifyText(raw, results), nil
+	}
+	return answer, nil
+}
+
+type rawSegment struct {
+	Text    string  `json:"text"`
+	PageIDs []int64 `json:"pageids"`
+}
+
+// decodeSegments reads the segments of a structured answer. A reply cut off
+// part way, as by a token limit, keeps the segments before the cut.
+func decodeSegments(raw string) ([]rawSegment, error) {
+	raw = stripCodeFence(raw)
+	var out struct {
+		Segments []rawSegment `json:"segments"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		if segments := scanSegments(raw); len(segments) > 0 {
+			log.Printf("structured answer: %v, kept %d segments", err, len(segments))
+			return segments, nil
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return out.Segments, nil
+}
+
+// scanSegments reads segments token by token until the JSON breaks off
+func scanSegments(raw string) []rawSegment {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	var (
+		segments []rawSegment
+		depth    int    // object nesting; segments are at 2
+		key      string // segment key awaiting its value
+		array    string // segment key of the array being read
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return segments
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{':
+				depth++
+				if depth == 2 {
+					segments = append(segments, rawSegment{})
+					key = ""
+				}
+			case '}':
+				depth--
+			case '[':
+				if depth == 2 {
+					array, key = key, ""
+				}
+			case ']':
+				if depth == 2 {
+					array = ""
+				}
+			}
+			continue
+		}
+		if depth != 2 {
+			continue
+		}
+
+		seg := &segments[len(segments)-1]
+		switch {
+		case array != "":
+			if id, ok := tok.(float64); ok && array == "pageids" {
+				seg.PageIDs = append(seg.PageIDs, int64(id))
+			}
+		case key == "":
+			key, _ = tok.(string)
+		default:
+			if text, ok := tok.(string); ok && key == "text" {
+				seg.Text = text
+			}
+			key = ""
+		}
+	}
+}
+
+func verifySegments(raw string, results []SearchResult) (*VerifiedAnswer, error) {
+	segments, err := decodeSegments(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := newFootnotes(results)
+	answer := &VerifiedAnswer{Segments: make([]AnswerSegment, 0, len(segments))}
+	var text strings.Builder
+	for _, seg := range segments {
+		segText := strings.TrimSpace(citationMark.ReplaceAllString(seg.Text, ""))
+		if segText == "" {
+			continue
+		}
+
+		segment := AnswerSegment{Text: segText, Footnotes: []int{}}
+		for _, id := range seg.PageIDs {
+			if n, ok := notes.cite(int32(id)); ok {
+				if !slices.Contains(segment.Footnotes, n) {
+					segment.Footnotes = append(segment.Footnotes, n)
+				}
+			} else {
+				segment.RejectedPageIDs = append(segment.RejectedPageIDs, int32(id))
+			}
+		}
+		segment.Unsupported = len(segment.Footnotes) == 0
+		answer.Segments = append(answer.Segments, segment)
+
+		if text.Len() > 0 {
+			text.WriteString(" ")
+		}
+		text.WriteString(segText)
+		for _, n := range segment.Footnotes {
+			fmt.Fprintf(&text, " [%d]", n)
+		}
+	}
+	if len(answer.Segments) == 0 {
+		return nil, fmt.Errorf("no segments")
+	}
+
+	answer.Answer = text.String()
+	answer.Citations = notes.citations
+	return answer, nil
+}
+
+// verifyText treats a plain answer as one segment cited by the bracketed
+// pageids it contains. In the answer each accepted id is rewritten to its
+// footnote and rejected ones are dropped.
+func verifyText(raw string, results []SearchResult) *VerifiedAnswer {
+	notes := newFootnotes(results)
+	segment := AnswerSegment{Footnotes: []int{}}
+	answer := citationMark.ReplaceAllStringFunc(raw, func(mark string) string {
+		id, err := strconv.ParseInt(citationMark.FindStringSubmatch(mark)[1], 10, 32)
+		if err != nil {
+			return ""
+		}
+		n, ok := notes.cite(int32(id))
+		if !ok {
+			segment.RejectedPageIDs = append(segment.RejectedPageIDs, int32(id))
+			return ""
+		}
+		if !slices.Contains(segment.Footnotes, n) {
+			segment.Footnotes = append(segment.Footnotes, n)
+		}
+		return fmt.Sprintf(" [%d]", n)
+	})
+	segment.Text = strings.TrimSpace(citationMark.ReplaceAllString(raw, ""))
+	segment.Unsupported = len(segment.Footnotes) == 0
+
+	return &VerifiedAnswer{
+		Answer:    strings.TrimSpace(answer),
+		Segments:  []AnswerSegment{segment},
+		Citations: notes.citations,
+	}
+}
+
+// citedSpells returns the retrieved spells a streamed answer cites by
+// bracketed pageid, ignoring ids that were not retrieved. Answers without
+// any ids fall back to the spells they mention by title.
+func citedSpells(answer string, results []SearchResult) []Citation {
+	verified := verifyText(answer, results)
+	if len(verified.Citations) > 0 {
+		return verified.Citations
+	}
+
+	notes := newFootnotes(results)
+	lower := strings.ToLower(answer)
+	for _, r := range results {
+		if r.Title != "" && strings.Contains(lower, strings.ToLower(r.Title)) {
+			notes.cite(r.PageID)
+		}
+	}
+	return notes.citations
+}
+
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,51 @@
+package mystic
+
+import (
+	"slices"
+	"testing"
+)
+
+var citedResults = []SearchResult{
+	{PageID: 101, Title: "Eye of Agamotto"},
+	{PageID: 202, Title: "Crimson Bands of Cyttorak"},
+}
+
+func TestVerifyTextRewritesMarks(t *testing.T) {
+	got := verifyText("The Eye bends time [202]. It is an amulet [101][999]. Bands bind [202].", citedResults)
+
+	if want := "The Eye bends time [1]. It is an amulet [2]. Bands bind [1]."; got.Answer != want {
+		t.Errorf("Answer = %q, want %q", got.Answer, want)
+	}
+	if len(got.Citations) != 2 || got.Citations[0].PageID != 202 || got.Citations[1].PageID != 101 {
+		t.Errorf("Citations = %+v, want 202 then 101", got.Citations)
+	}
+	seg := got.Segments[0]
+	if want := "The Eye bends time. It is an amulet. Bands bind."; seg.Text != want {
+		t.Errorf("segment Text = %q, want %q", seg.Text, want)
+	}
+	if !slices.Equal(seg.Footnotes, []int{1, 2}) || !slices.Equal(seg.RejectedPageIDs, []int32{999}) {
+		t.Errorf("segment = %+v, want footnotes [1 2] and rejected [999]", seg)
+	}
+}
+
+func TestVerifySegmentsKeepsSegmentsBeforeTruncation(t *testing.T) {
+	raw := "```json\n" + `{"segments": [{"text": "The Eye bends time.", "pageids": [101]}, ` +
+		`{"pageids": [202], "text": "The Bands bind."}, {"text": "The Bands are unbre`
+
+	got, err := verifySegments(raw, citedResults)
+	if err != nil {
+		t.Fatalf("verifySegments: %v", err)
+	}
+	if want := "The Eye bends time. [1] The Bands bind. [2]"; got.Answer != want {
+		t.Errorf("Answer = %q, want %q", got.Answer, want)
+	}
+	if len(got.Segments) != 2 {
+		t.Errorf("got %d segments, want 2", len(got.Segments))
+	}
+}
+
+func TestVerifySegmentsRejectsPlainText(t *testing.T) {
+	if _, err := verifySegments("The Eye bends time [101].", citedResults); err == nil {
+		t.Error("verifySegments accepted plain text")
+	}
+}
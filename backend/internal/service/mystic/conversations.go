@@ -0,0 +1,279 @@
+package mystic
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+)
+
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// historyTurns is how many earlier messages are used to rewrite a follow-up
+// question and to ground its answer
+const historyTurns = 6
+
+type ConversationMessage struct {
+	ID             uuid.UUID `json:"id"`
+	Role           string    `json:"role"`
+	Content        string    `json:"content"`
+	RetrievalQuery string    `json:"retrieval_query,omitempty"`
+	CitedPageIDs   []int32   `json:"cited_pageids"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ConversationReply struct {
+	RetrievalQuery string          `json:"retrieval_query"`
+	Answer         string          `json:"answer"`
+	Segments       []AnswerSegment `json:"segments"`
+	Results        []SearchResult  `json:"results"`
+	Citations      []Citation      `json:"citations"`
+}
+
+func (s *SearchService) CreateConversation(ctx context.Context, userID uuid.UUID, title string) (db.Conversation, error) {
+	if strings.TrimSpace(title) == "" {
+		title = "New conversation"
+	}
+	return s.db.CreateConversation(ctx, db.CreateConversationParams{
+		UserID: userID,
+		Title:  title,
+	})
+}
+
+func (s *SearchService) ListConversations(ctx context.Context, userID uuid.UUID) ([]db.Conversation, error) {
+	return s.db.ListConversationsByUser(ctx, userID)
+}
+
+// GetConversation returns the conversation if it belongs to userID
+func (s *SearchService) GetConversation(ctx context.Context, userID, conversationID uuid.UUID) (db.Conversation, error) {
+	conv, err := s.db.GetConversationByID(ctx, conversationID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && conv.UserID != userID) {
+		return db.Conversation{}, ErrConversationNotFound
+	}
+	return conv, err
+}
+
+func (s *SearchService) GetConversationMessages(ctx context.Context, conversationID uuid.UUID) ([]ConversationMessage, error) {
+	rows, err := s.db.GetConversationMessages(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]ConversationMessage, len(rows))
+	for i, m := range rows {
+		messages[i] = toConversationMessage(m)
+	}
+	return messages, nil
+}
+
+func (s *SearchService) DeleteConversation(ctx context.Context, conversationID uuid.UUID) error {
+	return s.db.DeleteConversation(ctx, conversationID)
+}
+
+// AskInConversation answers a question in the context of the conversation so
+// far. The question is rewritten into a standalone retrieval query using the
+// recent turns, and the answer is grounded on the new hits plus the spells
+// cited earlier in the conversation. Both turns are stored together.
+func (s *SearchService) AskInConversation(
+	ctx context.Context,
+	caller Caller,
+	conversationID uuid.UUID,
+	question string,
+	opts retrieval.Options,
+) (*ConversationReply, error) {
+	history, err := s.db.GetRecentConversationMessages(ctx, db.GetRecentConversationMessagesParams{
+		ConversationID: conversationID,
+		Limit:          historyTurns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	retrievalQuery, err := s.rewriteQuery(ctx, history, question)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.retrieve(ctx, caller, retrievalQuery, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	grounding, err := s.withEarlierCitations(ctx, caller, history, results)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &VerifiedAnswer{
+		Answer:    "No relevant spells found.",
+		Segments:  []AnswerSegment{},
+		Citations: []Citation{},
+	}
+	if len(grounding) > 0 {
+		answer, err = s.generateCited(ctx, buildConversationPrompt(history, question, grounding), grounding)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	citedIDs := make([]int32, len(answer.Citations))
+	for i, c := range answer.Citations {
+		citedIDs[i] = c.PageID
+	}
+
+	err = s.withTx(ctx, func(q *db.Queries) error {
+		if _, err := q.CreateConversationMessage(ctx, db.CreateConversationMessageParams{
+			ConversationID: conversationID,
+			Role:           db.MessageRoleEnumUser,
+			Content:        question,
+			RetrievalQuery: sql.NullString{String: retrievalQuery, Valid: true},
+			CitedPageids:   []int32{},
+		}); err != nil {
+			return fmt.Errorf("failed to store question: %w", err)
+		}
+		if _, err := q.CreateConversationMessage(ctx, db.CreateConversationMessageParams{
+			ConversationID: conversationID,
+			Role:           db.MessageRoleEnumAssistant,
+			Content:        answer.Answer,
+			CitedPageids:   citedIDs,
+		}); err != nil {
+			return fmt.Errorf("failed to store answer: %w", err)
+		}
+		if err := q.TouchConversation(ctx, conversationID); err != nil {
+			return fmt.Errorf("failed to update conversation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConversationReply{
+		RetrievalQuery: retrievalQuery,
+		Answer:         answer.Answer,
+		Segments:       answer.Segments,
+		Results:        results,
+		Citations:      answer.Citations,
+	}, nil
+}
+
+// withTx runs fn on queries bound to one transaction, committing if it
+// returns nil
+func (s *SearchService) withTx(ctx context.Context, fn func(q *db.Queries) error) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.db.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rewriteQuery turns a follow-up question into a query that can be searched
+// on its own. The first question of a conversation is used as is.
+func (s *SearchService) rewriteQuery(ctx context.Context, history []db.ConversationMessage, question string) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Rewrite the follow-up question as a standalone search query for a spell database. " +
+		"Resolve pronouns and references using the conversation. Reply with the query only.\n\n")
+	writeHistory(&prompt, history)
+	fmt.Fprintf(&prompt, "\nQuestion: %s\n", question)
+
+	rewritten, err := s.generator.Generate(ctx, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite query: %w", err)
+	}
+
+	rewritten = strings.TrimSpace(rewritten)
+	if rewritten == "" {
+		return question, nil
+	}
+	return rewritten, nil
+}
+
+// withEarlierCitations appends the spells cited in earlier answers, which the
+// caller is still cleared to see, to the new results
+func (s *SearchService) withEarlierCitations(
+	ctx context.Context,
+	caller Caller,
+	history []db.ConversationMessage,
+	results []SearchResult,
+) ([]SearchResult, error) {
+	seen := map[int32]bool{}
+	for _, r := range results {
+		seen[r.PageID] = true
+	}
+
+	var earlier []int32
+	for _, m := range history {
+		for _, id := range m.CitedPageids {
+			if !seen[id] {
+				seen[id] = true
+				earlier = append(earlier, id)
+			}
+		}
+	}
+	if len(earlier) == 0 {
+		return results, nil
+	}
+
+	rows, err := s.db.GetSpellsByPageIDs(ctx, db.GetSpellsByPageIDsParams{
+		Pageids:   earlier,
+		Clearance: caller.Clearance,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cited spells: %w", err)
+	}
+
+	grounding := append([]SearchResult{}, results...)
+	for _, r := range rows {
+		grounding = append(grounding, SearchResult{
+			PageID:     r.Pageid,
+			Title:      r.Title,
+			Summary:    r.Summary.String,
+			URL:        r.Url,
+			Categories: r.Categories,
+		})
+	}
+	return grounding, nil
+}
+
+func buildConversationPrompt(history []db.ConversationMessage, question string, spells []SearchResult) string {
+	var prompt strings.Builder
+	prompt.WriteString("You are a magical librarian continuing a conversation. Answer the latest question clearly " +
+		"using only the retrieved spells below, citing the spells supporting each sentence by their bracketed id.\n\n")
+	writeHistory(&prompt, history)
+	fmt.Fprintf(&prompt, "\nQuestion: %s\n\nRetrieved spells:\n", question)
+	writeSpells(&prompt, spells)
+	return prompt.String()
+}
+
+func writeHistory(b *strings.Builder, history []db.ConversationMessage) {
+	b.WriteString("Conversation so far:\n")
+	for _, m := range history {
+		fmt.Fprintf(b, "%s: %s\n", m.Role, m.Content)
+	}
+}
+
+func toConversationMessage(m db.ConversationMessage) ConversationMessage {
+	return ConversationMessage{
+		ID:             m.ID,
+		Role:           string(m.Role),
+		Content:        m.Content,
+		RetrievalQuery: m.RetrievalQuery.String,
+		CitedPageIDs:   m.CitedPageids,
+		CreatedAt:      m.CreatedAt,
+	}
+}
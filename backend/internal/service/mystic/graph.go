@@ -0,0 +1,102 @@
+package mystic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/spellgraph"
+)
+
+var ErrSpellNotFound = errors.New("spell not found")
+
+const (
+	// graphContextHits is how many top hits get their neighbours added to
+	// the prompt, and graphContextNeighbors how many neighbours each
+	graphContextHits      = 3
+	graphContextNeighbors = 5
+)
+
+type RelatedSpells struct {
+	Spell     spellgraph.Node       `json:"spell"`
+	Neighbors []spellgraph.Neighbor `json:"neighbors"`
+}
+
+// RelatedSpells returns the spells and entities a spell links to and the
+// spells linking to it. Spells above the caller's clearance are left out,
+// and asking for one is reported as not found.
+func (s *SearchService) RelatedSpells(ctx context.Context, caller Caller, pageid int32) (*RelatedSpells, error) {
+	graph, err := s.graph.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	spell, ok := graph.Spell(pageid, caller.Clearance)
+	if !ok {
+		return nil, ErrSpellNotFound
+	}
+	neighbors, _ := graph.Related(pageid, caller.Clearance)
+	return &RelatedSpells{Spell: spell, Neighbors: neighbors}, nil
+}
+
+// SpellPath returns the shortest link path between two spells or entities,
+// each given as a pageid or title. The path is empty if they are not
+// connected.
+func (s *SearchService) SpellPath(ctx context.Context, caller Caller, from, to string) ([]spellgraph.Hop, error) {
+	graph, err := s.graph.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := graph.Path(from, to, caller.Clearance)
+	if !ok {
+		return nil, ErrSpellNotFound
+	}
+	if path == nil {
+		path = []spellgraph.Hop{}
+	}
+	return path, nil
+}
+
+// graphContext describes the graph neighbours of the top results for the
+// prompt, or returns "" when graph context is off. The answer can still be
+// given without it, so a failure to load the graph is only logged.
+func (s *SearchService) graphContext(ctx context.Context, caller Caller, results []SearchResult) string {
+	if !s.graphInPrompt || len(results) == 0 {
+		return ""
+	}
+
+	graph, err := s.graph.Graph(ctx)
+	if err != nil {
+		log.Printf("graph context: %v", err)
+		return ""
+	}
+
+	retrieved := make(map[int32]bool, len(results))
+	for _, r := range results {
+		retrieved[r.PageID] = true
+	}
+
+	var b strings.Builder
+	for _, r := range results[:min(graphContextHits, len(results))] {
+		neighbors, _ := graph.Related(r.PageID, caller.Clearance)
+		n := 0
+		for _, nb := range neighbors {
+			if n == graphContextNeighbors {
+				break
+			}
+			if nb.Kind == spellgraph.KindSpell && retrieved[nb.PageID] {
+				continue
+			}
+			fmt.Fprintf(&b, "- %s %s %s", r.Title, strings.ReplaceAll(nb.Edge, "_", " "), nb.Title)
+			if nb.Summary != "" {
+				fmt.Fprintf(&b, ": %s", nb.Summary)
+			}
+			b.WriteString("\n")
+			n++
+		}
+	}
+	return b.String()
+}
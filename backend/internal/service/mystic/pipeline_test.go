@@ -0,0 +1,63 @@
+package mystic
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+)
+
+// TestFakePipeline runs a question through the offline provider: the spells
+// are ranked by distance between fake embeddings, as SearchSpells orders
+// them, and the answer generated from the prompt is verified against them
+func TestFakePipeline(t *testing.T) {
+	ctx := context.Background()
+	fake := llm.NewFake(768)
+	spells := []SearchResult{
+		{PageID: 101, Title: "Eye of Agamotto", Summary: "An amulet housing the Time Stone that can reverse time"},
+		{PageID: 202, Title: "Crimson Bands of Cyttorak", Summary: "Bands of red energy that bind a target"},
+		{PageID: 303, Title: "Mirror Dimension", Summary: "A reflected realm where sorcerers train unseen"},
+	}
+	query := "amulet reverse time"
+
+	texts := []string{query}
+	for _, s := range spells {
+		texts = append(texts, s.Title+" "+s.Summary)
+	}
+	vecs, err := fake.Embed(ctx, texts)
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+
+	distance := map[int32]float64{}
+	for i, s := range spells {
+		var d float64
+		for j, v := range vecs[i+1] {
+			diff := float64(v - vecs[0][j])
+			d += diff * diff
+		}
+		distance[s.PageID] = math.Sqrt(d)
+	}
+	sort.SliceStable(spells, func(i, j int) bool { return distance[spells[i].PageID] < distance[spells[j].PageID] })
+	if spells[0].PageID != 101 {
+		t.Fatalf("ranked %q first, want the Eye of Agamotto", spells[0].Title)
+	}
+	results := spells[:2]
+
+	s := &SearchService{generator: fake}
+	answer, err := s.generateCited(ctx, buildPrompt(query, results, ""), results)
+	if err != nil {
+		t.Fatalf("generateCited: %v", err)
+	}
+
+	if len(answer.Citations) == 0 || answer.Citations[0].PageID != 101 || answer.Citations[0].Index != 0 {
+		t.Fatalf("Citations = %+v, want the Eye of Agamotto first", answer.Citations)
+	}
+	for _, seg := range answer.Segments {
+		if seg.Unsupported || len(seg.RejectedPageIDs) > 0 {
+			t.Errorf("segment %+v is not backed by a retrieved spell", seg)
+		}
+	}
+}
@@ -4,191 +4,184 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
-	_ "github.com/lib/pq"
-	"github.com/sqlc-dev/pqtype"
-	"google.golang.org/genai"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/spellgraph"
 )
 
+// Notifier delivers in-app notifications, see calendar.CalendarService
+type Notifier interface {
+	CreateNotification(
+		ctx context.Context,
+		userID uuid.UUID,
+		missionID uuid.NullUUID,
+		notifType db.NotificationTypeEnum,
+		message string,
+	) (db.Notification, error)
+}
+
 type SearchService struct {
-	db *db.Queries
+	conn      *sql.DB
+	db        *db.Queries
+	notifier  Notifier
+	engine    *retrieval.Engine
+	generator llm.Generator
+	graph     *spellgraph.Loader
+
+	// graphInPrompt adds the link graph neighbours of the top hits to the
+	// answer prompt; set MYSTIC_GRAPH_CONTEXT=true to turn it on
+	graphInPrompt bool
 }
 
-func New(db *db.Queries) *SearchService {
+func New(conn *sql.DB, notifier Notifier, engine *retrieval.Engine, generator llm.Generator, graph *spellgraph.Loader) *SearchService {
 	return &SearchService{
-		db: db,
+		conn:          conn,
+		db:            db.New(conn),
+		notifier:      notifier,
+		engine:        engine,
+		generator:     generator,
+		graph:         graph,
+		graphInPrompt: os.Getenv("MYSTIC_GRAPH_CONTEXT") == "true",
 	}
 }
 
-type SearchResult struct {
-	Title      string   `json:"title"`
-	Summary    string   `json:"summary"`
-	URL        string   `json:"url"`
-	Categories []string `json:"categories"`
+// Caller is the user a search runs on behalf of. Spells whose access_level
+// exceeds Clearance are never returned to them.
+type Caller struct {
+	UserID    uuid.UUID
+	Email     string
+	Clearance int16
 }
 
+// SearchResult is a spell retrieved for a query
+type SearchResult = retrieval.Hit
+
+// RAGResponse is an answer with footnoted citations. Every citation is one of
+// the Results; Segments says which parts of the answer each one supports.
 type RAGResponse struct {
-	Answer  string         `json:"answer"`
-	Results []SearchResult `json:"results"`
+	Answer    string          `json:"answer"`
+	Segments  []AnswerSegment `json:"segments"`
+	Citations []Citation      `json:"citations"`
+	Results   []SearchResult  `json:"results"`
 }
 
-func (s *SearchService) QuerySpells(ctx context.Context, query string, limit int) (*RAGResponse, error) {
-	// Initialize DB connection inside the method
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		return nil, fmt.Errorf("missing DATABASE_URL")
-	}
-	sqlDB, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open DB: %w", err)
-	}
-	defer sqlDB.Close()
-
-	queries := db.New(sqlDB)
-
-	// Create Gemini client
-	client, err := genai.NewClient(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
-	}
-
-	// Embed query
-	contents := []*genai.Content{
-		genai.NewContentFromText(query, genai.RoleUser),
-	}
-	embedResp, err := client.Models.EmbedContent(ctx, "gemini-embedding-001", contents, nil)
+func (s *SearchService) QuerySpells(ctx context.Context, caller Caller, query string, opts retrieval.Options) (*RAGResponse, error) {
+	results, err := s.retrieve(ctx, caller, query, opts)
 	if err != nil {
-		return nil, fmt.Errorf("embedding failed: %w", err)
-	}
-
-	if len(embedResp.Embeddings) == 0 || len(embedResp.Embeddings[0].Values) == 0 {
-		return nil, fmt.Errorf("empty embedding result")
-	}
-
-	// Convert embedding to []float64
-	vec := embedResp.Embeddings[0].Values
-	vector32 := make([]float32, len(vec))
-	for i, v := range vec {
-		vector32[i] = float32(v)
-	}
-
-	var b strings.Builder
-	b.WriteString("[")
-	for i, v := range vector32 {
-		if i > 0 {
-			b.WriteString(",")
-		}
-		b.WriteString(fmt.Sprintf("%f", v))
-	}
-	b.WriteString("]")
-	vectorString := b.String()
-
-	// Use the existing sqlc-generated query
-	rows, err := queries.SearchSpells(ctx, db.SearchSpellsParams{
-		Embedding: vectorString,
-		Limit:     int32(limit),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("db query failed: %w", err)
-	}
-
-	results := make([]SearchResult, len(rows))
-	for i, r := range rows {
-		results[i] = SearchResult{
-			Title:      r.Title,
-			Summary:    r.Summary.String,
-			URL:        r.Url,
-			Categories: r.Categories,
-		}
+		return nil, err
 	}
 
 	if len(results) == 0 {
-		return &RAGResponse{Answer: "No relevant spells found.", Results: results}, nil
+		return &RAGResponse{
+			Answer:    "No relevant spells found.",
+			Segments:  []AnswerSegment{},
+			Citations: []Citation{},
+			Results:   results,
+		}, nil
 	}
 
-	// Build context for LLM
-	contextStr := "You are a magical librarian. Use the following retrieved spells:"
-	for _, r := range results {
-		contextStr += fmt.Sprintf("Title: %sSummary: %sURL: %sCategories: %v",
-			r.Title, r.Summary, r.URL, r.Categories)
+	answer, err := s.generateCited(ctx, buildPrompt(query, results, s.graphContext(ctx, caller, results)), results)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate answer
-	prompt := fmt.Sprintf("User query: %s Relevant spells: %s Answer the question clearly, cite the relevant spells when appropriate.",
-		query, contextStr,
-	)
+	return &RAGResponse{
+		Answer:    answer.Answer,
+		Segments:  answer.Segments,
+		Citations: answer.Citations,
+		Results:   results,
+	}, nil
+}
 
-	genResp, err := client.Models.GenerateContent(ctx, "gemini-1.5-flash", []*genai.Content{
-		genai.NewContentFromText(prompt, genai.RoleUser),
-	}, nil)
+// retrieve returns the spells the caller is cleared to see that best match
+// the query
+func (s *SearchService) retrieve(ctx context.Context, caller Caller, query string, opts retrieval.Options) ([]SearchResult, error) {
+	// The caller's clearance bounds the search itself, so restricted spells
+	// never reach the caller or the LLM context, but matching one still
+	// raises an alert
+	results, restricted, err := s.engine.SearchCleared(ctx, query, opts, caller.Clearance)
 	if err != nil {
-		return nil, fmt.Errorf("LLM generation failed: %w", err)
+		return nil, err
 	}
 
-	answer := ""
-	for _, cand := range genResp.Candidates {
-		for _, part := range cand.Content.Parts {
-			answer += part.Text
-		}
+	if len(restricted) > 0 {
+		s.raiseRestrictedAlerts(ctx, caller, restricted)
 	}
 
-	return &RAGResponse{
-		Answer:  answer,
-		Results: results,
-	}, nil
+	return results, nil
 }
 
-type Spell struct {
-	Pageid      int32                 `json:"pageid"`
-	Title       string                `json:"title"`
-	Url         string                `json:"url"`
-	Summary     string                `json:"summary"`
-	Categories  []string              `json:"categories"`
-	Realities   []string              `json:"realities"`
-	Origin      string                `json:"origin"`
-	PowerClass  string                `json:"power_class"`
-	AccessLevel int16                 `json:"access_level"`
-	Aliases     []string              `json:"aliases"`
-	Infobox     pqtype.NullRawMessage `json:"infobox"`
+// buildPrompt builds the LLM prompt for a query from the retrieved spells and
+// optionally the wiki links around them
+func buildPrompt(query string, results []SearchResult, related string) string {
+	var prompt strings.Builder
+	prompt.WriteString("You are a magical librarian. Answer the question clearly using only the retrieved spells below. " +
+		"Cite the spells supporting each sentence by their bracketed id, like [123].\n\n")
+	fmt.Fprintf(&prompt, "Question: %s\n\nRetrieved spells:\n", query)
+	writeSpells(&prompt, results)
+	if related != "" {
+		prompt.WriteString("\nLinked on the wiki:\n")
+		prompt.WriteString(related)
+	}
+	return prompt.String()
 }
 
-func (s *SearchService) ListSpells(ctx context.Context) ([]Spell, error) {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		return nil, fmt.Errorf("missing DATABASE_URL")
-	}
-	sqlDB, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open DB: %w", err)
+// writeSpells lists spells for a prompt, each tagged with its pageid
+func writeSpells(b *strings.Builder, results []SearchResult) {
+	for _, r := range results {
+		fmt.Fprintf(b, "- [%d] %s: %s (URL: %s, Categories: %s)\n",
+			r.PageID, r.Title, r.Summary, r.URL, strings.Join(r.Categories, ", "))
 	}
-	defer sqlDB.Close()
+}
 
-	queries := db.New(sqlDB)
+// restrictedAlertWindow is how long one alert covers a restricted spell;
+// further matches within it are not reported again
+const restrictedAlertWindow = time.Hour
+
+// raiseRestrictedAlerts stamps alert_triggered_at on each restricted spell and
+// notifies every admin, skipping spells that already alerted within
+// restrictedAlertWindow. Failures are logged rather than returned, since the
+// spells have already been withheld from the caller.
+func (s *SearchService) raiseRestrictedAlerts(ctx context.Context, caller Caller, spells []SearchResult) {
+	var adminIDs []uuid.UUID
+	for _, spell := range spells {
+		marked, err := s.db.MarkSpellAlertTriggered(ctx, db.MarkSpellAlertTriggeredParams{
+			Pageid: spell.PageID,
+			Since:  time.Now().Add(-restrictedAlertWindow),
+		})
+		if err != nil {
+			log.Printf("restricted spell alert: could not mark spell %d: %v", spell.PageID, err)
+			continue
+		}
+		if marked == 0 {
+			continue
+		}
 
-	rows, err := queries.GetSpells(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("db query failed: %w", err)
-	}
+		if adminIDs == nil {
+			if adminIDs, err = s.db.ListAdminIDs(ctx); err != nil {
+				log.Printf("restricted spell alert: could not list admins: %v", err)
+				return
+			}
+		}
 
-	spells := make([]Spell, len(rows))
-	for i, r := range rows {
-		spells[i] = Spell{
-			Pageid:      r.Pageid,
-			Title:       r.Title,
-			Url:         r.Url,
-			Summary:     r.Summary.String,
-			Categories:  r.Categories,
-			Realities:   r.Realities,
-			Origin:      r.Origin.String,
-			PowerClass:  r.PowerClass.String,
-			AccessLevel: r.AccessLevel.Int16,
-			Aliases:     r.Aliases,
-			Infobox:     r.Infobox,
+		message := fmt.Sprintf("Restricted spell %q (access level %d) was queried by %s",
+			spell.Title, spell.AccessLevel, caller.Email)
+		if spell.RestrictedReason != "" {
+			message += ": " + spell.RestrictedReason
 		}
-	}
 
-	return spells, nil
+		for _, adminID := range adminIDs {
+			_, err := s.notifier.CreateNotification(ctx, adminID, uuid.NullUUID{}, db.NotificationTypeEnumHighThreatAlert, message)
+			if err != nil {
+				log.Printf("restricted spell alert: could not notify %s: %v", adminID, err)
+			}
+		}
+	}
 }
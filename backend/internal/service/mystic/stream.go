@@ -0,0 +1,59 @@
+package mystic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+)
+
+// StreamSink receives the stages of a streamed query in order: the retrieved
+// spells, each answer token, then the final summary. Returning an error from
+// any method aborts the stream.
+type StreamSink interface {
+	Results(results []SearchResult) error
+	Token(text string) error
+	Done(done StreamDone) error
+}
+
+// StreamDone is sent once the answer is complete
+type StreamDone struct {
+	Citations []Citation `json:"citations"`
+	Usage     llm.Usage  `json:"usage"`
+}
+
+// StreamSpells answers a query like QuerySpells but hands the results and the
+// answer to sink as they become available. Cancelling ctx, e.g. when the
+// client disconnects, stops generation.
+func (s *SearchService) StreamSpells(ctx context.Context, caller Caller, query string, opts retrieval.Options, sink StreamSink) error {
+	results, err := s.retrieve(ctx, caller, query, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := sink.Results(results); err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		if err := sink.Token("No relevant spells found."); err != nil {
+			return err
+		}
+		return sink.Done(StreamDone{Citations: []Citation{}})
+	}
+
+	var answer strings.Builder
+	usage, err := s.generator.GenerateStream(ctx, buildPrompt(query, results, s.graphContext(ctx, caller, results)), func(token string) error {
+		answer.WriteString(token)
+		return sink.Token(token)
+	})
+	if err != nil {
+		return err
+	}
+
+	return sink.Done(StreamDone{
+		Citations: citedSpells(answer.String(), results),
+		Usage:     usage,
+	})
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// Roles returns the roles carried in the user's access tokens
+func Roles(user *db.User) []string {
+	return []string{string(user.Role)}
+}
+
+func (a *AuthService) SetUserRole(
+	ctx context.Context,
+	userID uuid.UUID,
+	role db.UserRoleEnum,
+	squadID uuid.NullUUID,
+) (db.User, error) {
+	return a.db.SetUserRole(ctx, db.SetUserRoleParams{
+		ID:      userID,
+		Role:    role,
+		SquadID: squadID,
+	})
+}
+
+func (a *AuthService) SetUserClearance(
+	ctx context.Context,
+	userID uuid.UUID,
+	clearance int16,
+) (db.User, error) {
+	return a.db.SetUserClearance(ctx, db.SetUserClearanceParams{
+		ID:             userID,
+		ClearanceLevel: clearance,
+	})
+}
+
+func (a *AuthService) CreateSquad(
+	ctx context.Context,
+	name string,
+	commanderID uuid.NullUUID,
+) (db.Squad, error) {
+	return a.db.CreateSquad(ctx, db.CreateSquadParams{
+		Name:        name,
+		CommanderID: commanderID,
+	})
+}
+
+func (a *AuthService) ListSquads(ctx context.Context) ([]db.Squad, error) {
+	return a.db.ListSquads(ctx)
+}
+
+// IsSquadMember reports whether the user belongs to a squad the commander leads
+func (a *AuthService) IsSquadMember(ctx context.Context, userID, commanderID uuid.UUID) (bool, error) {
+	return a.db.IsSquadMember(ctx, db.IsSquadMemberParams{
+		ID:          userID,
+		CommanderID: uuid.NullUUID{UUID: commanderID, Valid: true},
+	})
+}
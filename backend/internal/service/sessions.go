@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrSessionRevoked     = errors.New("session revoked")
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// SessionInfo describes the client a session was issued to
+type SessionInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is a freshly issued refresh token together with the session it
+// belongs to. RefreshToken is only ever available here; the database keeps
+// its hash.
+type Session struct {
+	ID           uuid.UUID
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// CreateSession starts a new session family for the user
+func (a *AuthService) CreateSession(ctx context.Context, userID uuid.UUID, info SessionInfo) (*Session, error) {
+	return a.issueRefreshToken(ctx, userID, uuid.New(), info)
+}
+
+// RefreshSession rotates a refresh token. Presenting a token that has already
+// been rotated is treated as theft and revokes the whole family.
+func (a *AuthService) RefreshSession(ctx context.Context, refreshToken string, info SessionInfo) (*db.User, *Session, error) {
+	current, err := a.db.GetSessionByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrSessionNotFound
+		}
+		return nil, nil, fmt.Errorf("could not get session: %w", err)
+	}
+
+	if current.RevokedAt.Valid {
+		return nil, nil, ErrSessionRevoked
+	}
+
+	if current.UsedAt.Valid {
+		if err := a.db.RevokeSessionFamily(ctx, current.FamilyID); err != nil {
+			return nil, nil, fmt.Errorf("could not revoke session family: %w", err)
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, ErrSessionExpired
+	}
+
+	// The conditional update only succeeds for one caller, so two concurrent
+	// refreshes with the same token are also treated as reuse.
+	if _, err := a.db.MarkSessionUsed(ctx, current.ID); err != nil {
+		if err == sql.ErrNoRows {
+			if err := a.db.RevokeSessionFamily(ctx, current.FamilyID); err != nil {
+				return nil, nil, fmt.Errorf("could not revoke session family: %w", err)
+			}
+			return nil, nil, ErrRefreshTokenReused
+		}
+		return nil, nil, fmt.Errorf("could not rotate session: %w", err)
+	}
+
+	user, err := a.db.GetUserByID(ctx, current.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get user: %w", err)
+	}
+
+	session, err := a.issueRefreshToken(ctx, current.UserID, current.FamilyID, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &user, session, nil
+}
+
+// RevokeSession revokes the session the refresh token belongs to
+func (a *AuthService) RevokeSession(ctx context.Context, refreshToken string) error {
+	current, err := a.db.GetSessionByTokenHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("could not get session: %w", err)
+	}
+
+	return a.db.RevokeSessionFamily(ctx, current.FamilyID)
+}
+
+// ActiveSession is a signed-in device of the user. ID is the session ID
+// carried in access tokens.
+type ActiveSession struct {
+	ID              uuid.UUID `json:"id"`
+	UserAgent       string    `json:"user_agent,omitempty"`
+	IPAddress       string    `json:"ip_address,omitempty"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// ListSessions returns the user's sessions that have not been revoked or
+// expired, most recently refreshed first
+func (a *AuthService) ListSessions(ctx context.Context, userID uuid.UUID) ([]ActiveSession, error) {
+	rows, err := a.db.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]ActiveSession, len(rows))
+	for i, r := range rows {
+		sessions[i] = ActiveSession{
+			ID:              r.FamilyID,
+			UserAgent:       r.UserAgent.String,
+			IPAddress:       r.IpAddress.String,
+			LastRefreshedAt: r.CreatedAt,
+			ExpiresAt:       r.ExpiresAt,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSessionByID revokes one of the user's sessions by the ID carried in
+// access tokens
+func (a *AuthService) RevokeSessionByID(ctx context.Context, userID, sessionID uuid.UUID) error {
+	n, err := a.db.RevokeUserSessionFamily(ctx, db.RevokeUserSessionFamilyParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions revokes every session of the user
+func (a *AuthService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	return a.db.RevokeUserSessions(ctx, userID)
+}
+
+// IsSessionActive reports whether access tokens for the session are still
+// honoured
+func (a *AuthService) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	return a.db.IsSessionActive(ctx, sessionID)
+}
+
+func (a *AuthService) issueRefreshToken(
+	ctx context.Context,
+	userID uuid.UUID,
+	familyID uuid.UUID,
+	info SessionInfo,
+) (*Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(b)
+
+	row, err := a.db.CreateSession(ctx, db.CreateSessionParams{
+		UserID:           userID,
+		FamilyID:         familyID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		UserAgent:        sql.NullString{String: info.UserAgent, Valid: info.UserAgent != ""},
+		IpAddress:        sql.NullString{String: info.IPAddress, Valid: info.IPAddress != ""},
+		ExpiresAt:        time.Now().Add(a.refreshTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create session: %w", err)
+	}
+
+	return &Session{
+		ID:           row.FamilyID,
+		RefreshToken: refreshToken,
+		ExpiresAt:    row.ExpiresAt,
+	}, nil
+}
+
+// Refresh tokens are 256 bits of randomness, so a plain SHA-256 is enough to
+// keep them unusable if the table leaks
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
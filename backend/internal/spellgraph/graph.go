@@ -0,0 +1,261 @@
+// Package spellgraph turns the outlinks scraped with each spell into a link
+// graph between spells and the other wiki entities they mention
+package spellgraph
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+type NodeKind string
+
+const (
+	KindSpell  NodeKind = "spell"
+	KindEntity NodeKind = "entity"
+)
+
+// Edge types, named from the point of view of the node they are listed on
+const (
+	EdgeLinksTo     = "links_to"     // the spell links to another spell
+	EdgeLinkedFrom  = "linked_from"  // another spell links to the spell
+	EdgeMentions    = "mentions"     // the spell links to a non-spell page
+	EdgeMentionedBy = "mentioned_by" // a spell links to the entity
+)
+
+// Node is a spell or an entity, such as a character or place, that a spell
+// links to. Entities are outlinks that do not resolve to a stored spell.
+type Node struct {
+	Kind    NodeKind `json:"kind"`
+	PageID  int32    `json:"pageid,omitempty"`
+	Title   string   `json:"title"`
+	URL     string   `json:"url"`
+	Summary string   `json:"summary,omitempty"`
+
+	accessLevel int16
+}
+
+// Neighbor is a node adjacent to a spell and the edge joining them
+type Neighbor struct {
+	Node
+	Edge string `json:"edge"`
+}
+
+// Hop is a step on a path; Edge is how the node was reached from the
+// previous one and is empty on the first hop
+type Hop struct {
+	Node
+	Edge string `json:"edge,omitempty"`
+}
+
+// Graph is an immutable link graph. Spells above a caller's clearance are
+// treated as absent by every lookup.
+type Graph struct {
+	nodes  []Node
+	spells map[int32]int
+	titles map[string]int
+	out    [][]int
+	in     [][]int
+}
+
+type outlink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Build resolves each spell's outlinks to spells by title or alias; the
+// rest become entity nodes shared by every spell linking to them
+func Build(rows []db.ListSpellGraphRow) *Graph {
+	g := &Graph{
+		spells: make(map[int32]int, len(rows)),
+		titles: make(map[string]int, len(rows)),
+	}
+
+	for _, r := range rows {
+		idx := g.add(Node{
+			Kind:        KindSpell,
+			PageID:      r.Pageid,
+			Title:       r.Title,
+			URL:         r.Url,
+			Summary:     r.Summary.String,
+			accessLevel: r.AccessLevel,
+		})
+		g.spells[r.Pageid] = idx
+		g.titles[normalize(r.Title)] = idx
+	}
+	// Aliases resolve only where no spell already has the title
+	for _, r := range rows {
+		for _, alias := range r.Aliases {
+			if _, taken := g.titles[normalize(alias)]; !taken {
+				g.titles[normalize(alias)] = g.spells[r.Pageid]
+			}
+		}
+	}
+
+	for _, r := range rows {
+		if !r.Outlinks.Valid {
+			continue
+		}
+		var links []outlink
+		if err := json.Unmarshal(r.Outlinks.RawMessage, &links); err != nil {
+			continue
+		}
+
+		from := g.spells[r.Pageid]
+		seen := map[int]bool{from: true}
+		for _, l := range links {
+			key := normalize(l.Title)
+			if key == "" {
+				continue
+			}
+			to, ok := g.titles[key]
+			if !ok {
+				to = g.add(Node{Kind: KindEntity, Title: l.Title, URL: l.URL})
+				g.titles[key] = to
+			}
+			if seen[to] {
+				continue
+			}
+			seen[to] = true
+			g.out[from] = append(g.out[from], to)
+			g.in[to] = append(g.in[to], from)
+		}
+	}
+	return g
+}
+
+func (g *Graph) add(n Node) int {
+	g.nodes = append(g.nodes, n)
+	g.out = append(g.out, nil)
+	g.in = append(g.in, nil)
+	return len(g.nodes) - 1
+}
+
+func (g *Graph) visible(idx int, clearance int16) bool {
+	n := g.nodes[idx]
+	return n.Kind == KindEntity || n.accessLevel <= clearance
+}
+
+// Spell returns a spell node
+func (g *Graph) Spell(pageid int32, clearance int16) (Node, bool) {
+	idx, ok := g.spells[pageid]
+	if !ok || !g.visible(idx, clearance) {
+		return Node{}, false
+	}
+	return g.nodes[idx], true
+}
+
+// Related lists the nodes a spell links to and the spells linking to it,
+// spells first
+func (g *Graph) Related(pageid int32, clearance int16) ([]Neighbor, bool) {
+	idx, ok := g.spells[pageid]
+	if !ok || !g.visible(idx, clearance) {
+		return nil, false
+	}
+
+	neighbors := []Neighbor{}
+	for _, to := range g.out[idx] {
+		if g.visible(to, clearance) {
+			neighbors = append(neighbors, Neighbor{Node: g.nodes[to], Edge: g.edge(idx, to)})
+		}
+	}
+	for _, from := range g.in[idx] {
+		if g.visible(from, clearance) {
+			neighbors = append(neighbors, Neighbor{Node: g.nodes[from], Edge: EdgeLinkedFrom})
+		}
+	}
+
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		if neighbors[i].Kind != neighbors[j].Kind {
+			return neighbors[i].Kind == KindSpell
+		}
+		return neighbors[i].Title < neighbors[j].Title
+	})
+	return neighbors, true
+}
+
+// resolve finds a node by spell pageid, or by spell title, alias or entity
+// title
+func (g *Graph) resolve(ref string, clearance int16) (int, bool) {
+	var idx int
+	var ok bool
+	if id, err := strconv.ParseInt(ref, 10, 32); err == nil {
+		idx, ok = g.spells[int32(id)]
+	} else {
+		idx, ok = g.titles[normalize(ref)]
+	}
+	if !ok || !g.visible(idx, clearance) {
+		return 0, false
+	}
+	return idx, true
+}
+
+// Path returns the shortest chain of links between two nodes, following
+// links in either direction. ok is false if either end is unknown; a nil
+// path means they are not connected.
+func (g *Graph) Path(from, to string, clearance int16) (path []Hop, ok bool) {
+	start, ok := g.resolve(from, clearance)
+	if !ok {
+		return nil, false
+	}
+	goal, ok := g.resolve(to, clearance)
+	if !ok {
+		return nil, false
+	}
+
+	prev := map[int]int{start: -1}
+	queue := []int{start}
+	for len(queue) > 0 && !containsKey(prev, goal) {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range append(append([]int{}, g.out[cur]...), g.in[cur]...) {
+			if containsKey(prev, next) || !g.visible(next, clearance) {
+				continue
+			}
+			prev[next] = cur
+			queue = append(queue, next)
+		}
+	}
+	if !containsKey(prev, goal) {
+		return nil, true
+	}
+
+	for cur := goal; cur != -1; cur = prev[cur] {
+		hop := Hop{Node: g.nodes[cur]}
+		if p := prev[cur]; p != -1 {
+			hop.Edge = g.edge(p, cur)
+		}
+		path = append(path, hop)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// edge names the link between adjacent nodes as seen from the first one
+func (g *Graph) edge(from, to int) string {
+	if slices.Contains(g.out[from], to) {
+		if g.nodes[to].Kind == KindSpell {
+			return EdgeLinksTo
+		}
+		return EdgeMentions
+	}
+	if g.nodes[from].Kind == KindEntity {
+		return EdgeMentionedBy
+	}
+	return EdgeLinkedFrom
+}
+
+func normalize(title string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(title, "_", " ")))
+}
+
+func containsKey(m map[int]int, k int) bool {
+	_, ok := m[k]
+	return ok
+}
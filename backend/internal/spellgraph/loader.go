@@ -0,0 +1,51 @@
+package spellgraph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// Loader builds the graph from the spells table and keeps it for a while,
+// so edits by the wiki refresher show up within one TTL
+type Loader struct {
+	db  *db.Queries
+	ttl time.Duration
+
+	mu       sync.Mutex
+	graph    *Graph
+	loadedAt time.Time
+}
+
+// NewLoader reads the TTL from SPELL_GRAPH_TTL, defaulting to 5m
+func NewLoader(queries *db.Queries) *Loader {
+	ttl := 5 * time.Minute
+	if v := os.Getenv("SPELL_GRAPH_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+	return &Loader{db: queries, ttl: ttl}
+}
+
+// Graph returns the cached graph, rebuilding it once it is older than the TTL
+func (l *Loader) Graph(ctx context.Context) (*Graph, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.graph != nil && time.Since(l.loadedAt) < l.ttl {
+		return l.graph, nil
+	}
+
+	rows, err := l.db.ListSpellGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spell links: %w", err)
+	}
+	l.graph = Build(rows)
+	l.loadedAt = time.Now()
+	return l.graph, nil
+}
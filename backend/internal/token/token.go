@@ -0,0 +1,185 @@
+// Package token issues and verifies the JWT access tokens used by the API
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config describes the signing keys and the claims every token must carry.
+// Keys maps a key ID to its HMAC secret; ActiveKID selects the key new tokens
+// are signed with. All keys are accepted for verification, so a retired key
+// can stay in the set until the tokens it signed have expired.
+type Config struct {
+	Keys      map[string][]byte
+	ActiveKID string
+	Issuer    string
+	Audience  string
+	TTL       time.Duration
+	Leeway    time.Duration
+}
+
+// LoadConfig reads the token configuration from the environment.
+//
+//	JWT_KEYS        comma separated kid:secret pairs, e.g. "2025-09:abc,2025-06:def"
+//	JWT_ACTIVE_KID  kid used for signing (defaults to the first key in JWT_KEYS)
+//	JWT_SECRET      single secret used when JWT_KEYS is not set
+//	JWT_ISSUER      defaults to "sinepsis"
+//	JWT_AUDIENCE    defaults to "sinepsis-api"
+//	JWT_ACCESS_TTL  Go duration, defaults to 24h
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		Keys:      map[string][]byte{},
+		ActiveKID: os.Getenv("JWT_ACTIVE_KID"),
+		Issuer:    getenv("JWT_ISSUER", "sinepsis"),
+		Audience:  getenv("JWT_AUDIENCE", "sinepsis-api"),
+		TTL:       24 * time.Hour,
+		Leeway:    30 * time.Second,
+	}
+
+	if raw := os.Getenv("JWT_KEYS"); raw != "" {
+		for _, pair := range strings.Split(raw, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || kid == "" || secret == "" {
+				return Config{}, fmt.Errorf("malformed JWT_KEYS entry %q", pair)
+			}
+			cfg.Keys[kid] = []byte(secret)
+			if cfg.ActiveKID == "" {
+				cfg.ActiveKID = kid
+			}
+		}
+	} else if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if cfg.ActiveKID == "" {
+			cfg.ActiveKID = "default"
+		}
+		cfg.Keys[cfg.ActiveKID] = []byte(secret)
+	}
+
+	if ttl := os.Getenv("JWT_ACCESS_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid JWT_ACCESS_TTL: %w", err)
+		}
+		cfg.TTL = d
+	}
+
+	return cfg, nil
+}
+
+// Claims are the claims carried by an access token
+type Claims struct {
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	SessionID string   `json:"sid"`
+	Roles     []string `json:"roles,omitempty"`
+	Clearance int16    `json:"clearance"`
+	jwt.RegisteredClaims
+}
+
+// Subject describes who an access token is issued to
+type Subject struct {
+	UserID    uuid.UUID
+	Email     string
+	SessionID uuid.UUID
+	Roles     []string
+	Clearance int16
+}
+
+type Manager struct {
+	cfg    Config
+	parser *jwt.Parser
+}
+
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("no JWT signing keys configured")
+	}
+	if _, ok := cfg.Keys[cfg.ActiveKID]; !ok {
+		return nil, fmt.Errorf("active JWT key %q is not in the key set", cfg.ActiveKID)
+	}
+	if cfg.TTL <= 0 {
+		return nil, errors.New("JWT TTL must be positive")
+	}
+
+	return &Manager{
+		cfg: cfg,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}, nil
+}
+
+// TTL is the lifetime of the access tokens this manager issues
+func (m *Manager) TTL() time.Duration {
+	return m.cfg.TTL
+}
+
+// Sign issues an access token for the subject's session using the active key
+func (m *Manager) Sign(sub Subject) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:    sub.UserID.String(),
+		Email:     sub.Email,
+		SessionID: sub.SessionID.String(),
+		Roles:     sub.Roles,
+		Clearance: sub.Clearance,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.cfg.Issuer,
+			Subject:   sub.UserID.String(),
+			Audience:  jwt.ClaimStrings{m.cfg.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.cfg.TTL)),
+			ID:        uuid.NewString(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.cfg.ActiveKID
+
+	return token.SignedString(m.cfg.Keys[m.cfg.ActiveKID])
+}
+
+// Verify checks the signature, kid and registered claims of a token
+func (m *Manager) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := m.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.cfg.Keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if _, err := uuid.Parse(claims.UserID); err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id claim", ErrInvalidToken)
+	}
+	if _, err := uuid.Parse(claims.SessionID); err != nil {
+		return nil, fmt.Errorf("%w: invalid sid claim", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
+
+func getenv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,260 @@
+package wikisync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	userAgent     = "SinepsisSpellRefresher/1.0"
+	retryAttempts = 4
+	retryDelay    = 600 * time.Millisecond
+
+	// revisionBatch is the most pageids MediaWiki accepts in one query
+	revisionBatch = 50
+)
+
+// Revision is the latest revision of a page. Missing is set for pages that
+// no longer exist on the wiki.
+type Revision struct {
+	RevID     int64
+	Timestamp time.Time
+	Missing   bool
+}
+
+// Client calls a MediaWiki API, waiting at least delay between requests to
+// stay polite
+type Client struct {
+	api   string
+	base  string
+	http  *http.Client
+	delay time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewClient talks to the api.php endpoint at apiURL. Page URLs and outlinks
+// are built on baseURL.
+func NewClient(apiURL, baseURL string, delay time.Duration) *Client {
+	return &Client{
+		api:   apiURL,
+		base:  strings.TrimRight(baseURL, "/"),
+		http:  &http.Client{Timeout: 30 * time.Second},
+		delay: delay,
+	}
+}
+
+// PageURL is the wiki URL of a page title
+func (c *Client) PageURL(title string) string {
+	return c.base + "/wiki/" + strings.ReplaceAll(title, " ", "_")
+}
+
+// Revisions returns the latest revision of each page, querying
+// revisionBatch pages per request
+func (c *Client) Revisions(ctx context.Context, pageids []int32) (map[int32]Revision, error) {
+	out := make(map[int32]Revision, len(pageids))
+	for start := 0; start < len(pageids); start += revisionBatch {
+		batch := pageids[start:min(start+revisionBatch, len(pageids))]
+		ids := make([]string, len(batch))
+		for i, id := range batch {
+			ids[i] = strconv.Itoa(int(id))
+		}
+
+		var resp queryResponse
+		if err := c.get(ctx, url.Values{
+			"action":  {"query"},
+			"pageids": {strings.Join(ids, "|")},
+			"prop":    {"revisions"},
+			"rvprop":  {"ids|timestamp"},
+		}, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, p := range resp.Query.Pages {
+			out[p.PageID] = p.revision()
+		}
+	}
+	return out, nil
+}
+
+// Fetch parses a page and reads its image, categories and latest revision
+func (c *Client) Fetch(ctx context.Context, pageid int32) (*Page, error) {
+	id := strconv.Itoa(int(pageid))
+
+	var parsed parseResponse
+	if err := c.get(ctx, url.Values{
+		"action": {"parse"},
+		"pageid": {id},
+		"prop":   {"text|wikitext|links"},
+	}, &parsed); err != nil {
+		return nil, err
+	}
+
+	var meta queryResponse
+	if err := c.get(ctx, url.Values{
+		"action":  {"query"},
+		"pageids": {id},
+		"prop":    {"pageimages|categories|info|revisions"},
+		"piprop":  {"original"},
+		"cllimit": {"max"},
+		"rvprop":  {"ids|timestamp"},
+	}, &meta); err != nil {
+		return nil, err
+	}
+	if len(meta.Query.Pages) == 0 {
+		return nil, fmt.Errorf("page %d has no metadata", pageid)
+	}
+	info := meta.Query.Pages[0]
+
+	categories := make([]string, 0, len(info.Categories))
+	for _, cat := range info.Categories {
+		categories = append(categories, strings.TrimPrefix(cat.Title, "Category:"))
+	}
+
+	var links []string
+	for _, l := range parsed.Parse.Links {
+		links = append(links, l.Title)
+	}
+
+	page := extract(parsed.Parse.Title, parsed.Parse.Text, parsed.Parse.Wikitext, links, c.PageURL)
+	page.PageID = pageid
+	page.Categories = categories
+	page.Revision = info.revision()
+	if info.Original != nil {
+		page.ImageURL = info.Original.Source
+	}
+	return page, nil
+}
+
+// get sends a GET with formatversion=2 JSON and decodes the response into
+// out, retrying with exponential backoff
+func (c *Client) get(ctx context.Context, params url.Values, out any) error {
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	endpoint := c.api + "?" + params.Encode()
+
+	var err error
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay << (attempt - 1)):
+			}
+		}
+		if err = c.wait(ctx); err != nil {
+			return err
+		}
+		if err = c.do(ctx, endpoint, out); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s: %w", params.Get("action"), endpoint, err)
+}
+
+func (c *Client) do(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wiki returned %s", resp.Status)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// MediaWiki reports API errors with a 200 and an error object
+	var apiErr struct {
+		Error *struct {
+			Code string `json:"code"`
+			Info string `json:"info"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &apiErr); err != nil {
+		return err
+	}
+	if apiErr.Error != nil {
+		return fmt.Errorf("wiki error %s: %s", apiErr.Error.Code, apiErr.Error.Info)
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	next := c.last.Add(c.delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
+
+type queryResponse struct {
+	Query struct {
+		Pages []queryPage `json:"pages"`
+	} `json:"query"`
+}
+
+type queryPage struct {
+	PageID    int32  `json:"pageid"`
+	Title     string `json:"title"`
+	Missing   bool   `json:"missing"`
+	Revisions []struct {
+		RevID     int64     `json:"revid"`
+		Timestamp time.Time `json:"timestamp"`
+	} `json:"revisions"`
+	Categories []struct {
+		Title string `json:"title"`
+	} `json:"categories"`
+	Original *struct {
+		Source string `json:"source"`
+	} `json:"original"`
+}
+
+func (p queryPage) revision() Revision {
+	if p.Missing || len(p.Revisions) == 0 {
+		return Revision{Missing: true}
+	}
+	return Revision{RevID: p.Revisions[0].RevID, Timestamp: p.Revisions[0].Timestamp}
+}
+
+type parseResponse struct {
+	Parse struct {
+		Title    string `json:"title"`
+		PageID   int32  `json:"pageid"`
+		Text     string `json:"text"`
+		Wikitext string `json:"wikitext"`
+		Links    []struct {
+			NS    int    `json:"ns"`
+			Title string `json:"title"`
+		} `json:"links"`
+	} `json:"parse"`
+}
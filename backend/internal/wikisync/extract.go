@@ -0,0 +1,237 @@
+package wikisync
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sectionTextLimit caps the text kept per section, in characters
+const sectionTextLimit = 2000
+
+var (
+	doctorStrangePattern = regexp.MustCompile(`(?i)\bDoctor Strange\b|\bStephen Strange\b`)
+	realityPattern       = regexp.MustCompile(`\bEarth-(?:\d{3,}|616|199999)\b`)
+	aliasesPattern       = regexp.MustCompile(`(?im)^\s*\|\s*aliases\s*=\s*(.+)$`)
+	firstAppPattern      = regexp.MustCompile(`(?im)^\s*\|\s*first\s*appearance\s*=\s*(.+)$`)
+	infoboxPattern       = regexp.MustCompile(`(?is)\{\{Infobox[^}]*\}\}`)
+	aliasSplitPattern    = regexp.MustCompile(`,|<br\s*/?>|\n`)
+	templatePattern      = regexp.MustCompile(`\s*\{\{.*?\}\}\s*`)
+	footnotePattern      = regexp.MustCompile(`\[\d+\]`)
+)
+
+// Section is a top-level section of a page with its prose
+type Section struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// Link is an article the page links to
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Page is a spell as extracted from the wiki. The extraction matches the
+// original scraper in web-scraping-script/spells.py so refreshed rows look
+// like scraped ones.
+type Page struct {
+	PageID              int32
+	Title               string
+	URL                 string
+	Summary             string
+	UsedByDoctorStrange bool
+	Revision            Revision
+	ImageURL            string
+	Categories          []string
+	Realities           []string
+	FirstAppearance     string
+	Aliases             []string
+	// Infobox is the raw infobox template, empty if the page has none
+	Infobox  string
+	Sections []Section
+	Outlinks []Link
+}
+
+func extract(title, pageHTML, wikitext string, links []string, pageURL func(string) string) *Page {
+	doc, err := html.Parse(strings.NewReader(pageHTML))
+	if err != nil {
+		// html.Parse only fails on reader errors
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	content := findNode(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && hasClass(n, "mw-parser-output")
+	})
+
+	page := &Page{
+		Title:               title,
+		URL:                 pageURL(title),
+		Summary:             summary(doc, content),
+		UsedByDoctorStrange: usedByDoctorStrange(pageHTML, wikitext),
+		Realities:           realities(wikitext + "\n" + pageHTML),
+		Aliases:             []string{},
+		Sections:            sections(content),
+		Outlinks:            []Link{},
+	}
+
+	if m := infoboxPattern.FindString(wikitext); m != "" {
+		page.Infobox = m
+	}
+	if m := aliasesPattern.FindStringSubmatch(wikitext); m != nil {
+		for _, a := range aliasSplitPattern.Split(m[1], -1) {
+			if a = strings.TrimSpace(a); a != "" {
+				page.Aliases = append(page.Aliases, a)
+			}
+		}
+	}
+	if m := firstAppPattern.FindStringSubmatch(wikitext); m != nil {
+		page.FirstAppearance = strings.TrimSpace(templatePattern.ReplaceAllString(m[1], ""))
+	}
+	for _, l := range links {
+		if l != "" && !strings.Contains(l, ":") {
+			page.Outlinks = append(page.Outlinks, Link{Title: l, URL: pageURL(l)})
+		}
+	}
+	return page
+}
+
+// summary is the first paragraph of the article body without footnote marks
+func summary(doc, content *html.Node) string {
+	var p *html.Node
+	if content != nil {
+		for c := content.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "p" {
+				p = c
+				break
+			}
+		}
+	}
+	if p == nil {
+		p = findNode(doc, func(n *html.Node) bool {
+			return n.Type == html.ElementNode && n.Data == "p"
+		})
+	}
+	if p == nil {
+		return ""
+	}
+	return footnotePattern.ReplaceAllString(text(p), "")
+}
+
+func usedByDoctorStrange(pageHTML, wikitext string) bool {
+	if doctorStrangePattern.MatchString(wikitext + "\n" + pageHTML) {
+		return true
+	}
+	return strings.Contains(pageHTML, `href="/wiki/Doctor_Strange`) ||
+		strings.Contains(pageHTML, `href="/wiki/Stephen_Strange`)
+}
+
+func realities(blob string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, r := range realityPattern.FindAllString(blob, -1) {
+		if !seen[r] {
+			seen[r] = true
+			out = append(out, r)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sections collects the prose under each h2 of the article body. Newer
+// MediaWiki versions wrap headings in a div.mw-heading2.
+func sections(content *html.Node) []Section {
+	out := []Section{}
+	if content == nil {
+		return out
+	}
+
+	var current *Section
+	var texts []string
+	flush := func() {
+		if current != nil && current.Title != "" {
+			current.Text = truncate(strings.Join(texts, " "), sectionTextLimit)
+			out = append(out, *current)
+		}
+	}
+
+	for c := content.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if c.Data == "h2" || (c.Data == "div" && hasClass(c, "mw-heading2")) {
+			flush()
+			title := strings.TrimSpace(strings.ReplaceAll(text(c), "[edit]", ""))
+			current, texts = &Section{Title: title}, nil
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch c.Data {
+		case "p", "ul", "ol", "table":
+			if t := text(c); t != "" {
+				texts = append(texts, t)
+			}
+		}
+	}
+	flush()
+	return out
+}
+
+// text joins the trimmed text nodes under n with spaces, skipping edit
+// section links
+func text(n *html.Node) string {
+	var parts []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasClass(n, "mw-editsection") {
+			return
+		}
+		if n.Type == html.TextNode {
+			if t := strings.TrimSpace(n.Data); t != "" {
+				parts = append(parts, t)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(parts, " ")
+}
+
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findNode(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, a := range n.Attr {
+		if a.Key == "class" {
+			for _, c := range strings.Fields(a.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,366 @@
+// Package wikisync keeps scraped spells in step with the wiki. It compares
+// stored revisions against a MediaWiki API, re-fetches only the pages that
+// changed, re-embeds them and records each change in spell_changes.
+package wikisync
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/llm"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/reindex"
+	"github.com/ieeemumsb/Sinepsis/backend/internal/retrieval"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Result counts what one refresh pass did
+type Result struct {
+	Checked    int `json:"checked"`
+	Updated    int `json:"updated"`
+	Missing    int `json:"missing"`
+	Reembedded int `json:"reembedded"`
+	Failed     int `json:"failed"`
+}
+
+// store is the spell data a refresh reads and writes, *db.Queries outside
+// tests
+type store interface {
+	ListSpellRevisions(ctx context.Context, arg db.ListSpellRevisionsParams) ([]db.ListSpellRevisionsRow, error)
+	GetSpellForRefresh(ctx context.Context, pageid int32) (db.GetSpellForRefreshRow, error)
+	GetSpellEmbeddingModel(ctx context.Context) (sql.NullString, error)
+	GetLatestSpellChangeKind(ctx context.Context, pageid int32) (db.SpellChangeKindEnum, error)
+	UpdateSpellFromWiki(ctx context.Context, arg db.UpdateSpellFromWikiParams) error
+	UpsertSpellEmbedding(ctx context.Context, arg db.UpsertSpellEmbeddingParams) error
+	DeleteOtherSpellEmbeddings(ctx context.Context, arg db.DeleteOtherSpellEmbeddingsParams) error
+	SetSpellEmbedding(ctx context.Context, arg db.SetSpellEmbeddingParams) error
+	InsertSpellChange(ctx context.Context, arg db.InsertSpellChangeParams) error
+}
+
+type Refresher struct {
+	store store
+	// inTx runs fn on a store bound to one transaction, committing if it
+	// returns nil
+	inTx     func(ctx context.Context, fn func(q store) error) error
+	wiki     *Client
+	embedder llm.Embedder
+}
+
+// New builds a refresher for the wiki at WIKI_API_URL, defaulting to the
+// Marvel fandom wiki the spells were scraped from. WIKI_BASE_URL is where
+// page URLs point and WIKI_REQUEST_DELAY the pause between wiki requests.
+func New(sqlDB *sql.DB, embedder llm.Embedder) (*Refresher, error) {
+	delay := 500 * time.Millisecond
+	if v := os.Getenv("WIKI_REQUEST_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid WIKI_REQUEST_DELAY %q", v)
+		}
+		delay = d
+	}
+
+	queries := db.New(sqlDB)
+	return &Refresher{
+		store: queries,
+		inTx: func(ctx context.Context, fn func(q store) error) error {
+			tx, err := sqlDB.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			if err := fn(queries.WithTx(tx)); err != nil {
+				return err
+			}
+			return tx.Commit()
+		},
+		wiki: NewClient(
+			getenv("WIKI_API_URL", "https://marvel.fandom.com/api.php"),
+			getenv("WIKI_BASE_URL", "https://marvel.fandom.com"),
+			delay,
+		),
+		embedder: embedder,
+	}, nil
+}
+
+// Schedule runs a refresh pass now and then every interval until ctx is
+// cancelled. A failed pass is logged and retried at the next tick.
+func (r *Refresher) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := r.Run(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("wikisync: refresh failed: %v", err)
+		} else if err == nil {
+			log.Printf("wikisync: checked %d spells, %d updated, %d re-embedded, %d missing, %d failed",
+				res.Checked, res.Updated, res.Reembedded, res.Missing, res.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run checks every stored spell's revision against the wiki and refreshes
+// the ones that changed. A page that fails to refresh is counted and skipped
+// so one bad page does not hold up the rest.
+func (r *Refresher) Run(ctx context.Context) (Result, error) {
+	var res Result
+	var after int32
+	for {
+		rows, err := r.store.ListSpellRevisions(ctx, db.ListSpellRevisionsParams{
+			AfterPageid: after,
+			Lim:         revisionBatch,
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to list spells: %w", err)
+		}
+		if len(rows) == 0 {
+			return res, nil
+		}
+
+		pageids := make([]int32, len(rows))
+		for i, row := range rows {
+			pageids[i] = row.Pageid
+		}
+		revisions, err := r.wiki.Revisions(ctx, pageids)
+		if err != nil {
+			return res, fmt.Errorf("failed to read revisions: %w", err)
+		}
+
+		for _, row := range rows {
+			res.Checked++
+			rev, ok := revisions[row.Pageid]
+			if !ok || rev.Missing {
+				if err := r.recordMissing(ctx, row); err != nil {
+					log.Printf("wikisync: pageid %d: %v", row.Pageid, err)
+				}
+				res.Missing++
+				continue
+			}
+			if row.PageRevID.Valid && row.PageRevID.Int64 == rev.RevID {
+				continue
+			}
+
+			reembedded, err := r.refresh(ctx, row.Pageid, row.PageRevID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return res, ctx.Err()
+				}
+				log.Printf("wikisync: pageid %d: %v", row.Pageid, err)
+				res.Failed++
+				continue
+			}
+			res.Updated++
+			if reembedded {
+				res.Reembedded++
+			}
+		}
+		after = rows[len(rows)-1].Pageid
+	}
+}
+
+// refresh re-fetches one page and stores it with its change log entry.
+// The vector is only recomputed when the text it is embedded from changed.
+func (r *Refresher) refresh(ctx context.Context, pageid int32, oldRev sql.NullInt64) (bool, error) {
+	page, err := r.wiki.Fetch(ctx, pageid)
+	if err != nil {
+		return false, err
+	}
+	stored, err := r.store.GetSpellForRefresh(ctx, pageid)
+	if err != nil {
+		return false, fmt.Errorf("failed to load spell: %w", err)
+	}
+
+	params, err := updateParams(page)
+	if err != nil {
+		return false, err
+	}
+	changed := changedFields(stored, params)
+	reembed := slices.ContainsFunc(changed, func(f string) bool {
+		return f == "title" || f == "summary" || f == "aliases" || f == "categories"
+	})
+
+	model := r.embedder.Model()
+	var vec []float32
+	var active sql.NullString
+	if reembed {
+		vec, err = llm.EmbedOne(ctx, r.embedder, reindex.SpellText(page.Title, page.Summary, page.Aliases, page.Categories))
+		if err != nil {
+			return false, fmt.Errorf("embedding failed: %w", err)
+		}
+		active, err = r.store.GetSpellEmbeddingModel(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to read spell embedding model: %w", err)
+		}
+	}
+
+	err = r.inTx(ctx, func(q store) error {
+		if err := q.UpdateSpellFromWiki(ctx, params); err != nil {
+			return fmt.Errorf("failed to update spell: %w", err)
+		}
+
+		if reembed {
+			literal := retrieval.VectorLiteral(vec)
+			if err := q.UpsertSpellEmbedding(ctx, db.UpsertSpellEmbeddingParams{
+				Pageid:    pageid,
+				Model:     model,
+				Embedding: literal,
+			}); err != nil {
+				return fmt.Errorf("failed to store vector: %w", err)
+			}
+			// Vectors other models computed from the old text are stale;
+			// dropping them makes a reindex run pick the spell up again
+			if err := q.DeleteOtherSpellEmbeddings(ctx, db.DeleteOtherSpellEmbeddingsParams{
+				Pageid: pageid,
+				Model:  model,
+			}); err != nil {
+				return fmt.Errorf("failed to drop stale vectors: %w", err)
+			}
+			if !active.Valid || active.String == model {
+				if err := q.SetSpellEmbedding(ctx, db.SetSpellEmbeddingParams{
+					Pageid:    pageid,
+					Embedding: literal,
+				}); err != nil {
+					return fmt.Errorf("failed to update vector: %w", err)
+				}
+			} else {
+				log.Printf("wikisync: pageid %d: retrieval uses %s, leaving its vector to cmd/reindex", pageid, active.String)
+			}
+		}
+
+		if err := q.InsertSpellChange(ctx, db.InsertSpellChangeParams{
+			Pageid:        pageid,
+			Kind:          db.SpellChangeKindEnumUpdated,
+			OldRevID:      oldRev,
+			NewRevID:      params.PageRevID,
+			ChangedFields: changed,
+			Reembedded:    reembed,
+		}); err != nil {
+			return fmt.Errorf("failed to log change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("wikisync: %s (%d) revision %d -> %d, changed %v", page.Title, pageid, oldRev.Int64, page.Revision.RevID, changed)
+	return reembed, nil
+}
+
+// recordMissing logs a page that is gone from the wiki, once until it
+// reappears
+func (r *Refresher) recordMissing(ctx context.Context, row db.ListSpellRevisionsRow) error {
+	kind, err := r.store.GetLatestSpellChangeKind(ctx, row.Pageid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if kind == db.SpellChangeKindEnumMissing {
+		return nil
+	}
+
+	log.Printf("wikisync: pageid %d is missing from the wiki", row.Pageid)
+	return r.store.InsertSpellChange(ctx, db.InsertSpellChangeParams{
+		Pageid:        row.Pageid,
+		Kind:          db.SpellChangeKindEnumMissing,
+		OldRevID:      row.PageRevID,
+		ChangedFields: []string{},
+	})
+}
+
+func updateParams(page *Page) (db.UpdateSpellFromWikiParams, error) {
+	var infobox pqtype.NullRawMessage
+	if page.Infobox != "" {
+		raw, err := json.Marshal(map[string]string{"raw": page.Infobox})
+		if err != nil {
+			return db.UpdateSpellFromWikiParams{}, err
+		}
+		infobox = pqtype.NullRawMessage{RawMessage: raw, Valid: true}
+	}
+	sections, err := json.Marshal(page.Sections)
+	if err != nil {
+		return db.UpdateSpellFromWikiParams{}, err
+	}
+	outlinks, err := json.Marshal(page.Outlinks)
+	if err != nil {
+		return db.UpdateSpellFromWikiParams{}, err
+	}
+
+	return db.UpdateSpellFromWikiParams{
+		Pageid:              page.PageID,
+		Title:               page.Title,
+		Url:                 page.URL,
+		Summary:             sql.NullString{String: page.Summary, Valid: true},
+		UsedByDoctorStrange: page.UsedByDoctorStrange,
+		PageRevID:           sql.NullInt64{Int64: page.Revision.RevID, Valid: true},
+		LastRevTs:           sql.NullTime{Time: page.Revision.Timestamp, Valid: !page.Revision.Timestamp.IsZero()},
+		ImageUrl:            sql.NullString{String: page.ImageURL, Valid: page.ImageURL != ""},
+		Categories:          page.Categories,
+		Realities:           page.Realities,
+		FirstAppearance:     sql.NullString{String: page.FirstAppearance, Valid: page.FirstAppearance != ""},
+		Aliases:             page.Aliases,
+		Infobox:             infobox,
+		Sections:            pqtype.NullRawMessage{RawMessage: sections, Valid: true},
+		Outlinks:            pqtype.NullRawMessage{RawMessage: outlinks, Valid: true},
+	}, nil
+}
+
+// changedFields names the spell columns a refresh alters
+func changedFields(old db.GetSpellForRefreshRow, fresh db.UpdateSpellFromWikiParams) []string {
+	changed := []string{}
+	add := func(field string, differs bool) {
+		if differs {
+			changed = append(changed, field)
+		}
+	}
+	add("title", old.Title != fresh.Title)
+	add("url", old.Url != fresh.Url)
+	add("summary", old.Summary.String != fresh.Summary.String)
+	add("used_by_doctor_strange", old.UsedByDoctorStrange != fresh.UsedByDoctorStrange)
+	add("image_url", old.ImageUrl.String != fresh.ImageUrl.String)
+	add("categories", !slices.Equal(old.Categories, fresh.Categories))
+	add("realities", !slices.Equal(old.Realities, fresh.Realities))
+	add("first_appearance", old.FirstAppearance.String != fresh.FirstAppearance.String)
+	add("aliases", !slices.Equal(old.Aliases, fresh.Aliases))
+	add("infobox", !jsonEqual(old.Infobox, fresh.Infobox))
+	add("sections", !jsonEqual(old.Sections, fresh.Sections))
+	add("outlinks", !jsonEqual(old.Outlinks, fresh.Outlinks))
+	return changed
+}
+
+// jsonEqual compares JSON documents by value, since JSONB does not keep the
+// formatting it was written with
+func jsonEqual(a, b pqtype.NullRawMessage) bool {
+	if a.Valid != b.Valid {
+		return false
+	}
+	if !a.Valid {
+		return true
+	}
+	var av, bv any
+	if json.Unmarshal(a.RawMessage, &av) != nil || json.Unmarshal(b.RawMessage, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func getenv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,355 @@
+package wikisync
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ieeemumsb/Sinepsis/backend/internal/db"
+)
+
+// stubPage is a page served by the stub wiki
+type stubPage struct {
+	title    string
+	revID    int64
+	html     string
+	wikitext string
+	image    string
+}
+
+// stubWiki serves the parts of the MediaWiki API the Client uses and counts
+// the pages parsed
+type stubWiki struct {
+	mu     sync.Mutex
+	pages  map[int32]stubPage
+	parsed map[int32]int
+}
+
+func (s *stubWiki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	var out any
+	switch q.Get("action") {
+	case "parse":
+		id, _ := strconv.Atoi(q.Get("pageid"))
+		page, ok := s.pages[int32(id)]
+		if !ok {
+			out = map[string]any{"error": map[string]string{"code": "nosuchpageid", "info": "no such page"}}
+			break
+		}
+		s.parsed[int32(id)]++
+		out = map[string]any{"parse": map[string]any{
+			"title":    page.title,
+			"pageid":   id,
+			"text":     page.html,
+			"wikitext": page.wikitext,
+			"links":    []map[string]any{},
+		}}
+	case "query":
+		var pages []map[string]any
+		for _, raw := range strings.Split(q.Get("pageids"), "|") {
+			id, _ := strconv.Atoi(raw)
+			page, ok := s.pages[int32(id)]
+			if !ok {
+				pages = append(pages, map[string]any{"pageid": id, "missing": true})
+				continue
+			}
+			entry := map[string]any{
+				"pageid": id,
+				"title":  page.title,
+				"revisions": []map[string]any{{
+					"revid":     page.revID,
+					"timestamp": "2025-08-30T10:00:00Z",
+				}},
+				"categories": []map[string]any{{"title": "Category:Magic Spells"}},
+			}
+			if page.image != "" {
+				entry["original"] = map[string]any{"source": page.image}
+			}
+			pages = append(pages, entry)
+		}
+		out = map[string]any{"query": map[string]any{"pages": pages}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// fakeStore keeps spells and their change log in memory
+type fakeStore struct {
+	spells  map[int32]db.GetSpellForRefreshRow
+	changes []db.InsertSpellChangeParams
+}
+
+func (f *fakeStore) ListSpellRevisions(_ context.Context, arg db.ListSpellRevisionsParams) ([]db.ListSpellRevisionsRow, error) {
+	var ids []int32
+	for id := range f.spells {
+		if id > arg.AfterPageid {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	rows := []db.ListSpellRevisionsRow{}
+	for _, id := range ids[:min(len(ids), int(arg.Lim))] {
+		rows = append(rows, db.ListSpellRevisionsRow{Pageid: id, PageRevID: f.spells[id].PageRevID})
+	}
+	return rows, nil
+}
+
+func (f *fakeStore) GetSpellForRefresh(_ context.Context, pageid int32) (db.GetSpellForRefreshRow, error) {
+	row, ok := f.spells[pageid]
+	if !ok {
+		return row, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (f *fakeStore) GetSpellEmbeddingModel(context.Context) (sql.NullString, error) {
+	return sql.NullString{String: "test-embed", Valid: true}, nil
+}
+
+func (f *fakeStore) GetLatestSpellChangeKind(_ context.Context, pageid int32) (db.SpellChangeKindEnum, error) {
+	for i := len(f.changes) - 1; i >= 0; i-- {
+		if f.changes[i].Pageid == pageid {
+			return f.changes[i].Kind, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func (f *fakeStore) UpdateSpellFromWiki(_ context.Context, arg db.UpdateSpellFromWikiParams) error {
+	f.spells[arg.Pageid] = storedRow(arg)
+	return nil
+}
+
+func (f *fakeStore) UpsertSpellEmbedding(context.Context, db.UpsertSpellEmbeddingParams) error {
+	return nil
+}
+
+func (f *fakeStore) DeleteOtherSpellEmbeddings(context.Context, db.DeleteOtherSpellEmbeddingsParams) error {
+	return nil
+}
+
+func (f *fakeStore) SetSpellEmbedding(context.Context, db.SetSpellEmbeddingParams) error {
+	return nil
+}
+
+func (f *fakeStore) InsertSpellChange(_ context.Context, arg db.InsertSpellChangeParams) error {
+	f.changes = append(f.changes, arg)
+	return nil
+}
+
+// countingEmbedder returns a fixed vector and counts the texts embedded
+type countingEmbedder struct {
+	texts []string
+}
+
+func (e *countingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.texts = append(e.texts, texts...)
+	out := make([][]float32, len(texts))
+	for i := range out {
+		out[i] = []float32{0.1, 0.2, 0.3}
+	}
+	return out, nil
+}
+
+func (e *countingEmbedder) Model() string { return "test-embed" }
+
+func storedRow(p db.UpdateSpellFromWikiParams) db.GetSpellForRefreshRow {
+	return db.GetSpellForRefreshRow{
+		Pageid:              p.Pageid,
+		Title:               p.Title,
+		Url:                 p.Url,
+		Summary:             p.Summary,
+		UsedByDoctorStrange: p.UsedByDoctorStrange,
+		PageRevID:           p.PageRevID,
+		ImageUrl:            p.ImageUrl,
+		Categories:          p.Categories,
+		Realities:           p.Realities,
+		FirstAppearance:     p.FirstAppearance,
+		Aliases:             p.Aliases,
+		Infobox:             p.Infobox,
+		Sections:            p.Sections,
+		Outlinks:            p.Outlinks,
+	}
+}
+
+type harness struct {
+	wiki     *stubWiki
+	store    *fakeStore
+	embedder *countingEmbedder
+	r        *Refresher
+}
+
+// newHarness serves pages from a stub wiki and stores each of them as the
+// refresher itself would have, so a run against unchanged pages is a no-op
+func newHarness(t *testing.T, pages map[int32]stubPage) *harness {
+	t.Helper()
+	wiki := &stubWiki{pages: pages, parsed: map[int32]int{}}
+	srv := httptest.NewServer(wiki)
+	t.Cleanup(srv.Close)
+
+	h := &harness{wiki: wiki, store: &fakeStore{spells: map[int32]db.GetSpellForRefreshRow{}}, embedder: &countingEmbedder{}}
+	h.r = &Refresher{
+		store:    h.store,
+		inTx:     func(_ context.Context, fn func(q store) error) error { return fn(h.store) },
+		wiki:     NewClient(srv.URL, "https://wiki.test", 0),
+		embedder: h.embedder,
+	}
+
+	for id := range pages {
+		page, err := h.r.wiki.Fetch(context.Background(), id)
+		if err != nil {
+			t.Fatalf("fetch %d: %v", id, err)
+		}
+		params, err := updateParams(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.store.spells[id] = storedRow(params)
+	}
+	wiki.parsed = map[int32]int{}
+	return h
+}
+
+// edit changes a page on the stub wiki as a new revision
+func (h *harness) edit(id int32, fn func(p *stubPage)) {
+	h.wiki.mu.Lock()
+	defer h.wiki.mu.Unlock()
+	p := h.wiki.pages[id]
+	fn(&p)
+	p.revID++
+	h.wiki.pages[id] = p
+}
+
+func (h *harness) run(t *testing.T) Result {
+	t.Helper()
+	res, err := h.r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return res
+}
+
+func spellPage(title, summary string, revID int64) stubPage {
+	return stubPage{
+		title:    title,
+		revID:    revID,
+		html:     `<div class="mw-parser-output"><p>` + summary + `</p></div>`,
+		wikitext: "{{Infobox Spell\n| aliases = " + title + " Spell\n}}",
+		image:    "https://img.test/" + strconv.FormatInt(revID, 10) + ".png",
+	}
+}
+
+func TestRunSkipsUnchangedRevision(t *testing.T) {
+	h := newHarness(t, map[int32]stubPage{
+		101: spellPage("Crimson Bands of Cyttorak", "A binding spell.", 5001),
+	})
+
+	res := h.run(t)
+
+	if res.Checked != 1 || res.Updated != 0 || res.Reembedded != 0 {
+		t.Fatalf("result = %+v, want one spell checked and nothing updated", res)
+	}
+	if n := h.wiki.parsed[101]; n != 0 {
+		t.Errorf("unchanged page parsed %d times, want 0", n)
+	}
+	if len(h.store.changes) != 0 {
+		t.Errorf("recorded %d changes, want none", len(h.store.changes))
+	}
+}
+
+func TestRunUpdatesChangedPage(t *testing.T) {
+	h := newHarness(t, map[int32]stubPage{
+		101: spellPage("Crimson Bands of Cyttorak", "A binding spell.", 5001),
+	})
+	h.edit(101, func(p *stubPage) {
+		p.html = `<div class="mw-parser-output"><p>A binding spell of crimson bands.</p></div>`
+	})
+
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	res := h.run(t)
+
+	if res.Updated != 1 {
+		t.Fatalf("result = %+v, want one update", res)
+	}
+	if len(h.store.changes) != 1 {
+		t.Fatalf("recorded %d changes, want 1", len(h.store.changes))
+	}
+	change := h.store.changes[0]
+	if change.Kind != db.SpellChangeKindEnumUpdated || change.OldRevID.Int64 != 5001 || change.NewRevID.Int64 != 5002 {
+		t.Errorf("change = %+v, want updated 5001 -> 5002", change)
+	}
+	if !slices.Equal(change.ChangedFields, []string{"summary"}) {
+		t.Errorf("changed fields = %v, want [summary]", change.ChangedFields)
+	}
+	if got := h.store.spells[101].Summary.String; got != "A binding spell of crimson bands." {
+		t.Errorf("stored summary = %q", got)
+	}
+	if !strings.Contains(logs.String(), "revision 5001 -> 5002, changed [summary]") {
+		t.Errorf("log does not name the changed fields: %q", logs.String())
+	}
+}
+
+func TestRunReembedsOnlyWhenTextChanged(t *testing.T) {
+	h := newHarness(t, map[int32]stubPage{
+		101: spellPage("Crimson Bands of Cyttorak", "A binding spell.", 5001),
+		102: spellPage("Shield of the Seraphim", "A protective spell.", 6001),
+	})
+	h.edit(101, func(p *stubPage) {
+		p.html = `<div class="mw-parser-output"><p>A stronger binding spell.</p></div>`
+	})
+	// Only the image changes, which the embedded text does not include
+	h.edit(102, func(p *stubPage) { p.image = "https://img.test/new.png" })
+
+	res := h.run(t)
+
+	if res.Updated != 2 || res.Reembedded != 1 {
+		t.Fatalf("result = %+v, want two updates and one re-embedding", res)
+	}
+	if len(h.embedder.texts) != 1 || !strings.HasPrefix(h.embedder.texts[0], "Crimson Bands of Cyttorak") {
+		t.Errorf("embedded %q, want only the Crimson Bands text", h.embedder.texts)
+	}
+	for _, c := range h.store.changes {
+		if want := c.Pageid == 101; c.Reembedded != want {
+			t.Errorf("pageid %d reembedded = %v, want %v", c.Pageid, c.Reembedded, want)
+		}
+	}
+}
+
+func TestRunRecordsMissingPageOnce(t *testing.T) {
+	h := newHarness(t, map[int32]stubPage{
+		101: spellPage("Crimson Bands of Cyttorak", "A binding spell.", 5001),
+	})
+	h.wiki.mu.Lock()
+	delete(h.wiki.pages, 101)
+	h.wiki.mu.Unlock()
+
+	for i := 0; i < 2; i++ {
+		if res := h.run(t); res.Missing != 1 {
+			t.Fatalf("run %d: result = %+v, want one missing", i+1, res)
+		}
+	}
+
+	if len(h.store.changes) != 1 {
+		t.Fatalf("recorded %d changes over two runs, want 1", len(h.store.changes))
+	}
+	if c := h.store.changes[0]; c.Kind != db.SpellChangeKindEnumMissing || c.OldRevID.Int64 != 5001 {
+		t.Errorf("change = %+v, want missing at revision 5001", c)
+	}
+}
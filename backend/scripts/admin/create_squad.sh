@@ -0,0 +1,15 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable to an admin's token before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/admin"
+COMMANDER_ID="bdf34752-2100-4c36-aa6d-db953f91ea18" # Replace with the commander's user ID
+
+curl -X POST "$BASE_URL/squads" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "name": "Strike Team Alpha",
+    "commander_id": "'$COMMANDER_ID'"
+}'
@@ -0,0 +1,9 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable to an admin's token before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/admin"
+
+curl -X GET "$BASE_URL/embedding-cache" \
+-H "Authorization: Bearer $TOKEN"
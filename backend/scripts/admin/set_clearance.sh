@@ -0,0 +1,14 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable to an admin's token before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/admin"
+USER_ID="bdf34752-2100-4c36-aa6d-db953f91ea18" # Replace with an actual user ID
+
+curl -X PUT "$BASE_URL/users/$USER_ID/clearance" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "clearance_level": 2
+}'
@@ -0,0 +1,16 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable to an admin's token before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/admin"
+USER_ID="bdf34752-2100-4c36-aa6d-db953f91ea18" # Replace with an actual user ID
+SQUAD_ID="7620b092-3034-4703-bcd7-c0a2edf5aaad" # Replace with an actual squad ID
+
+curl -X PUT "$BASE_URL/users/$USER_ID/role" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "role": "agent",
+    "squad_id": "'$SQUAD_ID'"
+}'
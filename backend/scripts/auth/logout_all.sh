@@ -0,0 +1,8 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+
+curl -X POST http://localhost:8080/api/auth/logout-all \
+-H "Authorization: Bearer $TOKEN"
@@ -0,0 +1,9 @@
+#!/bin/bash
+
+# Set the REFRESH_TOKEN environment variable before running this script:
+# export REFRESH_TOKEN="refresh_token_from_login"
+curl -X POST http://localhost:8080/api/auth/refresh \
+-H "Content-Type: application/json" \
+-d '{
+  "refresh_token": "'"$REFRESH_TOKEN"'"
+}'
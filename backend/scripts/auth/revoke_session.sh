@@ -0,0 +1,9 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+SESSION_ID="your_session_id_here" # Replace with an id listed by sessions.sh
+
+curl -X DELETE "http://localhost:8080/api/auth/sessions/$SESSION_ID" \
+-H "Authorization: Bearer $TOKEN"
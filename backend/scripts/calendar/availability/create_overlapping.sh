@@ -0,0 +1,19 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+START=$(date -u -d "+1 day" +"%Y-%m-%dT10:00:00Z")
+END=$(date -u -d "+1 day" +"%Y-%m-%dT11:00:00Z")
+
+# An event overlapping another is refused with a 409 listing the conflicts
+# unless allow_conflict=true is sent
+curl -X POST "$BASE_URL/events?allow_conflict=true" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "title": "Overlapping briefing",
+    "start_time": "'$START'",
+    "end_time": "'$END'"
+}'
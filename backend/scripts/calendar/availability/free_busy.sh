@@ -0,0 +1,11 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+FROM=$(date -u +"%Y-%m-%dT00:00:00Z")
+TO=$(date -u -d "+7 days" +"%Y-%m-%dT00:00:00Z")
+
+curl -X GET "$BASE_URL/free-busy?from=$FROM&to=$TO" \
+-H "Authorization: Bearer $TOKEN"
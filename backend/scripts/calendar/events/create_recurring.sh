@@ -0,0 +1,18 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+
+curl -X POST "$BASE_URL/events" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "title": "Weekly patrol briefing",
+    "description": "Recurring test event from a script.",
+    "start_time": "'$(date -u +"%Y-%m-%dT09:00:00Z")'",
+    "end_time": "'$(date -u +"%Y-%m-%dT09:30:00Z")'",
+    "rrule": "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10",
+    "timezone": "America/New_York"
+}'
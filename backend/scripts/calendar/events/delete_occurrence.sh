@@ -0,0 +1,12 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+EVENT_ID="your_event_id_here" # Replace with an actual recurring event ID
+OCCURRENCE="2026-10-19T13:00:00Z" # Replace with the occurrence's recurrence_id
+SCOPE="this" # this, following or all
+
+curl -X DELETE "$BASE_URL/events/$EVENT_ID?scope=$SCOPE&occurrence=$OCCURRENCE" \
+-H "Authorization: Bearer $TOKEN"
@@ -1,6 +1,11 @@
-BASE_URL="http://localhost:8080/api/calendar"
+#!/bin/bash
 
+# Lists every user's events; the token must belong to an admin.
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
 TOKEN="${JWT_TOKEN}"
 
+BASE_URL="http://localhost:8080/api/calendar"
+
 curl -X GET "$BASE_URL/events-all" \
 -H "Authorization: Bearer $TOKEN"
@@ -1,5 +1,6 @@
 #!/bin/bash
 
+# Returns the first page and its next_cursor; see get_page.sh for the rest.
 # Set the JWT_TOKEN environment variable before running this script:
 # export JWT_TOKEN="your_auth_token_here"
 TOKEN="${JWT_TOKEN}"
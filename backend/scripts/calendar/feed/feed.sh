@@ -0,0 +1,7 @@
+#!/bin/bash
+
+# The feed needs no JWT; the feed token from create_token.sh authenticates it
+BASE_URL="http://localhost:8080/api/calendar"
+FEED_TOKEN="your_feed_token_here" # Replace with the token from create_token.sh
+
+curl -X GET "$BASE_URL/feed/$FEED_TOKEN.ics"
@@ -0,0 +1,19 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+
+ICS_FILE="schedule.ics" # Replace with the path of an .ics file
+
+# Preview first: nothing is written with dry_run=true
+curl -X POST "$BASE_URL/import?dry_run=true" \
+-H "Authorization: Bearer $TOKEN" \
+-F "file=@$ICS_FILE"
+
+echo
+
+curl -X POST "$BASE_URL/import" \
+-H "Authorization: Bearer $TOKEN" \
+-F "file=@$ICS_FILE"
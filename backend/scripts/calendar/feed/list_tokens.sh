@@ -0,0 +1,9 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+
+curl -X GET "$BASE_URL/feed-tokens" \
+-H "Authorization: Bearer $TOKEN"
@@ -0,0 +1,10 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+TOKEN_ID="your_feed_token_id_here" # Replace with an actual feed token ID
+
+curl -X DELETE "$BASE_URL/feed-tokens/$TOKEN_ID" \
+-H "Authorization: Bearer $TOKEN"
@@ -0,0 +1,16 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+MISSION_ID="8f0e9173-22fc-4ac5-b51a-d15ff41f021a" # Replace with an actual mission ID
+MISSION_ID=$(echo "$MISSION_ID" | tr -d '[:space:]')
+
+curl -X POST "$BASE_URL/missions/$MISSION_ID/ask" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "query": "What did the team find at the eastern ward?",
+    "limit": 6
+}'
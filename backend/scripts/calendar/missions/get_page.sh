@@ -0,0 +1,12 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/calendar"
+FROM=$(date -u -d "-90 days" +"%Y-%m-%dT00:00:00Z")
+TO=$(date -u +"%Y-%m-%dT23:59:59Z")
+CURSOR="" # Replace with next_cursor from the previous page
+
+curl -X GET "$BASE_URL/missions?from=$FROM&to=$TO&mission_type=recon&threat_level=high&limit=20&cursor=$CURSOR" \
+-H "Authorization: Bearer $TOKEN"
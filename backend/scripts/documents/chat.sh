@@ -0,0 +1,18 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+# Leave document_ids out to search all of your documents.
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api"
+DOCUMENT_ID="5b2e8c1a-7d4f-4a9b-8e3c-2f1d0a9b8c7d" # Replace with an actual document ID
+DOCUMENT_ID=$(echo "$DOCUMENT_ID" | tr -d '[:space:]')
+
+curl -X POST "$BASE_URL/documents/chat" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "query": "Who holds the keys to the eastern ward?",
+    "document_ids": ["'"$DOCUMENT_ID"'"],
+    "limit": 6
+}'
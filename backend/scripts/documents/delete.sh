@@ -0,0 +1,11 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api"
+DOCUMENT_ID="5b2e8c1a-7d4f-4a9b-8e3c-2f1d0a9b8c7d" # Replace with an actual document ID
+DOCUMENT_ID=$(echo "$DOCUMENT_ID" | tr -d '[:space:]')
+
+curl -X DELETE "$BASE_URL/documents/$DOCUMENT_ID" \
+-H "Authorization: Bearer $TOKEN"
@@ -0,0 +1,12 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api"
+FILE_PATH="./briefing.pdf" # Replace with the path to a .txt, .md or .pdf file
+
+curl -X POST "$BASE_URL/documents" \
+-H "Authorization: Bearer $TOKEN" \
+-F "document=@$FILE_PATH" \
+-F "title=Kamar-Taj briefing"
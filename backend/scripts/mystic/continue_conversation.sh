@@ -0,0 +1,16 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+CONVERSATION_ID="3f1c2a9e-5b7d-4e8f-9a6b-1c2d3e4f5a6b" # Replace with an actual conversation ID
+CONVERSATION_ID=$(echo "$CONVERSATION_ID" | tr -d '[:space:]')
+
+curl -X POST "$BASE_URL/conversations/$CONVERSATION_ID/messages" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "query": "which of those did Strange use in Earth-616?",
+    "limit": 5
+}'
@@ -0,0 +1,11 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+SPELL_ID="2153" # Replace with an actual spell pageid
+SPELL_ID=$(echo "$SPELL_ID" | tr -d '[:space:]')
+
+curl -X GET "$BASE_URL/spells/$SPELL_ID" \
+-H "Authorization: Bearer $TOKEN"
@@ -0,0 +1,14 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+# Either end can be a spell pageid or a spell or entity title
+FROM="2153" # Replace with an actual spell pageid
+TO="Dormammu"
+
+curl -G "$BASE_URL/graph/path" \
+-H "Authorization: Bearer $TOKEN" \
+--data-urlencode "from=$FROM" \
+--data-urlencode "to=$TO"
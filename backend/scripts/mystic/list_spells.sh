@@ -0,0 +1,17 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+# Pass the next_cursor of a previous page to continue from it
+CURSOR=""
+
+curl -G "$BASE_URL/spells" \
+-H "Authorization: Bearer $TOKEN" \
+--data-urlencode "limit=20" \
+--data-urlencode "sort=title" \
+--data-urlencode "reality=Earth-616" \
+--data-urlencode "category=Magic Spells" \
+--data-urlencode "used_by_doctor_strange=true" \
+--data-urlencode "cursor=$CURSOR"
@@ -0,0 +1,19 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+
+curl -X POST "$BASE_URL/query" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "query": "binding spells",
+    "limit": 5,
+    "filters": {
+        "realities": ["Earth-616"],
+        "used_by_doctor_strange": true,
+        "max_distance": 1.2
+    }
+}'
@@ -0,0 +1,20 @@
+#!/bin/bash
+
+# Set the JWT_TOKEN environment variable before running this script:
+# export JWT_TOKEN="your_auth_token_here"
+TOKEN="${JWT_TOKEN}"
+BASE_URL="http://localhost:8080/api/mystic"
+
+# Favour exact spell names and aliases over semantic matches
+curl -X POST "$BASE_URL/query" \
+-H "Authorization: Bearer $TOKEN" \
+-H "Content-Type: application/json" \
+-d '{
+    "query": "Crimson Bands of Cyttorak",
+    "limit": 5,
+    "weights": {
+        "vector": 0.5,
+        "text": 1,
+        "name": 2
+    }
+}'
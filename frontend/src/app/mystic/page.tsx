@@ -45,6 +45,24 @@ import Link from "next/link";
 // 🔑 Use the real helpers (remove the hardcoded mocks)
 import { fetchJson, humanizeError } from '@/utils/http';
 
+// A spell the streamed answer cites, as sent in the "done" event
+type MysticCitation = {
+  footnote: number;
+  index: number;
+  pageid: number;
+  title: string;
+  url: string;
+};
+
+// A spell retrieved for the query, as sent in the "results" event
+type MysticResult = {
+  pageid: number;
+  title: string;
+  summary: string;
+  url: string;
+  score: number;
+};
+
 type ChatMessage = {
   id: string;
   role: 'user' | 'assistant' | 'system';
@@ -73,6 +91,8 @@ export default function MysticRAGChat() {
   // Archive state
   const [aiQuery, setAiQuery] = useState('');
   const [aiResponse, setAiResponse] = useState('');
+  const [aiResults, setAiResults] = useState<MysticResult[]>([]);
+  const [aiCitations, setAiCitations] = useState<MysticCitation[]>([]);
   const [isAiSearching, setIsAiSearching] = useState(false);
   const [searchQuery, setSearchQuery] = useState('');
   const [selectedCategory, setSelectedCategory] = useState('all');
@@ -112,10 +132,12 @@ export default function MysticRAGChat() {
     setIsAiSearching(true);
   
     try {
-      const response = await fetch("http://localhost:8080/api/mystic/query", {
+      const token = localStorage.getItem("token");
+      const response = await fetch("http://localhost:8080/api/mystic/query/stream", {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
+          ...(token ? { Authorization: `Bearer ${token}` } : {}),
         },
         body: JSON.stringify({
           query: aiQuery,
@@ -123,16 +145,46 @@ export default function MysticRAGChat() {
         }),
       });
   
-      if (!response.ok) {
+      if (!response.ok || !response.body) {
         throw new Error(`HTTP error! Status: ${response.status}`);
       }
   
-      const data = await response.json();
-      const aiResponse = data.data;
-      console.log("🔮 Mystic RAG Response:", aiResponse);
+      // Read the SSE stream and append answer tokens as they arrive
+      const reader = response.body.getReader();
+      const decoder = new TextDecoder();
+      let buffer = "";
+      let answer = "";
+      setAiResponse("");
+      setAiResults([]);
+      setAiCitations([]);
+  
+      while (true) {
+        const { done, value } = await reader.read();
+        if (done) break;
+        buffer += decoder.decode(value, { stream: true });
+  
+        const events = buffer.split("\n\n");
+        buffer = events.pop() ?? "";
+        for (const raw of events) {
+          const event = raw.match(/^event: (.*)$/m)?.[1];
+          const data = raw.match(/^data: (.*)$/m)?.[1];
+          if (!event || !data) continue;
+  
+          const payload = JSON.parse(data);
+          if (event === "token") {
+            answer += payload.text;
+            setAiResponse(answer);
+          } else if (event === "results") {
+            setAiResults(Array.isArray(payload) ? payload : []);
+          } else if (event === "done") {
+            setAiCitations(Array.isArray(payload.citations) ? payload.citations : []);
+          } else if (event === "error") {
+            throw new Error(payload.message);
+          }
+        }
+      }
   
-      // Display only the answer field
-      setAiResponse(data.data.answer || "No answer received");
+      if (!answer) setAiResponse("No answer received");
     } catch (error) {
       console.error("❌ RAG search failed:", error);
       setAiResponse("Error occurred while searching");
@@ -157,10 +209,13 @@ export default function MysticRAGChat() {
 
       for (const endpoint of endpoints) {
         try {
-          const response = await fetch(endpoint);
+          const token = localStorage.getItem('token');
+          const response = await fetch(endpoint, {
+            headers: token ? { Authorization: `Bearer ${token}` } : {},
+          });
           if (!response.ok) continue;
           const data = await response.json();
-          const spells = data?.data ?? data;
+          const spells = data?.data?.spells ?? data?.data ?? data;
 
           if (Array.isArray(spells)) {
             const normalized = spells.map((item: any) => ({
@@ -774,9 +829,40 @@ export default function MysticRAGChat() {
               <Alert className="mt-4">
                 <Sparkles className="h-4 w-4" />
                 <AlertTitle className="text-sm sm:text-base">AI Assistant Response</AlertTitle>
-                <AlertDescription className="text-sm sm:text-base">{aiResponse}</AlertDescription>
+                <AlertDescription className="text-sm sm:text-base">
+                  <p>{aiResponse}</p>
+                  {aiCitations.length > 0 && (
+                    <ol className="mt-3 space-y-1 text-xs text-muted-foreground">
+                      {aiCitations.map((c) => (
+                        <li key={c.pageid}>
+                          [{c.footnote}]{' '}
+                          {c.url ? (
+                            <a href={c.url} target="_blank" rel="noopener noreferrer" className="text-primary hover:underline">
+                              {c.title}
+                            </a>
+                          ) : (
+                            c.title
+                          )}
+                        </li>
+                      ))}
+                    </ol>
+                  )}
+                </AlertDescription>
               </Alert>
             )}
+
+            {aiResults.length > 0 && (
+              <div className="mt-4">
+                <h4 className="text-sm font-semibold mb-2 text-primary">Retrieved Spells</h4>
+                <div className="flex flex-wrap gap-2">
+                  {aiResults.map((r) => (
+                    <Badge key={r.pageid} variant="outline" className="text-xs" title={r.summary}>
+                      {r.title}
+                    </Badge>
+                  ))}
+                </div>
+              </div>
+            )}
             
             {/* Spell Cards from API Data - Based on RAG Response */}
             {artifacts.length > 0 && aiResponse && (
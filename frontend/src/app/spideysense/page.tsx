@@ -163,6 +163,28 @@ const transformEventData = (apiEvent: ApiEvent): TransformedEvent => {
   }
 }
 
+// The calendar listings are paged; follow next_cursor until every item is
+// loaded
+const fetchAllPages = async <T,>(url: string, key: string, token: string): Promise<T[]> => {
+  const items: T[] = []
+  let cursor = ""
+  do {
+    const pageUrl = cursor ? `${url}?cursor=${encodeURIComponent(cursor)}` : url
+    const res = await fetch(pageUrl, {
+      headers: {
+        Authorization: `Bearer ${token}`,
+      },
+    })
+    if (!res.ok) {
+      throw new Error(`Failed to fetch ${url}: ${res.status}`)
+    }
+    const body = await res.json()
+    items.push(...(body.data?.[key] ?? []))
+    cursor = body.data?.next_cursor ?? ""
+  } while (cursor)
+  return items
+}
+
 // Update your useEffect to use the transformation:
 useEffect(() => {
   const fetchOperations = async () => {
@@ -171,41 +193,20 @@ useEffect(() => {
       if (!token) return
 
       // Fetch missions
-      const missionsRes = await fetch("http://localhost:8080/api/calendar/missions", {
-        headers: {
-          Authorization: `Bearer ${token}`,
-        },
-      })
-      if (missionsRes.ok) {
-        const missionsData = await missionsRes.json()
-        console.log("Raw missions data:", missionsData) // Keep this for debugging
-        
-        // Transform the data to match component expectations
-        if (missionsData.data && Array.isArray(missionsData.data)) {
-          const transformedMissions = missionsData.data.map(transformMissionData)
-          console.log("Transformed missions:", transformedMissions) // Debug transformed data
-          setMissions(transformedMissions)
-        } else {
-          setMissions([])
-        }
-      }
+      const missionsData = await fetchAllPages<ApiMission>(
+        "http://localhost:8080/api/calendar/missions",
+        "missions",
+        token,
+      )
+      setMissions(missionsData.map(transformMissionData))
 
       // Fetch events
-      const eventsRes = await fetch("http://localhost:8080/api/calendar/events", {
-        headers: {
-          Authorization: `Bearer ${token}`,
-        },
-      })
-      if (eventsRes.ok) {
-        const eventsData = await eventsRes.json()
-        
-        if (eventsData.data && Array.isArray(eventsData.data)) {
-          const transformedEvents = eventsData.data.map(transformEventData)
-          setEvents(transformedEvents)
-        } else {
-          setEvents([])
-        }
-      }
+      const eventsData = await fetchAllPages<ApiEvent>(
+        "http://localhost:8080/api/calendar/events",
+        "events",
+        token,
+      )
+      setEvents(eventsData.map(transformEventData))
     } catch (error) {
       toast("Failed to load missions or events", {
         action: { label: "Dismiss", onClick: () => {} },